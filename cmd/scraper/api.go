@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/archive"
+)
+
+const (
+	defaultAPISince = "30d"
+	maxAPILimit     = 500
+)
+
+// runServe serves the REST API on -api-addr until interrupted. Scans only run on request,
+// and every scanned announcement is archived so /api/announcements can serve it.
+func runServe() {
+	d := &daemon{
+		scanner:   newScanner(),
+		startedAt: time.Now(),
+	}
+
+	if d.scanner.archive == nil {
+		a, err := archive.Open(*archiveDB)
+		if err != nil {
+			log.Fatalf("Fatal error opening archive: %v", err)
+		}
+		d.scanner.archive = a
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", d.handleStatus)
+	mux.HandleFunc("GET /api/matches", d.handleAPIMatches)
+	mux.HandleFunc("GET /api/announcements", d.handleAPIAnnouncements)
+	mux.HandleFunc("POST /api/scan", d.handleScan)
+
+	server := &http.Server{Addr: *apiAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving REST API on http://%s/api/ (archive %s).", *apiAddr, d.scanner.archive.Path())
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Fatal error serving REST API: %v", err)
+	}
+	log.Println("REST API stopped.")
+}
+
+// handleAPIMatches returns the matches notified on ?date= ("today" or YYYY-MM-DD).
+func (d *daemon) handleAPIMatches(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date != "" && date != "today" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			http.Error(w, "invalid date (expected YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	matches, err := d.matchesOn(date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, matches)
+}
+
+// handleAPIAnnouncements lists archived announcements, newest first. ?ticker= restricts them
+// to one company and ?q= runs a full-text search instead; ?since= and ?limit= bound the results.
+func (d *daemon) handleAPIAnnouncements(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	sinceStr := query.Get("since")
+	if sinceStr == "" {
+		sinceStr = defaultAPISince
+	}
+	since, err := parseSince(sinceStr, time.Now())
+	if err != nil {
+		http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := 50
+	if l := query.Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(limit, maxAPILimit)
+	}
+
+	var results []archive.Result
+	if q := query.Get("q"); q != "" {
+		results, err = d.scanner.archive.Search(r.Context(), q, since, limit)
+		if ticker := query.Get("ticker"); ticker != "" && err == nil {
+			results = filterResultsByTicker(results, ticker)
+		}
+	} else {
+		results, err = d.scanner.archive.Announcements(r.Context(), query.Get("ticker"), since, limit)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if results == nil {
+		results = []archive.Result{}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func filterResultsByTicker(results []archive.Result, ticker string) []archive.Result {
+	var kept []archive.Result
+	for _, r := range results {
+		if strings.EqualFold(r.Ticker, ticker) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
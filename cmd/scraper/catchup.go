@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+)
+
+// maxCatchUpDays bounds how far back -since can reach.
+const maxCatchUpDays = 31
+
+// run scans today's feed once or, with -since, catches up on every trading day since.
+func (s *scanner) run(ctx context.Context) {
+	if *sinceStr == "" {
+		s.scan(ctx)
+		return
+	}
+	s.catchUp(ctx, *sinceStr)
+}
+
+// catchUp scans the feed of each trading day from since up to and including today, oldest
+// first. Announcements already notified, on any day, are skipped.
+func (s *scanner) catchUp(ctx context.Context, since string) {
+	if *scrapePrevious {
+		log.Fatalf("Error: -since and -previous cannot be combined.")
+	}
+
+	today := s.clock.Now().In(s.loc)
+	from, err := parseSince(since, today)
+	if err != nil {
+		log.Fatalf("Invalid -since: %v", err)
+	}
+	dates := s.catchUpDates(from, today)
+	if len(dates) > maxCatchUpDays {
+		log.Fatalf("Invalid -since %s: reaches back %d trading days, the most is %d.", since, len(dates)-1, maxCatchUpDays)
+	}
+
+	if s.notified, err = s.history.NotificationIDs(); err != nil {
+		log.Printf("Warning: Could not load earlier notifications, matches reported on earlier days may repeat: %v", err)
+	}
+	defer func() { s.notified = nil }()
+
+	log.Printf("Catching up on %d feed(s): %s to %s.", len(dates), dates[0], dates[len(dates)-1])
+	for _, date := range dates {
+		if ctx.Err() != nil {
+			return
+		}
+		s.scanDate(ctx, date)
+	}
+}
+
+// catchUpDates returns the trading days on or after from and before today, then today,
+// as YYYY-MM-DD dates.
+func (s *scanner) catchUpDates(from, today time.Time) []string {
+	first := from.In(s.loc).Format("2006-01-02")
+	dates := []string{today.Format("2006-01-02")}
+	for day := s.calendar.PreviousTradingDay(today); day.Format("2006-01-02") >= first; day = s.calendar.PreviousTradingDay(day) {
+		dates = append(dates, day.Format("2006-01-02"))
+		if len(dates) > maxCatchUpDays {
+			break
+		}
+	}
+	slices.Reverse(dates)
+	return dates
+}
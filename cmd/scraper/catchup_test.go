@@ -0,0 +1,88 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/calendar"
+)
+
+func TestCatchUpDates(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal, err := calendar.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &scanner{loc: sydney, calendar: cal}
+	day := func(d string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", d+" 09:30", sydney)
+		return t
+	}
+
+	tests := []struct {
+		name      string
+		from      time.Time
+		today     time.Time
+		want      []string
+		wantCount int // checked instead of want when set
+	}{
+		{
+			name:  "today only",
+			from:  day("2026-10-16"),
+			today: day("2026-10-16"),
+			want:  []string{"2026-10-16"},
+		},
+		{
+			name:  "over a weekend",
+			from:  day("2026-10-09"),
+			today: day("2026-10-13"),
+			want:  []string{"2026-10-09", "2026-10-12", "2026-10-13"},
+		},
+		{
+			name:  "from a weekend",
+			from:  day("2026-10-10"),
+			today: day("2026-10-13"),
+			want:  []string{"2026-10-12", "2026-10-13"},
+		},
+		{
+			name:  "over Christmas",
+			from:  day("2026-12-23"),
+			today: day("2026-12-30"),
+			want:  []string{"2026-12-23", "2026-12-24", "2026-12-29", "2026-12-30"},
+		},
+		{
+			name:  "today is a weekend",
+			from:  day("2026-10-15"),
+			today: day("2026-10-17"),
+			want:  []string{"2026-10-15", "2026-10-16", "2026-10-17"},
+		},
+		{
+			name:  "date in UTC",
+			from:  time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+			today: day("2026-10-16"),
+			want:  []string{"2026-10-15", "2026-10-16"},
+		},
+		{
+			name:      "bounded",
+			from:      day("2025-01-01"),
+			today:     day("2026-10-16"),
+			wantCount: maxCatchUpDays + 1,
+		},
+	}
+	for _, tt := range tests {
+		got := s.catchUpDates(tt.from, tt.today)
+		if tt.wantCount > 0 {
+			if len(got) != tt.wantCount {
+				t.Errorf("%s: catchUpDates() returned %d dates, want %d", tt.name, len(got), tt.wantCount)
+			}
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: catchUpDates() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/archive"
+	"github.com/shanehull/annscraper/internal/history"
+	"github.com/shanehull/annscraper/internal/notify"
+	"github.com/shanehull/annscraper/internal/paths"
+	"github.com/shanehull/annscraper/internal/schema"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const commandsUsage = `Commands:
+  run                            Scan the feed once (the default when only flags are given)
+  history list|clear             Show or forget what has been reported today
+  history export [-format f]     Write today's history to stdout as json or csv
+  history import <file|->        Mark announcements as already reported (entries without keywords suppress all matches)
+  notifications [-all]           List stored notifications (-all includes deleted)
+  resend -id <id> [-channel c]   Re-deliver a stored notification (console, email, exec, desktop, ntfy, sms, teams, matrix)
+  share -id <id>                 Print a public link to a notification (served by the daemon's -share-addr)
+  delete -id <id>                Soft-delete a stored notification
+  restore -id <id>               Restore a soft-deleted notification
+  daemon [-interval d]           Run scans on a schedule, controlled over -socket (also run as annscraperd);
+                                 GET /stream on the socket or -stream-addr pushes new matches as SSE
+  serve [-api-addr a]            Serve a REST API (GET /api/matches?date=, GET /api/announcements?ticker=&q=, POST /api/scan)
+  status                         Show the running daemon's status
+  scan now                       Ask the daemon to scan immediately
+  matches [today|YYYY-MM-DD]     List the daemon's matches for a day
+  weekly [-since 7d]             Email (or print) a summary of recent matches, catalysts and dates
+  query <terms> [-since 30d]     Full-text search the -archive (words, "phrases", OR, NEAR, prefix*)
+  publish [-out dir]             Render stored notifications into a static site (index.html plus a page per day)
+  schema                         Print the JSON Schema of the match JSON written by the stream, MQTT, NATS, Kafka and uploads
+
+  help                           Show this help
+
+Global flags such as -smtp-server or -exec-command may follow the command.
+'annscraper -k ...' keeps working and is equivalent to 'annscraper run -k ...'.`
+
+// runCommand dispatches a subcommand. Subcommands share the global flag set so channel
+// settings (SMTP, exec, ...) can be given exactly as for a normal run.
+func runCommand(name string, args []string) {
+	notificationID := flag.String("id", "", "Notification ID (see 'annscraper notifications')")
+	channel := flag.String("channel", "console", "Channel to re-send to: console, email, exec, desktop, ntfy, sms, teams or matrix")
+	all := flag.Bool("all", false, "Include deleted notifications")
+	limit := flag.Int("limit", 20, "Maximum number of search results")
+	format := flag.String("format", "json", "History export/import format: json or csv")
+	out := flag.String("out", "site", "Directory 'publish' writes the static site to")
+
+	positional := parseInterleaved(args)
+	paths.SetDataDir(*dataDir)
+	setDisplayTimezone()
+
+	switch name {
+	case "run":
+		runOnce()
+	case "help":
+		flag.Usage()
+	case "daemon":
+		runDaemon()
+	case "serve":
+		runServe()
+	case "status", "scan", "matches":
+		runClientCommand(name, positional)
+	case "weekly":
+		runWeeklyCommand(cmp.Or(*sinceStr, "7d"))
+	case "query":
+		queryArchive(strings.Join(positional, " "), cmp.Or(*sinceStr, "30d"), *limit)
+	case "history":
+		runHistoryCommand(positional, *format)
+	case "publish":
+		runPublishCommand(*out)
+	case "schema":
+		os.Stdout.Write(schema.Match())
+	case "notifications":
+		listNotifications(openHistory(), *all)
+	case "resend":
+		resendNotification(openHistory(), requireID(*notificationID), *channel)
+	case "share":
+		printShareLink(openHistory(), requireID(*notificationID))
+	case "delete", "restore":
+		if err := openHistory().SetNotificationDeleted(requireID(*notificationID), name == "delete"); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		log.Printf("Notification %s %sd.", *notificationID, name)
+	default:
+		fmt.Printf("Unknown command %q.\n\n%s\n", name, commandsUsage)
+		os.Exit(2)
+	}
+}
+
+// parseInterleaved parses the global flags from args, allowing flags to appear after
+// positional arguments ("query lithium -since 7d"), and returns the positional arguments.
+func parseInterleaved(args []string) []string {
+	var positional []string
+	for {
+		if err := flag.CommandLine.Parse(args); err != nil {
+			os.Exit(2)
+		}
+		args = flag.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func openHistory() *history.Manager {
+	historyManager, err := history.NewManager(timezone, nil)
+	if err != nil {
+		log.Fatalf("Fatal error setting up history: %v", err)
+	}
+	historyManager.SetStore(openHistoryStore())
+	return historyManager
+}
+
+// openHistoryStore opens -history-store, nil for the history file.
+func openHistoryStore() history.Store {
+	store, err := history.OpenStore(*historyStore)
+	if err != nil {
+		log.Fatalf("Fatal error opening -history-store: %v", err)
+	}
+	return store
+}
+
+func requireID(id string) string {
+	if id == "" {
+		log.Fatalf("Error: -id is required.")
+	}
+	return id
+}
+
+func listNotifications(historyManager *history.Manager, includeDeleted bool) {
+	notifications, err := historyManager.Notifications(includeDeleted)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if len(notifications) == 0 {
+		fmt.Println("No stored notifications.")
+		return
+	}
+
+	for _, n := range notifications {
+		deleted := ""
+		if n.Deleted() {
+			deleted = " (deleted)"
+		}
+		fmt.Printf("%s  %s  %-6s %s%s\n", n.ID, n.SentAt.Format("2006-01-02 15:04"), n.Match.Match.Ticker, n.Match.Match.Title, deleted)
+	}
+}
+
+func resendNotification(historyManager *history.Manager, id, channel string) {
+	n, err := historyManager.Notification(id)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if n.Deleted() {
+		log.Fatalf("Error: notification %s is deleted. Restore it first with 'annscraper restore -id %s'.", id, id)
+	}
+
+	matches := []types.AnnotatedMatch{n.Match}
+
+	switch channel {
+	case "console":
+		notify.ReportMatches(matches, historyManager.HistoryFilePath())
+	case "email":
+		cfg := emailConfigFromFlags()
+		if !cfg.Enabled {
+			log.Fatalf("Error: email channel requires -to-email and credentials for -email-provider (e.g. -smtp-server, -smtp-user and -smtp-pass).")
+		}
+		notify.EmailMatches(matches, cfg)
+	case "exec":
+		cfg := execConfigFromFlags()
+		if !cfg.Enabled {
+			log.Fatalf("Error: exec channel requires -exec-command.")
+		}
+		notify.ExecMatches(matches, cfg)
+	case "desktop":
+		notify.DesktopMatches(matches)
+	case "ntfy":
+		cfg := ntfyConfigFromFlags()
+		if !cfg.Enabled {
+			log.Fatalf("Error: ntfy channel requires -ntfy-url.")
+		}
+		notify.NtfyMatches(matches, cfg)
+	case "sms":
+		cfg := smsConfigFromFlags()
+		if !cfg.Enabled {
+			log.Fatalf("Error: sms channel requires -twilio-sid, -twilio-token, -sms-from and -sms-to.")
+		}
+		cfg.AllMatches = true // an explicit resend overrides the price sensitive gate
+		notify.SMSMatches(matches, cfg)
+	case "teams":
+		cfg := teamsConfigFromFlags()
+		if !cfg.Enabled {
+			log.Fatalf("Error: teams channel requires -teams-webhook.")
+		}
+		notify.TeamsMatches(matches, cfg)
+	case "matrix":
+		cfg := matrixConfigFromFlags()
+		if !cfg.Enabled {
+			log.Fatalf("Error: matrix channel requires -matrix-homeserver, -matrix-token and -matrix-room.")
+		}
+		notify.MatrixMatches(matches, cfg)
+	default:
+		log.Fatalf("Error: unknown channel %q.", channel)
+	}
+}
+
+// runClientCommand forwards a command to the running daemon and prints its reply.
+func runClientCommand(name string, args []string) {
+	client := newDaemonClient(*socketPath)
+
+	switch name {
+	case "status":
+		var status daemonStatus
+		if err := client.call(http.MethodGet, "/status", &status); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		state := "idle"
+		if status.Scanning {
+			state = "scanning"
+		}
+		fmt.Printf("Daemon (pid %d) up since %s, %s, scanning every %s.\n", status.PID, status.StartedAt.Format("2006-01-02 15:04:05"), state, status.Interval)
+		if !status.NextScan.IsZero() {
+			fmt.Printf("Next scan at %s.\n", status.NextScan.Format("2006-01-02 15:04:05"))
+		}
+		if status.LastScan != nil {
+			printScanResult(status.LastScan)
+		}
+	case "scan":
+		if len(args) != 1 || args[0] != "now" {
+			log.Fatalf("Usage: annscraper scan now")
+		}
+		var result scanResult
+		if err := client.call(http.MethodPost, "/scan", &result); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		printScanResult(&result)
+	case "matches":
+		day := "today"
+		if len(args) > 0 {
+			day = args[0]
+		}
+		var notifications []history.StoredNotification
+		if err := client.call(http.MethodGet, "/matches?day="+url.QueryEscape(day), &notifications); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if len(notifications) == 0 {
+			fmt.Printf("No matches for %s.\n", day)
+			return
+		}
+		for _, n := range notifications {
+			fmt.Printf("%s  %s  %-6s %s\n", n.ID, n.SentAt.Format("15:04"), n.Match.Match.Ticker, n.Match.Match.Title)
+		}
+	}
+}
+
+func queryArchive(query, sinceStr string, limit int) {
+	if strings.TrimSpace(query) == "" {
+		log.Fatalf("Usage: annscraper query <terms> [-since 30d] [-limit 20]")
+	}
+
+	since, err := parseSince(sinceStr, time.Now())
+	if err != nil {
+		log.Fatalf("Invalid -since: %v", err)
+	}
+
+	a, err := archive.Open(*archiveDB)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	results, err := a.Search(context.Background(), query, since, limit)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No archived announcements match %q since %s.\n", query, since.Format("2006-01-02"))
+		return
+	}
+
+	for _, r := range results {
+		sensitive := ""
+		if r.PriceSensitive {
+			sensitive = " [$]"
+		}
+		fmt.Printf("%s  %-6s %s%s\n", r.Published.Format("2006-01-02"), r.Ticker, r.Title, sensitive)
+		fmt.Printf("    %s\n", strings.Join(strings.Fields(r.Snippet), " "))
+		fmt.Printf("    %s\n", r.URL)
+	}
+}
+
+// parseSince accepts a day count ("30d"), a Go duration ("12h") or a date ("2025-01-31").
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a day count, duration or YYYY-MM-DD date", s)
+}
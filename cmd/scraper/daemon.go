@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/history"
+	"github.com/shanehull/annscraper/internal/telemetry"
+)
+
+// daemonName is the binary name that starts the daemon directly (e.g. via a symlink).
+const daemonName = "annscraperd"
+
+// daemonStatus is returned by the daemon's /status endpoint.
+type daemonStatus struct {
+	PID       int         `json:"pid"`
+	StartedAt time.Time   `json:"started_at"`
+	Interval  string      `json:"interval"`
+	Scanning  bool        `json:"scanning"`
+	NextScan  time.Time   `json:"next_scan"`
+	LastScan  *scanResult `json:"last_scan,omitempty"`
+}
+
+// daemon runs scans on a schedule and answers control requests on a unix socket.
+type daemon struct {
+	scanner   *scanner
+	interval  time.Duration
+	startedAt time.Time
+
+	weeklyEnabled bool
+	weeklyDay     time.Weekday
+
+	stream *matchStream // receives each scan's new matches
+
+	scanMutex sync.Mutex // held for the duration of a scan
+
+	mutex    sync.Mutex
+	scanning bool
+	nextScan time.Time
+	lastScan *scanResult
+}
+
+func defaultSocketPath() string {
+	return filepath.Join(os.TempDir(), "annscraper", daemonName+".sock")
+}
+
+// runDaemon starts the scheduler and the control API and blocks until interrupted.
+func runDaemon() {
+	if *scanInterval <= 0 {
+		log.Fatalf("Invalid -interval %s (must be positive)", *scanInterval)
+	}
+
+	weeklyDay, weeklyEnabled, err := parseWeekday(*weeklyWrap)
+	if err != nil {
+		log.Fatalf("Invalid -weekly-wrap: %v", err)
+	}
+
+	d := &daemon{
+		scanner:       newScanner(),
+		interval:      *scanInterval,
+		startedAt:     time.Now(),
+		weeklyEnabled: weeklyEnabled,
+		weeklyDay:     weeklyDay,
+		stream:        newMatchStream(),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*socketPath), 0o755); err != nil {
+		log.Fatalf("Fatal error creating socket directory: %v", err)
+	}
+	if conn, err := net.Dial("unix", *socketPath); err == nil {
+		conn.Close()
+		log.Fatalf("Fatal error: a daemon is already listening on %s", *socketPath)
+	}
+	_ = os.Remove(*socketPath)
+
+	listener, err := net.Listen("unix", *socketPath)
+	if err != nil {
+		log.Fatalf("Fatal error listening on %s: %v", *socketPath, err)
+	}
+	defer os.Remove(*socketPath)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", d.handleStatus)
+	mux.HandleFunc("POST /scan", d.handleScan)
+	mux.HandleFunc("GET /matches", d.handleMatches)
+	mux.Handle("GET /stream", d.stream)
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Error serving control API: %v", err)
+		}
+	}()
+
+	shareServer := serveShares(d.scanner.history)
+	streamServer := serveStream(d.stream)
+
+	log.Printf("Daemon listening on %s, scanning every %s.", *socketPath, d.interval)
+
+	if *retryInterval > 0 {
+		go d.retryLoop(ctx, *retryInterval)
+	}
+
+	d.schedule(ctx)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = server.Shutdown(shutdownCtx)
+	if shareServer != nil {
+		_ = shareServer.Shutdown(shutdownCtx)
+	}
+	if streamServer != nil {
+		_ = streamServer.Close()
+	}
+	telemetry.Shutdown()
+	log.Println("Daemon stopped.")
+}
+
+// schedule runs a scan immediately and then every interval until ctx is cancelled.
+func (d *daemon) schedule(ctx context.Context) {
+	for {
+		d.runScan(ctx, true)
+		d.maybeSendWeeklyWrap(ctx)
+
+		d.mutex.Lock()
+		d.nextScan = time.Now().Add(d.interval)
+		d.mutex.Unlock()
+
+		timer := time.NewTimer(d.interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// runScan performs one scan, serialised with any scan triggered through the API. Scheduled
+// scans poll the feed and skip the scan when it hasn't changed; triggered scans always run.
+func (d *daemon) runScan(ctx context.Context, scheduled bool) scanResult {
+	d.scanMutex.Lock()
+	defer d.scanMutex.Unlock()
+
+	d.scanner.pollFeed = scheduled
+	defer d.scanner.reporter.Recover()
+
+	d.mutex.Lock()
+	d.scanning = true
+	d.mutex.Unlock()
+
+	result := d.scanner.scan(ctx)
+
+	d.mutex.Lock()
+	d.scanning = false
+	d.lastScan = &result
+	d.mutex.Unlock()
+
+	if d.stream != nil {
+		d.stream.publish(result.Matches)
+	}
+
+	return result
+}
+
+func (d *daemon) handleStatus(w http.ResponseWriter, r *http.Request) {
+	d.mutex.Lock()
+	status := daemonStatus{
+		PID:       os.Getpid(),
+		StartedAt: d.startedAt,
+		Interval:  d.interval.String(),
+		Scanning:  d.scanning,
+		NextScan:  d.nextScan,
+		LastScan:  d.lastScan,
+	}
+	d.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (d *daemon) handleScan(w http.ResponseWriter, r *http.Request) {
+	if !d.scanMutex.TryLock() {
+		http.Error(w, "a scan is already in progress", http.StatusConflict)
+		return
+	}
+	d.scanMutex.Unlock()
+
+	result := d.runScan(r.Context(), false)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleMatches returns the matches notified on a given day ("today" or YYYY-MM-DD).
+func (d *daemon) handleMatches(w http.ResponseWriter, r *http.Request) {
+	matches, err := d.matchesOn(r.URL.Query().Get("day"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, matches)
+}
+
+// matchesOn returns the notifications sent on day ("", "today" or YYYY-MM-DD).
+func (d *daemon) matchesOn(day string) ([]history.StoredNotification, error) {
+	if day == "" || day == "today" {
+		day = d.scanner.clock.Now().In(d.scanner.loc).Format("2006-01-02")
+	}
+
+	notifications, err := d.scanner.history.Notifications(false)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []history.StoredNotification{}
+	for _, n := range notifications {
+		if n.SentAt.In(d.scanner.loc).Format("2006-01-02") == day {
+			matches = append(matches, n)
+		}
+	}
+	return matches, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// daemonClient talks to a running daemon over its unix socket.
+type daemonClient struct {
+	http *http.Client
+}
+
+func newDaemonClient(socket string) *daemonClient {
+	return &daemonClient{http: &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}}
+}
+
+// call sends a request to the daemon and decodes the JSON response into out.
+func (c *daemonClient) call(method, path string, out any) error {
+	req, err := http.NewRequest(method, "http://"+daemonName+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("daemon not reachable on %s (start it with 'annscraper daemon'): %w", *socketPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("daemon returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func printScanResult(r *scanResult) {
+	fmt.Printf("Scan of %s feed at %s (%s): %d announcements, %d matches",
+		r.Date, r.StartedAt.Format("2006-01-02 15:04:05"), r.FinishedAt.Sub(r.StartedAt).Round(time.Second),
+		r.Announcements, len(r.Matches))
+	if r.Error != "" {
+		fmt.Printf(", error: %s", r.Error)
+	}
+	fmt.Println()
+
+	for _, am := range r.Matches {
+		fmt.Printf("  %-6s %s\n", am.Match.Ticker, am.Match.Title)
+	}
+}
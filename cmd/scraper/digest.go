@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/asx"
+	"github.com/shanehull/annscraper/internal/notify"
+	"github.com/shanehull/annscraper/internal/routing"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// parseDigestTimes parses "10:30,13:00,16:30" into offsets from midnight, earliest first.
+func parseDigestTimes(s string) ([]time.Duration, error) {
+	var times []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q (expected HH:MM)", part)
+		}
+		times = append(times, time.Duration(t.Hour())*time.Hour+time.Duration(t.Minute())*time.Minute)
+	}
+	slices.Sort(times)
+	return times, nil
+}
+
+// lastDigestSlot returns the most recent scheduled digest time at or before now.
+func lastDigestSlot(now time.Time, times []time.Duration) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i := len(times) - 1; i >= 0; i-- {
+		if slot := midnight.Add(times[i]); !slot.After(now) {
+			return slot
+		}
+	}
+	return midnight.AddDate(0, 0, -1).Add(times[len(times)-1])
+}
+
+// splitForDigest separates matches to email immediately from those to hold for the digest.
+// Matches whose severity is routed to neither email nor the digest are dropped.
+func (s *scanner) splitForDigest(matches []types.AnnotatedMatch) (instant, queued []types.AnnotatedMatch) {
+	for _, am := range matches {
+		if channels, ok := s.routes.Channels(am); ok {
+			switch {
+			case slices.Contains(channels, notify.ChannelEmail):
+				instant = append(instant, am)
+			case slices.Contains(channels, routing.ChannelDigest):
+				queued = append(queued, am)
+			}
+			continue
+		}
+
+		if len(s.digestTimes) == 0 || (*digestInstantPS && am.Match.IsPriceSensitive) {
+			instant = append(instant, am)
+		} else {
+			queued = append(queued, am)
+		}
+	}
+	return instant, queued
+}
+
+// maybeSendDigest emails the queued matches once a scheduled digest time has passed.
+func (s *scanner) maybeSendDigest() {
+	if len(s.digestTimes) == 0 || !s.emailConfig.Enabled {
+		return
+	}
+
+	pending, lastSent, err := s.history.PendingDigest()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	now := s.clock.Now().In(s.loc)
+	slot := lastDigestSlot(now, s.digestTimes)
+	if !lastSent.Before(slot) {
+		return
+	}
+	if len(pending) == 0 {
+		if err := s.history.DigestSent(now); err != nil {
+			log.Printf("Warning: failed to record digest: %v", err)
+		}
+		return
+	}
+
+	_ = asx.SortMatches(pending, *sortOrder) // validated in newScanner
+	if err := notify.EmailDigest(pending, now, s.emailConfig); err != nil {
+		log.Printf("Error sending digest, keeping %d match(es) queued: %v", len(pending), err)
+		return
+	}
+	if err := s.history.DigestSent(now); err != nil {
+		log.Printf("Warning: failed to record digest: %v", err)
+	}
+}
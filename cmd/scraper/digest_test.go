@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastDigestSlot(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, clock string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", day+" "+clock, sydney)
+		return t
+	}
+	times, err := parseDigestTimes("16:30, 10:30,13:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		now, want time.Time
+	}{
+		{at("2026-10-16", "11:00"), at("2026-10-16", "10:30")},
+		{at("2026-10-16", "10:30"), at("2026-10-16", "10:30")},
+		{at("2026-10-16", "13:00"), at("2026-10-16", "13:00")},
+		{at("2026-10-16", "23:59"), at("2026-10-16", "16:30")},
+		{at("2026-10-16", "09:00"), at("2026-10-15", "16:30")},
+		{at("2026-10-16", "00:00"), at("2026-10-15", "16:30")},
+		{at("2026-10-01", "08:00"), at("2026-09-30", "16:30")},
+		// Daylight saving starts on 2026-10-04: the previous day's slot is still 16:30 local.
+		{at("2026-10-04", "09:00"), at("2026-10-03", "16:30")},
+	}
+	for _, tt := range tests {
+		if got := lastDigestSlot(tt.now, times); !got.Equal(tt.want) {
+			t.Errorf("lastDigestSlot(%s) = %s, want %s", tt.now.Format(time.DateTime), got.Format(time.DateTime), tt.want.Format(time.DateTime))
+		}
+	}
+}
+
+func TestParseDigestTimes(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "10:30", want: []time.Duration{10*time.Hour + 30*time.Minute}},
+		{in: "16:00, 09:15,", want: []time.Duration{9*time.Hour + 15*time.Minute, 16 * time.Hour}},
+		{in: "25:00", wantErr: true},
+		{in: "9am", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDigestTimes(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDigestTimes(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseDigestTimes(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseDigestTimes(%q) = %v, want %v", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
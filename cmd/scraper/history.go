@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/history"
+)
+
+// runHistoryCommand inspects and edits today's report history: list, export, import or clear.
+func runHistoryCommand(args []string, format string) {
+	if len(args) == 0 {
+		log.Fatalf("Error: expected 'history list', 'history export', 'history import <file>' or 'history clear'.")
+	}
+
+	historyManager := openHistory()
+	switch args[0] {
+	case "list":
+		entries := historyManager.Entries()
+		if len(entries) == 0 {
+			fmt.Println("Nothing reported today.")
+			return
+		}
+		for _, e := range entries {
+			fmt.Printf("%-6s %s  [%s]\n", e.Ticker, e.Title, e.Describe())
+		}
+	case "export":
+		if err := history.WriteEntries(os.Stdout, historyManager.Entries(), format); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	case "import":
+		if len(args) < 2 {
+			log.Fatalf("Error: 'history import' needs a JSON or CSV file, or - for stdin.")
+		}
+		entries, err := readHistoryEntries(args[1], format)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		added, err := historyManager.Import(entries)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		log.Printf("Imported %d announcement(s) (%d new keyword(s)) into today's history.", len(entries), added)
+	case "clear":
+		if err := historyManager.Clear(); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		log.Printf("Cleared today's history.")
+	default:
+		log.Fatalf("Error: unknown history command %q (expected list, export, import or clear).", args[0])
+	}
+}
+
+// readHistoryEntries reads entries from path, or stdin for "-". A .csv or .json extension
+// overrides -format.
+func readHistoryEntries(path, format string) ([]history.Entry, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".csv" || ext == ".json" {
+			format = ext[1:]
+		}
+	}
+	return history.ReadEntries(r, format)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"github.com/shanehull/annscraper/internal/asx"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// watermark remembers the latest announcement a scan has fully processed, so the daemon's
+// scheduled scans only download documents lodged since rather than the whole day's feed.
+type watermark struct {
+	date   string
+	latest time.Time
+	urls   map[string]bool // announcements lodged at latest, which may share its timestamp with newer ones
+}
+
+// newer returns the announcements for date lodged after the watermark. All of them are
+// returned when the watermark is for another day.
+func (w *watermark) newer(date string, announcements []types.Announcement) []types.Announcement {
+	if w.date != date {
+		return announcements
+	}
+
+	var fresh []types.Announcement
+	for _, ann := range announcements {
+		if ann.DateTime.After(w.latest) || (ann.DateTime.Equal(w.latest) && !w.urls[ann.PDFURL]) {
+			fresh = append(fresh, ann)
+		}
+	}
+	return fresh
+}
+
+// advance moves the watermark past announcements once they have been processed.
+func (w *watermark) advance(date string, announcements []types.Announcement) {
+	if w.date != date {
+		*w = watermark{date: date, urls: make(map[string]bool)}
+	}
+
+	for _, ann := range announcements {
+		switch {
+		case ann.DateTime.After(w.latest):
+			w.latest = ann.DateTime
+			w.urls = map[string]bool{ann.PDFURL: true}
+		case ann.DateTime.Equal(w.latest):
+			w.urls[ann.PDFURL] = true
+		}
+	}
+}
+
+// retryable reports whether any announcements were skipped for a reason that may clear by
+// the next scan, in which case the watermark must not move past them.
+func retryable(stats *asx.ProcessStats) bool {
+	for _, reason := range []string{asx.FailureBandwidth, asx.FailureSourceDegraded, asx.FailureProcessing} {
+		if stats.Failures[reason] > 0 {
+			return true
+		}
+	}
+	return false
+}
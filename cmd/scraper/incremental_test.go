@@ -0,0 +1,67 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/asx"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+func TestWatermark(t *testing.T) {
+	at := func(minute int, url string) types.Announcement {
+		return types.Announcement{DateTime: time.Date(2026, 10, 16, 10, minute, 0, 0, time.UTC), PDFURL: url}
+	}
+	urls := func(anns []types.Announcement) []string {
+		var out []string
+		for _, a := range anns {
+			out = append(out, a.PDFURL)
+		}
+		return out
+	}
+
+	var w watermark
+	first := []types.Announcement{at(1, "a"), at(5, "b"), at(5, "c")}
+	if got := urls(w.newer("2026-10-16", first)); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("empty watermark: newer() = %v, want everything", got)
+	}
+	w.advance("2026-10-16", first)
+
+	// The feed grows: d shares the watermark's timestamp, e is later.
+	second := []types.Announcement{at(1, "a"), at(5, "b"), at(5, "c"), at(5, "d"), at(9, "e")}
+	if got := urls(w.newer("2026-10-16", second)); !slices.Equal(got, []string{"d", "e"}) {
+		t.Errorf("newer() = %v, want [d e]", got)
+	}
+	if got := urls(w.newer("2026-10-17", second)); len(got) != len(second) {
+		t.Errorf("another day: newer() = %v, want everything", got)
+	}
+
+	w.advance("2026-10-16", second)
+	if got := w.newer("2026-10-16", second); len(got) != 0 {
+		t.Errorf("after advance: newer() = %v, want nothing", urls(got))
+	}
+
+	w.advance("2026-10-17", []types.Announcement{at(2, "f")})
+	if w.date != "2026-10-17" || !w.urls["f"] || w.urls["e"] {
+		t.Errorf("new day: watermark = %+v, want reset to f", w)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		failures map[string]int
+		want     bool
+	}{
+		{nil, false},
+		{map[string]int{asx.FailureUnsupported: 2, asx.FailureSize: 1}, false},
+		{map[string]int{asx.FailureBandwidth: 1}, true},
+		{map[string]int{asx.FailureSourceDegraded: 1}, true},
+		{map[string]int{asx.FailureProcessing: 3, asx.FailureSize: 1}, true},
+	}
+	for _, tt := range tests {
+		if got := retryable(&asx.ProcessStats{Failures: tt.failures}); got != tt.want {
+			t.Errorf("retryable(%v) = %t, want %t", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// loadKeywords parses a -keywords value. "@path" reads a file and "-" reads stdin, one keyword
+// or phrase per line, ignoring blank lines and '#' comments; anything else is a comma-separated list.
+func loadKeywords(spec string) ([]string, error) {
+	switch {
+	case spec == "-":
+		return readKeywords(os.Stdin, "stdin")
+	case strings.HasPrefix(spec, "@"):
+		path := strings.TrimPrefix(spec, "@")
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readKeywords(f, path)
+	default:
+		return parseKeywords(spec), nil
+	}
+}
+
+func readKeywords(r io.Reader, name string) ([]string, error) {
+	var keywords []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		keyword := strings.ToLower(strings.TrimSpace(line))
+		if keyword == "" || seen[keyword] {
+			continue
+		}
+		seen[keyword] = true
+		keywords = append(keywords, keyword)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read keywords from %s: %w", name, err)
+	}
+	return keywords, nil
+}
@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // Sydney and -display-timezone resolve without a system zone database
 
-	"github.com/shanehull/annscraper/internal/asx"
-	"github.com/shanehull/annscraper/internal/history"
 	"github.com/shanehull/annscraper/internal/notify"
-	"github.com/shanehull/annscraper/internal/types"
+	"github.com/shanehull/annscraper/internal/paths"
+	"github.com/shanehull/annscraper/internal/semantic"
+	"github.com/shanehull/annscraper/internal/telemetry"
 )
 
 const timezone = "Australia/Sydney"
@@ -41,32 +45,205 @@ func parseTickers(s string) []string {
 	return tickers
 }
 
+// parseModels splits a comma-separated model list, keeping the order models are tried in.
+func parseModels(s string) []string {
+	var models []string
+	for _, part := range strings.Split(s, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			models = append(models, trimmed)
+		}
+	}
+	return models
+}
+
+func parseAddresses(s string) []string {
+	var addresses []string
+	for _, part := range strings.Split(s, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			addresses = append(addresses, trimmed)
+		}
+	}
+	return addresses
+}
+
 var (
-	keywordsStr          = flag.String("keywords", "", "(-k) Comma-separated list of keywords or exact phrases to match")
+	keywordsStr          = flag.String("keywords", "", "(-k) Comma-separated list of keywords, exact phrases or '\"a\" NEAR/20 \"b\"' proximity expressions to match ('dividend:3' requires 3 occurrences), or @file / - (stdin) with one per line")
+	conceptsStr          = flag.String("concepts", "", "Comma-separated concept queries matched by embedding similarity rather than exact words (e.g. 'imminent capital raise,resource upgrade'), or @file / - (stdin) with one per line")
+	conceptThreshold     = flag.Float64("concept-threshold", semantic.DefaultThreshold, "Cosine similarity (0-1) a passage needs to match a -concepts query")
+	embedModel           = flag.String("embed-model", "", "Embedding model for -concepts (empty = "+semantic.DefaultModel+", or "+semantic.DefaultOllamaModel+" with -embed-url)")
+	embedURL             = flag.String("embed-url", "", "Ollama-compatible server to embed -concepts with locally (e.g. 'http://localhost:11434'; empty = Gemini)")
+	embedRPM             = flag.Int("embed-rpm", 100, "Maximum Gemini embedding requests per minute, separate from -ai-rpm (0 = unlimited)")
 	tickersStr           = flag.String("tickers", "", "(-t) Comma-separated list of tickers to match (takes precedence over keywords)")
+	titleFilter          = flag.String("title-filter", "", "Only process announcements whose title matches this regular expression, before any download (e.g. '(?i)quarterly|drill|takeover')")
+	titleExclude         = flag.String("title-exclude", "", "Skip announcements whose title matches this regular expression, before any download")
+	skipBoilerplate      = flag.Bool("skip-boilerplate", true, "Skip daily NTA/NAV statements, ETF basket files and DRP price notices (recognised by title) before any download, except from -tickers, -watchlist and -companies")
+	excludeTickersStr    = flag.String("exclude-tickers", "", "Comma-separated tickers whose announcements are skipped before any download, even if otherwise watched (e.g. ETFs and LICs lodging daily NTA statements), or @file with one per line as for -watchlist")
+	companiesStr         = flag.String("companies", "", "Comma-separated company name substrings to match, resolved to tickers via the ASX company list (e.g. 'Fortescue')")
+	watchlistPath        = flag.String("watchlist", "", "File of tickers to match, one per line ('#' comments allowed); re-read before every scan")
 	filterPriceSensitive = flag.Bool("price-sensitive", false, "(-s) Process ONLY price sensitive announcements")
-	scrapePrevious       = flag.Bool("previous", false, "(-p) Scrape previous business days announcements")
+	scrapePrevious       = flag.Bool("previous", false, "(-p) Scrape the previous trading day's announcements, skipping weekends and ASX holidays")
 	quiet                = flag.Bool("quiet", false, "(-q) Suppress report output to console")
-
-	modelName    = flag.String("model", "gemini-3-pro-preview", "Gemini model to use for analysis (e.g., 'gemini-2.5-flash', 'gemini-3-pro-preview')")
-	geminiAPIKey = flag.String("gemini-key", "", "Gemini API Key for generating AI summaries")
+	sortOrder            = flag.String("sort", "score", "Order of matches in reports, emails and digests: 'score' (AI relevance, price sensitivity, watched tickers and keywords found), 'time' (newest first) or 'ticker'")
+	outputFormat         = flag.String("output", "text", "Format of the match report on stdout: 'text' (console), 'md' (Markdown, e.g. for Obsidian) or 'csv'")
+	noColor              = flag.Bool("no-color", false, "Disable colours in console output (also set by NO_COLOR)")
+	displayTimezone      = flag.String("display-timezone", "", "Time zone to show announcement times in, e.g. Europe/London or Local (default Sydney time, as released)")
+	dataDir              = flag.String("data-dir", "", "Directory for history, notifications and the archive, with caches in its cache subdirectory (default $XDG_DATA_HOME/annscraper and $XDG_CACHE_HOME/annscraper)")
+	historyStore         = flag.String("history-store", "file", "Where reported matches are kept for dedup: 'file', 'sqlite[:PATH]' or a redis://[:pass@]host:6379/db URL shared between instances")
+	asOf                 = flag.String("as-of", "", "Process as if today were this date (YYYY-MM-DD, Australia/Sydney)")
+	sinceStr             = flag.String("since", "", "Catch up on every trading day's feed since this (e.g. 3d or 2025-01-10) as well as today's, skipping announcements already notified; for 'weekly' and 'query', how far back to look (default 7d and 30d)")
+	holidaysPath         = flag.String("holidays", "", "File of extra ASX market holidays for -previous, one YYYY-MM-DD per line ('YYYY-MM-DD open' undoes a built-in holiday)")
+	minFundingQuarters   = flag.Float64("min-funding-quarters", 0, "Alert on Appendix 4C/5B cash flow reports with fewer than this many quarters of funding (0 = off)")
+	statesStr            = flag.String("states", "", "Only report matches tagged with these states/territories (e.g. 'WA,NT')")
+	commoditiesStr       = flag.String("commodities", "", "Only report matches tagged with these commodities (e.g. 'gold,copper')")
+	sectorsStr           = flag.String("sectors", "", "Only process announcements from companies in these GICS sectors or industry groups (e.g. 'Materials,Energy')")
+	minMarketCapStr      = flag.String("min-market-cap", "", "Only process announcements from companies with at least this market cap (e.g. '50m')")
+	maxMarketCapStr      = flag.String("max-market-cap", "", "Only process announcements from companies with at most this market cap (e.g. '500m', '2b')")
+	priceReaction        = flag.Bool("price-reaction", false, "Include each match's price and volume move since the announcement (from Yahoo Finance)")
+	shortInterest        = flag.Bool("short-interest", false, "Include each match's short position as a % of issued capital (from ASIC's daily report)")
+	maxBandwidthMB       = flag.Int64("max-bandwidth", 0, "Soft cap in MiB on data downloaded per run; further documents are skipped once reached (0 = unlimited)")
+	maxPDFSizeMB         = flag.Int64("max-pdf-size", 50, "Skip announcement documents larger than this many MiB (0 = unlimited)")
+	maxPDFPages          = flag.Int("max-pdf-pages", 0, "Only extract text from the first N pages of each PDF (0 = all pages)")
+	sourcesStr           = flag.String("sources", "markit,asx", "Announcement sources to try in order until one succeeds: 'markit' (market feed) and 'asx' (ASX company API, covers -tickers, -watchlist and -companies only)")
+	sentryDSN            = flag.String("sentry-dsn", os.Getenv("SENTRY_DSN"), "Sentry DSN to report scan failures, per-announcement processing errors and panics to")
+	errorWebhook         = flag.String("error-webhook", "", "URL to POST the same error reports to as JSON")
+	otlpEndpoint         = flag.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OpenTelemetry collector to export traces and metrics to over OTLP/HTTP (e.g. http://localhost:4318); headers come from OTEL_EXPORTER_OTLP_HEADERS")
+	proxyURL             = flag.String("proxy", "", "HTTP or SOCKS5 proxy for ASX requests (e.g. 'http://proxy:3128', 'socks5://localhost:1080'); defaults to HTTP(S)_PROXY")
+	breakerThreshold     = flag.Int("breaker-threshold", 5, "Pause requests to an ASX host after this many consecutive errors or maintenance pages (0 = never)")
+	breakerCooldown      = flag.Duration("breaker-cooldown", time.Minute, "How long to pause a failing ASX host before retrying; doubles while it keeps failing")
+	userAgent            = flag.String("user-agent", "", "User-Agent header for ASX requests (empty = Go's default)")
+	layoutMode           = flag.String("layout", "off", "Extract PDFs with their layout preserved and tables rebuilt as 'cell | cell' rows: 'off', 'tables' (cash flow reports, drilling and assay results) or 'always'")
+	progressMode         = flag.String("progress", "auto", "Progress output: 'auto' (a line redrawn in place on a terminal, log lines otherwise), 'log', 'json' (one object per announcement on stdout) or 'none'")
+	pdfExtractor         = flag.String("pdf-extractor", "pdftotext", "PDF text extractor: 'pdftotext' or 'mutool' with optional replacement options (e.g. 'pdftotext -layout'), or any command using {input} for the PDF path")
+	docCacheDir          = flag.String("doc-cache", "", "Directory to cache downloaded documents and extracted text in (empty = no cache)")
+	docCacheMB           = flag.Int64("doc-cache-size", 500, "Maximum size of -doc-cache in MiB; least recently used documents are evicted (0 = unlimited)")
+	minResultChange      = flag.Float64("min-result-change", 0, "Alert when structured (XBRL) revenue, NPAT or EPS changes by at least this percent (0 = off)")
+
+	modelName            = flag.String("model", "gemini-3-pro-preview", "Gemini model to use for analysis, or a comma-separated list tried in order when a model fails or runs out of quota (e.g., 'gemini-3-pro-preview,gemini-2.5-flash')")
+	geminiAPIKey         = flag.String("gemini-key", "", "Gemini API Key for generating AI summaries")
+	aiRPM                = flag.Int("ai-rpm", 10, "Maximum Gemini requests per minute (0 = unlimited)")
+	aiWorkers            = flag.Int("ai-workers", 2, "Number of matches analysed by AI at once, separately from the document download workers")
+	aiTimeout            = flag.Duration("ai-timeout", 2*time.Minute, "Timeout for each Gemini request; timed out requests are retried like other transient errors (0 = none)")
+	aiBackend            = flag.String("ai-backend", "gemini", "AI backend: 'gemini' (API key) or 'vertex' (Application Default Credentials)")
+	vertexProj           = flag.String("vertex-project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "Google Cloud project for the Vertex AI backend")
+	vertexLoc            = flag.String("vertex-location", "global", "Google Cloud region for the Vertex AI backend (e.g. 'us-central1')")
+	aiHistoric           = flag.Int("ai-historic-months", 3, "Months of the company's price sensitive announcements to give the AI as context")
+	aiHistoricTexts      = flag.Int("ai-historic-texts", 0, "Download the most recent N of those announcements and give the AI their condensed text as well as their links (0 = links only)")
+	minAIScore           = flag.Int("min-ai-score", 0, "Suppress alerts whose AI relevance score (0-100) is below this value")
+	thesis               = flag.String("thesis", "", "Free-text investment thesis for the AI to judge each match against")
+	thesisMode           = flag.String("thesis-mode", "tag", "How to treat matches that don't fit -thesis: 'tag' or 'filter'")
+	aiOnlyPriceSensitive = flag.Bool("ai-price-sensitive-only", false, "Only run AI analysis on price sensitive announcements")
+	aiOnlyTickers        = flag.Bool("ai-tickers-only", false, "Only run AI analysis on announcements matched by -tickers")
+	aiKeywordsStr        = flag.String("ai-keywords", "", "Comma-separated subset of -keywords whose matches get AI analysis (default: all)")
+
+	aiPDF = flag.Bool("ai-pdf", false, "Upload the original PDF to Gemini instead of extracted text (falls back to text on failure)")
+
+	aiSystemPromptFile = flag.String("ai-system-prompt-file", "", "Go template file overriding the built-in AI system prompt")
+	aiUserPromptFile   = flag.String("ai-user-prompt-file", "", "Go template file overriding the built-in AI user prompt ({{.Ticker}}, {{.Text}}, {{.HistoricAnnouncements}})")
+
+	emailProvider = flag.String("email-provider", "smtp", "Email delivery: 'smtp', 'sendgrid', 'ses' or 'mailgun'")
+	emailAPIKey   = flag.String("email-api-key", os.Getenv("EMAIL_API_KEY"), "SendGrid or Mailgun API key")
+	mailgunDomain = flag.String("mailgun-domain", "", "Mailgun sending domain")
+	emailRegion   = flag.String("email-region", os.Getenv("AWS_REGION"), "SES region (e.g. ap-southeast-2), or 'eu' for Mailgun's EU endpoint")
 
 	smtpServer = flag.String("smtp-server", "smtp.gmail.com", "SMTP server address (default: smtp.gmail.com)")
 	smtpPort   = flag.Int("smtp-port", 587, "SMTP server port (default: 587)")
 	smtpUser   = flag.String("smtp-user", "", "SMTP username (email address)")
 	smtpPass   = flag.String("smtp-pass", "", "SMTP password or App Password")
-	toEmail    = flag.String("to-email", "", "Recipient email address")
+	toEmail    = flag.String("to-email", "", "Comma-separated recipient email addresses")
+	ccEmail    = flag.String("cc-email", "", "Comma-separated CC email addresses")
+	bccEmail   = flag.String("bcc-email", "", "Comma-separated BCC email addresses")
 	fromEmail  = flag.String("from-email", "", "Sender email address (default: smtp-user)")
+
+	smtpOAuth2Provider     = flag.String("smtp-oauth2-provider", "", "Authenticate to SMTP with OAuth2 (XOAUTH2) instead of -smtp-pass: 'google' or 'microsoft'")
+	smtpOAuth2ClientID     = flag.String("smtp-oauth2-client-id", "", "OAuth2 client ID for -smtp-oauth2-provider")
+	smtpOAuth2ClientSecret = flag.String("smtp-oauth2-client-secret", "", "OAuth2 client secret for -smtp-oauth2-provider")
+	smtpOAuth2Refresh      = flag.String("smtp-oauth2-refresh-token", os.Getenv("SMTP_OAUTH2_REFRESH_TOKEN"), "OAuth2 refresh token used to obtain SMTP access tokens")
+	smtpOAuth2TokenURL     = flag.String("smtp-oauth2-token-url", "", "OAuth2 token endpoint (default: the provider's; required for other providers)")
+
+	emailTemplateDir = flag.String("email-template-dir", "", "Directory with email.html, email.txt and/or subject.txt templates overriding the built-in email layout")
+
+	digestTimesStr  = flag.String("digest-times", "", "Email matches as digests at these Sydney times (e.g. '10:30,13:00,16:30') instead of one email each")
+	digestInstantPS = flag.Bool("digest-instant-price-sensitive", true, "With -digest-times, still email price sensitive matches immediately")
+
+	routesPath = flag.String("routes", "", "Rules file ranking matches as normal, high or critical ('price-sensitive = high', 'keyword takeover = critical', 'score 90 = critical') and routing each severity to channels ('critical -> sms, email', 'normal -> digest')")
+
+	emailSummary = flag.Bool("email-summary", false, "Email an end of run summary: announcements, downloads, matches, errors and AI usage")
+
+	titlesFirst = flag.Bool("titles-first", false, "Only download documents whose title matches a keyword (plus -tickers, halt follow-ups and results); body-only keyword matches are missed")
+
+	snippetWindow    = flag.Int("snippet-window", 50, "Characters of context shown either side of a keyword match")
+	snippetSentences = flag.Bool("snippet-sentences", false, "Expand match snippets to full sentences")
+
+	savePDFsDir = flag.String("save-pdfs", "", "Save each matching announcement's document to DIR/TICKER/YYYY-MM-DD_Title.pdf")
+
+	s3Bucket    = flag.String("s3-bucket", "", "Upload each run's matches and their documents to this S3-compatible bucket (credentials from AWS_* env)")
+	s3Prefix    = flag.String("s3-prefix", "annscraper/", "Key prefix for -s3-bucket uploads")
+	s3Region    = flag.String("s3-region", "", "Region for -s3-bucket (default: AWS_REGION or us-east-1)")
+	s3Endpoint  = flag.String("s3-endpoint", "", "S3-compatible endpoint URL, e.g. for MinIO or R2 (default: AWS)")
+	s3PathStyle = flag.Bool("s3-path-style", false, "Use path-style bucket addressing (needed by most non-AWS endpoints)")
+
+	archiveEnabled = flag.Bool("archive", false, "Store every scraped announcement and its text in a local SQLite archive (requires sqlite3)")
+	archiveDB      = flag.String("archive-db", "", "Path of the SQLite announcement archive (default archive.db in -data-dir)")
+	showChanges    = flag.Bool("changes", false, "Show what changed in recurring reports (4C/5B, quarterly activities, presentations) since the company's previous one in the archive, and tell the AI (requires -archive)")
+
+	icsFile    = flag.String("ics-file", "", "Write AI-extracted key dates of matches to this iCalendar (.ics) file")
+	reportHTML = flag.String("report-html", "", "Write each scan's matches to this standalone HTML file, laid out as in the emails ('{date}' is replaced with the report date, e.g. 'out/{date}.html')")
+
+	syslogEnabled = flag.Bool("syslog", false, "Log matches and run status to syslog/journald with severities")
+	syslogAddr    = flag.String("syslog-addr", "", "Remote syslog server (e.g. udp://host:514); empty = local syslog/journald")
+	syslogTag     = flag.String("syslog-tag", "annscraper", "Tag (identifier) for syslog entries")
+
+	desktopNotify = flag.Bool("desktop", false, "Show a native desktop notification for each match")
+
+	ntfyURL   = flag.String("ntfy-url", "", "ntfy topic URL to push each match to (e.g. https://ntfy.sh/my-asx-alerts)")
+	ntfyToken = flag.String("ntfy-token", os.Getenv("NTFY_TOKEN"), "Access token for a protected ntfy topic")
+
+	teamsWebhook = flag.String("teams-webhook", os.Getenv("TEAMS_WEBHOOK_URL"), "Microsoft Teams incoming webhook URL to post each match to as an Adaptive Card")
+
+	matrixHomeserver = flag.String("matrix-homeserver", "", "Matrix homeserver URL to post each match to (e.g. https://matrix.example.org)")
+	matrixToken      = flag.String("matrix-token", os.Getenv("MATRIX_ACCESS_TOKEN"), "Matrix access token for -matrix-homeserver")
+	matrixRoom       = flag.String("matrix-room", "", "Matrix room ID to post to (e.g. !abcdef:example.org)")
+
+	twilioSID    = flag.String("twilio-sid", os.Getenv("TWILIO_ACCOUNT_SID"), "Twilio account SID for SMS alerts")
+	twilioToken  = flag.String("twilio-token", os.Getenv("TWILIO_AUTH_TOKEN"), "Twilio auth token for SMS alerts")
+	smsFrom      = flag.String("sms-from", "", "Twilio phone number or messaging service SID to send SMS alerts from")
+	smsTo        = flag.String("sms-to", "", "Comma-separated phone numbers (E.164, e.g. +61400000000) to text price sensitive matches to")
+	smsAllAlerts = flag.Bool("sms-all-matches", false, "With -sms-to, text every match instead of only price sensitive ones")
+
+	natsURL       = flag.String("nats-url", "", "NATS server to publish each match to as JSON (nats://[user:pass@]host:4222, tls:// for TLS)")
+	natsSubject   = flag.String("nats-subject", "annscraper.matches.{ticker}", "NATS subject for matches ({ticker} is replaced)")
+	natsJetStream = flag.Bool("nats-jetstream", false, "Wait for a JetStream ack for each published match")
+	kafkaRESTURL  = flag.String("kafka-rest-url", "", "Kafka REST proxy to produce each match to as JSON (e.g. http://localhost:8082)")
+	kafkaTopic    = flag.String("kafka-topic", "annscraper-matches", "Kafka topic for matches ({ticker} is replaced)")
+	mqttURL       = flag.String("mqtt-url", "", "MQTT broker to publish each match to as a retained JSON message (mqtt://[user:pass@]host:1883, mqtts:// for TLS)")
+	mqttTopic     = flag.String("mqtt-topic", "annscraper/{ticker}", "MQTT topic for matches ({ticker} is replaced)")
+
+	execCommand = flag.String("exec-command", "", "Shell command to pipe each match to on stdin")
+	execFormat  = flag.String("exec-format", "json", "Format piped to -exec-command: 'json' or 'text'")
+
+	hookPreDownload = flag.String("hook-pre-download", "", "Command run with each announcement as JSON on stdin before its PDF is downloaded")
+	hookPostExtract = flag.String("hook-post-extract", "", "Command run with each announcement and its extracted text as JSON on stdin")
+	hookPostMatch   = flag.String("hook-post-match", "", "Command run with each match as JSON on stdin before AI analysis")
+	hookPreNotify   = flag.String("hook-pre-notify", "", "Command run with each annotated match as JSON on stdin before reporting")
+
+	scanInterval  = flag.Duration("interval", 15*time.Minute, "Time between scans when running as a daemon")
+	retryInterval = flag.Duration("retry-interval", 5*time.Minute, "How often the daemon retries notifications that failed to send, between scans (0 = only when a scan starts)")
+	socketPath    = flag.String("socket", defaultSocketPath(), "Unix socket for the daemon control API")
+	weeklyWrap    = flag.String("weekly-wrap", "", "Weekday on which the daemon sends a weekly wrap after 5pm (e.g. 'fri'; empty = off)")
+	shareAddr     = flag.String("share-addr", "", "Address for the daemon to serve token-guarded match pages on (e.g. ':8080'; empty = off)")
+	shareURLFlag  = flag.String("share-url", "", "Public base URL of -share-addr used in share links (default: http://localhost:<port>)")
+	streamAddr    = flag.String("stream-addr", "", "Address for the daemon to stream new matches on as Server-Sent Events at /stream (e.g. ':8090'; empty = off)")
+	apiAddr       = flag.String("api-addr", "localhost:8780", "Address for 'annscraper serve' to serve the REST API on")
 )
 
 func init() {
 	flag.StringVar(keywordsStr, "k", "", "(-k) Comma-separated list of keywords or exact phrases (shorthand)")
 	flag.StringVar(tickersStr, "t", "", "(-t) Comma-separated list of tickers to match (takes precedence over keywords) (shorthand)")
 	flag.BoolVar(filterPriceSensitive, "s", false, "(-s) Process ONLY price sensitive announcements (shorthand)")
-	flag.BoolVar(scrapePrevious, "p", false, "(-p) Scrape previous business days announcements (shorthand)")
+	flag.BoolVar(scrapePrevious, "p", false, "(-p) Scrape the previous trading day's announcements (shorthand)")
 	flag.BoolVar(quiet, "q", false, "(-q) Suppress report output to console (shorthand)")
 
-	flag.StringVar(modelName, "m", "gemini-3-pro-preview", "Gemini model to use for analysis (e.g., 'gemini-2.5-flash', 'gemini-3-pro-preview') (shorthand)")
+	flag.StringVar(modelName, "m", "gemini-3-pro-preview", "Gemini model to use for analysis, or a comma-separated list tried in order when a model fails or runs out of quota (e.g., 'gemini-3-pro-preview,gemini-2.5-flash') (shorthand)")
 	flag.StringVar(geminiAPIKey, "g", "", "Gemini API Key for generating AI summaries (shorthand)")
 
 	flag.Usage = func() {
@@ -75,17 +252,145 @@ func init() {
 
 		order := []string{
 			"keywords",
+			"concepts",
+			"concept-threshold",
+			"embed-model",
+			"embed-url",
+			"embed-rpm",
 			"tickers",
+			"exclude-tickers",
+			"skip-boilerplate",
+			"title-filter",
+			"title-exclude",
+			"watchlist",
+			"companies",
 			"price-sensitive",
 			"previous",
+			"as-of",
+			"since",
+			"holidays",
+			"states",
+			"commodities",
+			"sectors",
+			"min-market-cap",
+			"max-market-cap",
+			"price-reaction",
+			"short-interest",
+			"min-result-change",
+			"min-funding-quarters",
+			"max-bandwidth",
+			"max-pdf-size",
+			"max-pdf-pages",
+			"pdf-extractor",
+			"layout",
+			"progress",
+			"output",
+			"sort",
+			"no-color",
+			"display-timezone",
+			"data-dir",
+			"history-store",
+			"sources",
+			"sentry-dsn",
+			"error-webhook",
+			"otlp-endpoint",
+			"proxy",
+			"user-agent",
+			"breaker-threshold",
+			"breaker-cooldown",
+			"doc-cache",
+			"doc-cache-size",
 			"gemini-key",
 			"model",
+			"ai-backend",
+			"vertex-project",
+			"vertex-location",
+			"ai-rpm",
+			"ai-workers",
+			"ai-timeout",
+			"ai-pdf",
+			"ai-price-sensitive-only",
+			"ai-tickers-only",
+			"ai-keywords",
+			"min-ai-score",
+			"thesis",
+			"thesis-mode",
+			"ai-historic-months",
+			"ai-historic-texts",
+			"ai-system-prompt-file",
+			"ai-user-prompt-file",
+			"email-provider",
+			"email-api-key",
+			"mailgun-domain",
+			"email-region",
 			"smtp-server",
 			"smtp-port",
 			"smtp-user",
 			"smtp-pass",
 			"to-email",
+			"cc-email",
+			"bcc-email",
 			"from-email",
+			"smtp-oauth2-provider",
+			"smtp-oauth2-client-id",
+			"smtp-oauth2-client-secret",
+			"smtp-oauth2-refresh-token",
+			"smtp-oauth2-token-url",
+			"email-template-dir",
+			"digest-times",
+			"digest-instant-price-sensitive",
+			"routes",
+			"email-summary",
+			"titles-first",
+			"snippet-window",
+			"snippet-sentences",
+			"save-pdfs",
+			"s3-bucket",
+			"s3-prefix",
+			"s3-region",
+			"s3-endpoint",
+			"s3-path-style",
+			"archive",
+			"archive-db",
+			"changes",
+			"ics-file",
+			"report-html",
+			"desktop",
+			"syslog",
+			"syslog-addr",
+			"syslog-tag",
+			"ntfy-url",
+			"ntfy-token",
+			"teams-webhook",
+			"matrix-homeserver",
+			"matrix-token",
+			"matrix-room",
+			"twilio-sid",
+			"twilio-token",
+			"sms-from",
+			"sms-to",
+			"sms-all-matches",
+			"nats-url",
+			"nats-subject",
+			"nats-jetstream",
+			"kafka-rest-url",
+			"kafka-topic",
+			"mqtt-url",
+			"mqtt-topic",
+			"exec-command",
+			"exec-format",
+			"hook-pre-download",
+			"hook-post-extract",
+			"hook-post-match",
+			"hook-pre-notify",
+			"interval",
+			"retry-interval",
+			"socket",
+			"weekly-wrap",
+			"share-addr",
+			"share-url",
+			"stream-addr",
+			"api-addr",
 		}
 
 		for _, name := range order {
@@ -95,108 +400,184 @@ func init() {
 				fmt.Printf("    %s\n", f.Usage)
 			}
 		}
-	}
-}
-
-func main() {
-	flag.Parse()
 
-	if *keywordsStr == "" && *tickersStr == "" {
-		fmt.Println("Error: Keywords or tickers are required.")
-		fmt.Println("Usage: annscraper -keywords 'keyword1,keyword2' -tickers 'cba,bhp' [-s] --smtp-server=... --to-email=...")
-		os.Exit(1)
-	}
-
-	keywords := parseKeywords(*keywordsStr)
-	if keywords != nil {
-		log.Printf("Filtering for keywords/phrases: [%s]", strings.TrimSpace(*keywordsStr))
+		fmt.Printf("\n%s\n", commandsUsage)
 	}
+}
 
-	tickers := parseTickers(*tickersStr)
-	if tickers != nil {
-		log.Printf("Filtering for tickers: [%s]", strings.ToUpper(strings.TrimSpace(*tickersStr)))
+func emailConfigFromFlags() notify.EmailConfig {
+	if err := notify.LoadEmailTemplates(*emailTemplateDir); err != nil {
+		log.Fatalf("Fatal error loading email templates: %v", err)
 	}
 
-	emailConfig := notify.EmailConfig{
+	cfg := notify.EmailConfig{
+		Provider:   strings.ToLower(*emailProvider),
 		SMTPServer: *smtpServer,
 		SMTPPort:   *smtpPort,
 		SMTPUser:   *smtpUser,
 		SMTPPass:   *smtpPass,
-		ToEmail:    *toEmail,
+		To:         parseAddresses(*toEmail),
+		CC:         parseAddresses(*ccEmail),
+		BCC:        parseAddresses(*bccEmail),
 		FromEmail:  *fromEmail,
-		Enabled:    (*smtpServer != "" && *smtpUser != "" && *smtpPass != "" && *toEmail != ""),
+		APIKey:     *emailAPIKey,
+		Domain:     *mailgunDomain,
+		Region:     *emailRegion,
 	}
 
-	if emailConfig.FromEmail == "" && emailConfig.SMTPUser != "" {
-		emailConfig.FromEmail = emailConfig.SMTPUser
+	oauth2, err := notify.OAuth2Config{
+		Provider:     *smtpOAuth2Provider,
+		TokenURL:     *smtpOAuth2TokenURL,
+		ClientID:     *smtpOAuth2ClientID,
+		ClientSecret: *smtpOAuth2ClientSecret,
+		RefreshToken: *smtpOAuth2Refresh,
+	}.WithDefaults()
+	if err != nil {
+		log.Fatalf("Invalid SMTP OAuth2 settings: %v", err)
 	}
+	cfg.OAuth2 = oauth2
 
-	historyManager, err := history.NewManager(timezone)
-	if err != nil {
-		log.Fatalf("Fatal error setting up history: %v", err)
+	if cfg.FromEmail == "" && cfg.SMTPUser != "" {
+		cfg.FromEmail = cfg.SMTPUser
 	}
 
-	log.Printf("Starting ASX Scraper...")
+	switch cfg.Provider {
+	case notify.ProviderSMTP:
+		cfg.Enabled = *smtpServer != "" && *smtpUser != "" && (*smtpPass != "" || oauth2.Enabled()) && len(cfg.To) > 0
+	case notify.ProviderSendGrid:
+		cfg.Enabled = cfg.APIKey != "" && cfg.FromEmail != "" && len(cfg.To) > 0
+	case notify.ProviderMailgun:
+		cfg.Enabled = cfg.APIKey != "" && cfg.Domain != "" && cfg.FromEmail != "" && len(cfg.To) > 0
+	case notify.ProviderSES:
+		cfg.Enabled = notify.AWSCredentialsAvailable() && cfg.Region != "" && cfg.FromEmail != "" && len(cfg.To) > 0
+	default:
+		log.Fatalf("Invalid -email-provider %q (expected smtp, sendgrid, ses or mailgun)", *emailProvider)
+	}
+	return cfg
+}
 
-	log.Printf("Scraping %s aggregate feed.", func() string {
-		if *scrapePrevious {
-			return "previous day's"
-		}
-		return "today's"
-	}())
-
-	date := func() string {
-		loc, _ := time.LoadLocation("Australia/Sydney")
-		return time.Now().In(loc).Format("2006-01-02")
-	}()
-	if *scrapePrevious {
-		loc, _ := time.LoadLocation("Australia/Sydney")
-		date = time.Now().In(loc).AddDate(0, 0, -1).Format("2006-01-02")
-	}
-
-	announcements, err := asx.FetchAnnouncements(asx.FetchParams{
-		Date:               date,
-		PriceSensitiveOnly: *filterPriceSensitive,
-	})
-	if err != nil {
-		log.Fatalf("Fatal error during scraping: %v", err)
+func syslogConfigFromFlags() notify.SyslogConfig {
+	return notify.SyslogConfig{
+		Address: *syslogAddr,
+		Tag:     *syslogTag,
+		Enabled: *syslogEnabled,
 	}
+}
 
-	totalAnns := len(announcements)
-	if totalAnns == 0 {
-		log.Println("No announcements found today or scraping failed.")
+func ntfyConfigFromFlags() notify.NtfyConfig {
+	return notify.NtfyConfig{
+		URL:     *ntfyURL,
+		Token:   *ntfyToken,
+		Enabled: *ntfyURL != "",
+	}
+}
 
-		historyManager.RecordMatches(nil)
-		log.Printf("Saved history to: %s.", historyManager.HistoryFilePath())
+func teamsConfigFromFlags() notify.TeamsConfig {
+	return notify.TeamsConfig{
+		WebhookURL: *teamsWebhook,
+		Enabled:    *teamsWebhook != "",
+	}
+}
 
-		return
+func matrixConfigFromFlags() notify.MatrixConfig {
+	return notify.MatrixConfig{
+		Homeserver:  *matrixHomeserver,
+		AccessToken: *matrixToken,
+		RoomID:      *matrixRoom,
+		Enabled:     *matrixHomeserver != "" && *matrixToken != "" && *matrixRoom != "",
 	}
-	log.Printf("Found %d total announcements (price-sensitive: %t). Starting PDF download and search...", totalAnns, *filterPriceSensitive)
+}
 
-	filterFunc := func(ann types.Announcement, foundKeywords []string, isTickerMatch bool) []string {
-		return historyManager.FilterNewMatches(ann, foundKeywords, isTickerMatch)
+func smsConfigFromFlags() notify.SMSConfig {
+	cfg := notify.SMSConfig{
+		AccountSID: *twilioSID,
+		AuthToken:  *twilioToken,
+		From:       *smsFrom,
+		To:         parseAddresses(*smsTo),
+		AllMatches: *smsAllAlerts,
 	}
+	cfg.Enabled = cfg.AccountSID != "" && cfg.AuthToken != "" && cfg.From != "" && len(cfg.To) > 0
+	return cfg
+}
 
-	ctx := context.Background()
-	annotatedMatches := asx.ProcessAnnouncements(ctx, announcements, keywords, tickers, filterFunc, *geminiAPIKey, *modelName)
+func natsConfigFromFlags() notify.NATSConfig {
+	return notify.NATSConfig{
+		URL:       *natsURL,
+		Subject:   *natsSubject,
+		JetStream: *natsJetStream,
+		Enabled:   *natsURL != "",
+	}
+}
 
-	var coreMatches []types.Match
-	for _, am := range annotatedMatches {
-		coreMatches = append(coreMatches, am.Match)
+func kafkaConfigFromFlags() notify.KafkaConfig {
+	return notify.KafkaConfig{
+		RESTURL: *kafkaRESTURL,
+		Topic:   *kafkaTopic,
+		Enabled: *kafkaRESTURL != "",
 	}
+}
 
-	if len(annotatedMatches) == 0 {
-		log.Println("No new matching keywords found in any announcement today.")
-	} else {
-		if !*quiet {
-			notify.ReportMatches(annotatedMatches, historyManager.HistoryFilePath())
-		}
+func mqttConfigFromFlags() notify.MQTTConfig {
+	return notify.MQTTConfig{
+		URL:     *mqttURL,
+		Topic:   *mqttTopic,
+		Enabled: *mqttURL != "",
+	}
+}
 
-		if emailConfig.Enabled {
-			notify.EmailMatches(annotatedMatches, emailConfig)
-		}
+func execConfigFromFlags() notify.ExecConfig {
+	if *execFormat != "json" && *execFormat != "text" {
+		log.Fatalf("Invalid -exec-format %q (expected 'json' or 'text')", *execFormat)
+	}
+
+	return notify.ExecConfig{
+		Command: *execCommand,
+		Format:  *execFormat,
+		Enabled: *execCommand != "",
+	}
+}
+
+// setDisplayTimezone applies -display-timezone to reports, emails and messages.
+func setDisplayTimezone() {
+	if *displayTimezone == "" {
+		return
+	}
+	loc, err := time.LoadLocation(*displayTimezone)
+	if err != nil {
+		log.Fatalf("Invalid -display-timezone %q: %v", *displayTimezone, err)
+	}
+	notify.SetDisplayLocation(loc)
+}
+
+func main() {
+	if filepath.Base(strings.TrimSuffix(os.Args[0], ".exe")) == daemonName {
+		flag.Parse()
+		paths.SetDataDir(*dataDir)
+		setDisplayTimezone()
+		runDaemon()
+		return
+	}
+
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runCommand(os.Args[1], os.Args[2:])
+		return
 	}
 
-	historyManager.RecordMatches(coreMatches)
-	log.Printf("Saved history to: %s.", historyManager.HistoryFilePath())
+	flag.Parse()
+	paths.SetDataDir(*dataDir)
+	setDisplayTimezone()
+
+	runOnce()
+}
+
+// runOnce scans once and exits: the default command and 'annscraper run'. Telemetry is
+// flushed even if the scan panics.
+func runOnce() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s := newScanner()
+	defer telemetry.Shutdown()
+	defer s.reporter.Recover()
+	s.run(ctx)
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/shanehull/annscraper/internal/asx"
+	"github.com/shanehull/annscraper/internal/notify"
+)
+
+// progressReporter returns the asx.ProcessParams.Progress callback for -progress, or nil to
+// log each announcement as it starts.
+//
+//   - auto: a single line redrawn in place when stdout is a terminal, log lines otherwise
+//   - log:  log lines
+//   - json: one JSON object per processed announcement on stdout
+//   - none: no progress output
+func progressReporter(mode string) (func(asx.ProgressEvent), error) {
+	switch mode {
+	case "auto":
+		if !notify.IsTerminal(os.Stdout) {
+			return nil, nil
+		}
+		return printProgressLine, nil
+	case "log":
+		return nil, nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		return func(e asx.ProgressEvent) {
+			if err := enc.Encode(e); err != nil {
+				log.Printf("Warning: failed to write progress: %v", err)
+			}
+		}, nil
+	case "none":
+		return func(asx.ProgressEvent) {}, nil
+	}
+	return nil, fmt.Errorf("unknown progress mode %q (expected 'auto', 'log', 'json' or 'none')", mode)
+}
+
+// printProgressLine redraws the progress line, ending it once the last announcement is done.
+func printProgressLine(e asx.ProgressEvent) {
+	fmt.Printf("\r\033[KProcessing... %d/%d (%s)", e.Done, e.Total, e.Ticker)
+	if e.Done == e.Total {
+		fmt.Println()
+	}
+}
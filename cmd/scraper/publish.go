@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/notify"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// runPublishCommand renders the stored notifications into a static site in dir, one page
+// per day the announcements were released (in the report timezone).
+func runPublishCommand(dir string) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Fatalf("Fatal error loading timezone: %v", err)
+	}
+
+	notifications, err := openHistory().Notifications(false)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	days := make(map[string][]types.AnnotatedMatch)
+	for _, n := range notifications {
+		date := n.Match.Match.DateTime.In(loc).Format("2006-01-02")
+		days[date] = append(days[date], n.Match)
+	}
+
+	written, err := notify.WriteSite(dir, days)
+	if err != nil {
+		log.Fatalf("Error publishing site: %v", err)
+	}
+	log.Printf("Published %d notification(s) across %d day(s) to %s (%d page(s) written).", len(notifications), len(days), dir, written)
+}
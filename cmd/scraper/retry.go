@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/notify"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// retryFailedNotifications resends matches that channels failed to deliver on earlier runs.
+// Those that fail again are re-queued by the channel itself, and those for a channel that
+// has since been disabled stay queued.
+func (s *scanner) retryFailedNotifications() {
+	failed, err := s.history.PendingFailed()
+	if err != nil {
+		log.Printf("Error reading failed notification queue: %v", err)
+		return
+	}
+	if len(failed) == 0 {
+		return
+	}
+	log.Printf("Retrying %d failed notification(s).", len(failed))
+
+	byChannel := make(map[string][]types.AnnotatedMatch)
+	var channels []string
+	for _, f := range failed {
+		if _, ok := byChannel[f.Channel]; !ok {
+			channels = append(channels, f.Channel)
+		}
+		byChannel[f.Channel] = append(byChannel[f.Channel], f.Match)
+	}
+
+	for _, channel := range channels {
+		matches := byChannel[channel]
+		var enabled bool
+		var send func()
+		switch channel {
+		case notify.ChannelEmail:
+			enabled, send = s.emailConfig.Enabled, func() { notify.EmailMatches(matches, s.emailConfig) }
+		case notify.ChannelExec:
+			enabled, send = s.execConfig.Enabled, func() { notify.ExecMatches(matches, s.execConfig) }
+		case notify.ChannelNtfy:
+			enabled, send = s.ntfyConfig.Enabled, func() { notify.NtfyMatches(matches, s.ntfyConfig) }
+		case notify.ChannelSMS:
+			cfg := s.smsConfig
+			cfg.AllMatches = true // already selected when first sent
+			enabled, send = cfg.Enabled, func() { notify.SMSMatches(matches, cfg) }
+		case notify.ChannelTeams:
+			enabled, send = s.teamsConfig.Enabled, func() { notify.TeamsMatches(matches, s.teamsConfig) }
+		case notify.ChannelMatrix:
+			enabled, send = s.matrixConfig.Enabled, func() { notify.MatrixMatches(matches, s.matrixConfig) }
+		case notify.ChannelNATS:
+			enabled, send = s.natsConfig.Enabled, func() { notify.NATSMatches(matches, s.natsConfig) }
+		case notify.ChannelKafka:
+			enabled, send = s.kafkaConfig.Enabled, func() { notify.KafkaMatches(matches, s.kafkaConfig) }
+		case notify.ChannelMQTT:
+			enabled, send = s.mqttConfig.Enabled, func() { notify.MQTTMatches(matches, s.mqttConfig) }
+		default:
+			log.Printf("Warning: dropping %d queued notification(s) for unknown channel %q", len(matches), channel)
+			enabled, send = true, func() {}
+		}
+
+		if enabled {
+			send()
+		} else {
+			log.Printf("Keeping %d queued %s notification(s) until the channel is enabled again.", len(matches), channel)
+		}
+		if err := s.history.FinishRetry(channel, enabled); err != nil {
+			log.Printf("Error updating failed notification queue: %v", err)
+		}
+	}
+}
+
+// retryLoop retries failed notifications every interval between scans.
+func (d *daemon) retryLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// A running scan retries when it starts, so skip rather than wait for it.
+			if d.scanMutex.TryLock() {
+				d.scanner.retryFailedNotifications()
+				d.scanMutex.Unlock()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
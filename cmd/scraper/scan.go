@@ -0,0 +1,759 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/archive"
+	"github.com/shanehull/annscraper/internal/asx"
+	"github.com/shanehull/annscraper/internal/calendar"
+	"github.com/shanehull/annscraper/internal/clock"
+	"github.com/shanehull/annscraper/internal/errreport"
+	"github.com/shanehull/annscraper/internal/geo"
+	"github.com/shanehull/annscraper/internal/history"
+	"github.com/shanehull/annscraper/internal/hooks"
+	"github.com/shanehull/annscraper/internal/notify"
+	"github.com/shanehull/annscraper/internal/paths"
+	"github.com/shanehull/annscraper/internal/prices"
+	"github.com/shanehull/annscraper/internal/routing"
+	"github.com/shanehull/annscraper/internal/s3"
+	"github.com/shanehull/annscraper/internal/semantic"
+	"github.com/shanehull/annscraper/internal/shorts"
+	"github.com/shanehull/annscraper/internal/telemetry"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// scanner holds the configuration and state shared between scans so that a long-running
+// daemon can reuse it across runs.
+type scanner struct {
+	keywords       []string
+	concepts       *semantic.Matcher // nil = no -concepts
+	tickers        []string
+	excluded       []string       // from -exclude-tickers
+	titleInclude   *regexp.Regexp // from -title-filter, nil = every title
+	titleExclude   *regexp.Regexp // from -title-exclude, nil = none
+	watchlist      []string       // from -watchlist, reloaded before each scan
+	companies      []string       // company name substrings from -companies
+	companyTickers []string       // -companies resolved against the directory
+	directory      *asx.Directory // nil = directory unavailable
+	companyFilter  asx.CompanyFilter
+	loc            *time.Location
+	calendar       *calendar.Calendar
+	notified       map[string]bool     // IDs of stored notifications while catching up with -since, else nil
+	pollFeed       bool                // repeated scans: skip work while the feed is unchanged
+	processed      watermark           // how far through the day's feed scans have got, used when pollFeed
+	sourceDegraded bool                // a source degraded notification has been sent
+	reporter       *errreport.Reporter // nil = errors are only logged
+	clock          clock.Clock
+	history        *history.Manager
+	hooks          *hooks.Runner
+	archive        *archive.Archive // nil = archiving disabled
+	s3             *s3.Client       // nil = no uploads
+	aiSelection    asx.AISelection
+	layout         asx.LayoutMode
+	progress       func(asx.ProgressEvent) // nil = log each announcement
+	geoFilter      geo.Filter
+	emailConfig    notify.EmailConfig
+	execConfig     notify.ExecConfig
+	syslogConfig   notify.SyslogConfig
+	ntfyConfig     notify.NtfyConfig
+	smsConfig      notify.SMSConfig
+	teamsConfig    notify.TeamsConfig
+	matrixConfig   notify.MatrixConfig
+	natsConfig     notify.NATSConfig
+	kafkaConfig    notify.KafkaConfig
+	mqttConfig     notify.MQTTConfig
+	digestTimes    []time.Duration // scheduled digest times after midnight, empty = email instantly
+	routes         *routing.Rules  // nil = every match goes to every enabled channel
+}
+
+// scanResult summarises a single scan.
+type scanResult struct {
+	Date          string                 `json:"date"`
+	StartedAt     time.Time              `json:"started_at"`
+	FinishedAt    time.Time              `json:"finished_at"`
+	Announcements int                    `json:"announcements"`
+	Unchanged     bool                   `json:"unchanged,omitempty"` // feed unchanged since the previous scan
+	Matches       []types.AnnotatedMatch `json:"matches"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+// newConceptMatcher returns the matcher for -concepts, or nil if none are set.
+func newConceptMatcher() (*semantic.Matcher, error) {
+	concepts, err := loadKeywords(*conceptsStr)
+	if err != nil || len(concepts) == 0 {
+		return nil, err
+	}
+	if *embedURL == "" && *geminiAPIKey == "" && *aiBackend != ai.BackendVertex {
+		return nil, fmt.Errorf("-concepts needs -gemini-key, the vertex backend or -embed-url")
+	}
+	log.Printf("Matching concepts: [%s]", strings.Join(concepts, ","))
+
+	var embedder semantic.Embedder = semantic.Gemini{APIKey: *geminiAPIKey, Model: cmp.Or(*embedModel, semantic.DefaultModel)}
+	if *embedURL != "" {
+		embedder = semantic.Ollama{URL: *embedURL, Model: cmp.Or(*embedModel, semantic.DefaultOllamaModel)}
+	}
+	return semantic.NewMatcher(concepts, *conceptThreshold, embedder), nil
+}
+
+// newScanner validates the global flags and prepares everything a scan needs.
+func newScanner() *scanner {
+	if *keywordsStr == "" && *conceptsStr == "" && *tickersStr == "" && *watchlistPath == "" && *companiesStr == "" {
+		fmt.Println("Error: Keywords, concepts, tickers, companies or a watchlist are required.")
+		fmt.Println("Usage: annscraper -keywords 'keyword1,keyword2' -tickers 'cba,bhp' [-s] --smtp-server=... --to-email=...")
+		os.Exit(1)
+	}
+
+	s := &scanner{}
+	notify.SetConsole(notify.DetectConsole(*noColor))
+
+	keywords, err := loadKeywords(*keywordsStr)
+	if err != nil {
+		log.Fatalf("Fatal error reading keywords: %v", err)
+	}
+	s.keywords = keywords
+	if s.keywords != nil {
+		log.Printf("Filtering for keywords/phrases: [%s]", strings.Join(s.keywords, ","))
+	}
+
+	if s.concepts, err = newConceptMatcher(); err != nil {
+		log.Fatalf("Fatal error reading concepts: %v", err)
+	}
+
+	s.tickers = parseTickers(*tickersStr)
+	if s.tickers != nil {
+		log.Printf("Filtering for tickers: [%s]", strings.ToUpper(strings.TrimSpace(*tickersStr)))
+	}
+	if s.excluded, err = loadTickers(*excludeTickersStr); err != nil {
+		log.Fatalf("Invalid -exclude-tickers: %v", err)
+	}
+	if s.excluded != nil {
+		log.Printf("Excluding tickers: [%s]", strings.Join(s.excluded, ","))
+	}
+	if *titleFilter != "" {
+		if s.titleInclude, err = regexp.Compile(*titleFilter); err != nil {
+			log.Fatalf("Invalid -title-filter: %v", err)
+		}
+	}
+	if *titleExclude != "" {
+		if s.titleExclude, err = regexp.Compile(*titleExclude); err != nil {
+			log.Fatalf("Invalid -title-exclude: %v", err)
+		}
+	}
+
+	s.companies = parseKeywords(*companiesStr)
+
+	if *watchlistPath != "" {
+		if _, err := readWatchlist(*watchlistPath); err != nil {
+			log.Fatalf("Fatal error reading watchlist: %v", err)
+		}
+		s.reloadWatchlist()
+	}
+	s.reloadDirectory()
+
+	s.geoFilter = parseGeoFilter(*statesStr, *commoditiesStr)
+	s.companyFilter = parseCompanyFilter(*sectorsStr, *minMarketCapStr, *maxMarketCapStr)
+	if s.companyFilter.Active() && s.directory == nil {
+		log.Fatalf("Error: -sectors, -min-market-cap and -max-market-cap require the ASX company directory, which could not be loaded.")
+	}
+
+	s.aiSelection = asx.AISelection{
+		PriceSensitiveOnly: *aiOnlyPriceSensitive,
+		TickerMatchesOnly:  *aiOnlyTickers,
+		Keywords:           parseKeywords(*aiKeywordsStr),
+	}
+
+	s.emailConfig = emailConfigFromFlags()
+	digestTimes, err := parseDigestTimes(*digestTimesStr)
+	if err != nil {
+		log.Fatalf("Invalid -digest-times: %v", err)
+	}
+	s.digestTimes = digestTimes
+	s.execConfig = execConfigFromFlags()
+	s.syslogConfig = syslogConfigFromFlags()
+	s.ntfyConfig = ntfyConfigFromFlags()
+	s.smsConfig = smsConfigFromFlags()
+	s.teamsConfig = teamsConfigFromFlags()
+	s.matrixConfig = matrixConfigFromFlags()
+	s.natsConfig = natsConfigFromFlags()
+	s.kafkaConfig = kafkaConfigFromFlags()
+	s.mqttConfig = mqttConfigFromFlags()
+
+	if *routesPath != "" {
+		if s.routes, err = routing.Load(*routesPath); err != nil {
+			log.Fatalf("Invalid -routes: %v", err)
+		}
+		if s.routes.RoutesTo(routing.ChannelDigest) && len(s.digestTimes) == 0 {
+			log.Fatalf("Error: -routes sends matches to the digest, which requires -digest-times.")
+		}
+		if s.routes.RoutesTo(notify.ChannelSMS) {
+			s.smsConfig.AllMatches = true // the routes choose which matches are texted
+		}
+	}
+
+	if s.reporter, err = errreport.New(errreport.Config{SentryDSN: *sentryDSN, WebhookURL: *errorWebhook}); err != nil {
+		log.Fatalf("Fatal error setting up error reporting: %v", err)
+	}
+	otel := telemetry.ConfigFromEnv()
+	otel.Endpoint = *otlpEndpoint
+	telemetry.Init(otel)
+	if err := asx.SetSources(parseKeywords(*sourcesStr)); err != nil {
+		log.Fatalf("Invalid -sources: %v", err)
+	}
+	if err := asx.SetProxy(*proxyURL); err != nil {
+		log.Fatalf("Fatal error setting up proxy: %v", err)
+	}
+	asx.SetUserAgent(*userAgent)
+	asx.SetCircuitBreaker(*breakerThreshold, *breakerCooldown)
+	asx.SetMaxBandwidth(*maxBandwidthMB * 1024 * 1024)
+	asx.SetDocumentLimits(*maxPDFSizeMB*1024*1024, *maxPDFPages)
+	if err := asx.SetPDFExtractor(*pdfExtractor); err != nil {
+		log.Fatalf("Fatal error setting up PDF extractor: %v", err)
+	}
+	if s.layout, err = asx.ParseLayoutMode(*layoutMode); err != nil {
+		log.Fatalf("Invalid -layout: %v", err)
+	}
+	if err := asx.SortMatches(nil, *sortOrder); err != nil {
+		log.Fatalf("Invalid -sort: %v", err)
+	}
+	switch *outputFormat {
+	case "text", "md", "csv":
+	default:
+		log.Fatalf("Invalid -output %q (expected 'text', 'md' or 'csv')", *outputFormat)
+	}
+
+	if s.progress, err = progressReporter(*progressMode); err != nil {
+		log.Fatalf("Invalid -progress: %v", err)
+	}
+	if err := asx.SetDocumentCache(*docCacheDir, *docCacheMB*1024*1024); err != nil {
+		log.Fatalf("Fatal error setting up document cache: %v", err)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		log.Fatalf("Fatal error loading time zone %s: %v", timezone, err)
+	}
+	s.loc = loc
+
+	s.clock = clock.System{}
+	if *asOf != "" {
+		fixed, err := clock.AsOf(*asOf, loc)
+		if err != nil {
+			log.Fatalf("Invalid -as-of: %v", err)
+		}
+		s.clock = fixed
+		log.Printf("Running as of %s.", *asOf)
+	}
+	if s.calendar, err = calendar.Load(*holidaysPath); err != nil {
+		log.Fatalf("Invalid -holidays: %v", err)
+	}
+
+	s.history, err = history.NewManager(timezone, s.clock)
+	if err != nil {
+		log.Fatalf("Fatal error setting up history: %v", err)
+	}
+	s.history.SetStore(openHistoryStore())
+	notify.SetFailureQueue(s.history)
+
+	ai.SetRequestsPerMinute(*aiRPM)
+	ai.SetEmbedRequestsPerMinute(*embedRPM)
+	ai.SetTimeout(*aiTimeout)
+
+	if err := ai.SetBackend(ai.BackendConfig{
+		Backend:  *aiBackend,
+		Project:  *vertexProj,
+		Location: *vertexLoc,
+	}); err != nil {
+		log.Fatalf("Fatal error configuring AI backend: %v", err)
+	}
+	if *aiWorkers < 1 {
+		log.Fatalf("Invalid -ai-workers %d (expected at least 1)", *aiWorkers)
+	}
+
+	if *thesisMode != "tag" && *thesisMode != "filter" {
+		log.Fatalf("Invalid -thesis-mode %q (expected 'tag' or 'filter')", *thesisMode)
+	}
+	ai.SetThesis(*thesis)
+
+	if err := ai.LoadPromptTemplates(*aiSystemPromptFile, *aiUserPromptFile); err != nil {
+		log.Fatalf("Fatal error loading prompt templates: %v", err)
+	}
+
+	if *archiveEnabled {
+		s.archive, err = archive.Open(*archiveDB)
+		if err != nil {
+			log.Fatalf("Fatal error opening archive: %v", err)
+		}
+	}
+	if *showChanges && s.archive == nil {
+		log.Fatalf("Error: -changes requires -archive.")
+	}
+
+	s.s3 = newS3ClientFromFlags()
+
+	s.hooks = hooks.NewRunner()
+	for stage, command := range map[hooks.Stage]string{
+		hooks.PreDownload: *hookPreDownload,
+		hooks.PostExtract: *hookPostExtract,
+		hooks.PostMatch:   *hookPostMatch,
+		hooks.PreNotify:   *hookPreNotify,
+	} {
+		if command != "" {
+			s.hooks.Register(stage, hooks.CommandHook{Command: command})
+			log.Printf("Registered %s hook: %s", stage, command)
+		}
+	}
+
+	return s
+}
+
+// scan fetches, matches, analyses and notifies for today's feed, or the previous trading
+// day's with -previous.
+func (s *scanner) scan(ctx context.Context) scanResult {
+	day := s.clock.Now().In(s.loc)
+	if *scrapePrevious {
+		day = s.calendar.PreviousTradingDay(day)
+	}
+	return s.scanDate(ctx, day.Format("2006-01-02"))
+}
+
+// scanDate fetches, matches, analyses and notifies for one feed date.
+func (s *scanner) scanDate(ctx context.Context, date string) scanResult {
+	result := scanResult{StartedAt: time.Now()}
+	defer func() { result.FinishedAt = time.Now() }()
+
+	ctx, scanSpan := telemetry.Start(ctx, "scan")
+	defer func() {
+		var err error
+		if result.Error != "" {
+			err = errors.New(result.Error)
+		}
+		scanSpan.End(err)
+	}()
+
+	if err := s.history.Reload(); err != nil {
+		log.Fatalf("Fatal error reloading history: %v", err)
+	}
+	s.reloadWatchlist()
+	s.reloadDirectory()
+	s.retryFailedNotifications()
+	defer s.reporter.Flush()
+	defer s.maybeSendDigest()
+	defer s.checkSources()
+
+	log.Printf("Starting ASX Scraper...")
+
+	log.Printf("Scraping the aggregate feed for %s.", date)
+	result.Date = date
+
+	_, span := telemetry.Start(ctx, "scrape", "date", date)
+	announcements, fetchStats, err := asx.FetchAnnouncementsWithStats(asx.FetchParams{
+		Date:               date,
+		PriceSensitiveOnly: *filterPriceSensitive,
+		FallbackTickers:    s.allTickers(),
+		IfChanged:          s.pollFeed,
+	})
+	span.End(err)
+	if err != nil {
+		log.Printf("Error during scraping: %v", err)
+		s.reporter.Capture("scan", err, map[string]string{"date": date})
+		notify.SyslogStatus(s.syslogConfig, notify.SeverityError, "scan of %s failed: %v", date, err)
+		result.Error = err.Error()
+		return result
+	}
+	if fetchStats.Unchanged {
+		log.Println("Feed unchanged since the previous scan, nothing to do.")
+		result.Unchanged = true
+		return result
+	}
+
+	fetched := announcements
+	if s.pollFeed {
+		announcements = s.processed.newer(date, announcements)
+		if skipped := len(fetched) - len(announcements); skipped > 0 {
+			log.Printf("Skipping %d announcement(s) processed by earlier scans, %d new.", skipped, len(announcements))
+		}
+	}
+
+	if len(s.excluded) > 0 {
+		before := len(announcements)
+		announcements = excludeTickers(announcements, s.excluded)
+		if skipped := before - len(announcements); skipped > 0 {
+			log.Printf("Skipping %d announcement(s) from excluded tickers.", skipped)
+		}
+	}
+	if *skipBoilerplate {
+		before := len(announcements)
+		announcements = dropBoilerplate(announcements, s.allTickers())
+		if skipped := before - len(announcements); skipped > 0 {
+			log.Printf("Skipping %d NTA, ETF basket and DRP price notice(s).", skipped)
+		}
+	}
+	if s.titleInclude != nil || s.titleExclude != nil {
+		before := len(announcements)
+		announcements = filterTitles(announcements, s.titleInclude, s.titleExclude)
+		log.Printf("Title filter kept %d of %d announcements.", len(announcements), before)
+	}
+
+	s.directory.Annotate(announcements)
+	if s.companyFilter.Active() {
+		before := len(announcements)
+		announcements = s.directory.FilterAnnouncements(announcements, s.companyFilter)
+		log.Printf("Company filter kept %d of %d announcements.", len(announcements), before)
+	}
+
+	totalAnns := len(announcements)
+	result.Announcements = totalAnns
+	notify.SyslogStatus(s.syslogConfig, notify.SeverityInfo, "scanning %d announcements for %s", totalAnns, date)
+	if totalAnns == 0 {
+		if len(fetched) > 0 {
+			log.Println("No new announcements to process.")
+		} else {
+			log.Println("No announcements found today or scraping failed.")
+		}
+
+		s.history.Save()
+		log.Printf("Saved history to: %s.", s.history.HistoryFilePath())
+		s.processed.advance(date, fetched)
+
+		return result
+	}
+	log.Printf("Found %d total announcements (price-sensitive: %t). Starting PDF download and search...", totalAnns, *filterPriceSensitive)
+
+	uploader := newRunUploader(s.s3, date, result.StartedAt)
+
+	stats := &asx.ProcessStats{}
+	filterFunc := func(ann types.Announcement, foundKeywords []string, isTickerMatch bool) []string {
+		if s.notified[history.NotificationID(types.Match{Announcement: ann})] {
+			return nil // reported on an earlier day, which today's history doesn't cover
+		}
+		return s.history.FilterNewMatches(ann, foundKeywords, isTickerMatch)
+	}
+
+	annotatedMatches := asx.ProcessAnnouncements(ctx, announcements, asx.ProcessParams{
+		Keywords:      s.keywords,
+		Tickers:       s.allTickers(),
+		FilterFn:      filterFunc,
+		GeminiAPIKey:  *geminiAPIKey,
+		Models:        parseModels(*modelName),
+		AnalysisCache: s.history,
+		Halts:         s.history,
+		Archiver:      s.archiver(),
+		Hooks:         s.hooks,
+		Errors:        s.errorReporter(),
+		AnalyzePDF:    *aiPDF,
+
+		HistoricLookback: time.Duration(*aiHistoric) * 30 * 24 * time.Hour,
+		HistoricTexts:    *aiHistoricTexts,
+		Clock:            s.clock,
+
+		MinResultChange:    *minResultChange,
+		MinFundingQuarters: *minFundingQuarters,
+		AISelection:        s.aiSelection,
+
+		SaveDocumentsDir: *savePDFsDir,
+		DocumentStore:    uploader.documentStore(),
+
+		Snippet:     asx.SnippetOptions{Window: *snippetWindow, Sentences: *snippetSentences},
+		TitlesFirst: *titlesFirst,
+		Layout:      s.layout,
+		Stats:       stats,
+		Progress:    s.progress,
+		AIWorkers:   *aiWorkers,
+		Semantic:    s.concepts,
+		Previous:    s.previousReports(),
+	})
+	if !retryable(stats) {
+		s.processed.advance(date, fetched)
+	}
+
+	if s.archive != nil {
+		if n, err := s.archive.Flush(ctx); err != nil {
+			log.Printf("Error archiving announcements: %v", err)
+		} else {
+			log.Printf("Archived %d announcement(s) to %s.", n, s.archive.Path())
+		}
+	}
+
+	// Claimed before grouping and filtering, so instances sharing a history store never both
+	// notify and dropped matches aren't reprocessed.
+	annotatedMatches = s.history.ClaimMatches(annotatedMatches)
+
+	if grouped := asx.GroupJoint(annotatedMatches); len(grouped) < len(annotatedMatches) {
+		log.Printf("Grouped %d match(es) on documents released under several tickers", len(annotatedMatches)-len(grouped))
+		annotatedMatches = grouped
+	}
+
+	annotatedMatches = applyPreNotifyHooks(ctx, s.hooks, annotatedMatches)
+	annotatedMatches = asx.FilterByScore(annotatedMatches, *minAIScore)
+	annotatedMatches = asx.FilterByGeo(annotatedMatches, s.geoFilter)
+	if *thesisMode == "filter" {
+		annotatedMatches = asx.FilterByThesis(annotatedMatches)
+	}
+	_ = asx.SortMatches(annotatedMatches, *sortOrder) // validated in newScanner
+	if *priceReaction {
+		addPriceReactions(ctx, annotatedMatches)
+	}
+	if *shortInterest && len(annotatedMatches) > 0 {
+		addShortInterest(ctx, annotatedMatches, s.clock.Now())
+	}
+	result.Matches = annotatedMatches
+
+	if !*quiet && *outputFormat != "text" {
+		if err := notify.WriteMatches(os.Stdout, *outputFormat, date, annotatedMatches); err != nil {
+			log.Printf("Error writing matches: %v", err)
+		}
+	}
+
+	if len(annotatedMatches) == 0 {
+		log.Println("No new matching keywords found in any announcement today.")
+	} else {
+		_, span := telemetry.Start(ctx, "notify", "matches", strconv.Itoa(len(annotatedMatches)))
+
+		if s.routes != nil {
+			log.Printf("Match severity: %s.", s.routes.Summary(annotatedMatches))
+		}
+
+		if !*quiet && *outputFormat == "text" {
+			notify.ReportMatches(s.routes.Filter(annotatedMatches, notify.ChannelConsole), s.history.HistoryFilePath())
+			notify.ReportUsage(ai.RunUsage())
+			notify.ReportBandwidth(asx.BandwidthUsage())
+		}
+
+		if s.emailConfig.Enabled {
+			instant, queued := s.splitForDigest(annotatedMatches)
+			notify.EmailMatches(instant, s.emailConfig)
+			if err := s.history.QueueDigest(queued); err != nil {
+				log.Printf("Error queueing %d match(es) for the digest: %v", len(queued), err)
+			} else if len(queued) > 0 {
+				log.Printf("Queued %d match(es) for the next digest.", len(queued))
+			}
+		}
+
+		if s.execConfig.Enabled {
+			notify.ExecMatches(s.routes.Filter(annotatedMatches, notify.ChannelExec), s.execConfig)
+		}
+
+		if *desktopNotify {
+			notify.DesktopMatches(s.routes.Filter(annotatedMatches, notify.ChannelDesktop))
+		}
+
+		notify.SyslogMatches(s.routes.Filter(annotatedMatches, notify.ChannelSyslog), s.syslogConfig)
+		notify.NtfyMatches(s.routes.Filter(annotatedMatches, notify.ChannelNtfy), s.ntfyConfig)
+		notify.SMSMatches(s.routes.Filter(annotatedMatches, notify.ChannelSMS), s.smsConfig)
+		notify.TeamsMatches(s.routes.Filter(annotatedMatches, notify.ChannelTeams), s.teamsConfig)
+		notify.MatrixMatches(s.routes.Filter(annotatedMatches, notify.ChannelMatrix), s.matrixConfig)
+		notify.NATSMatches(s.routes.Filter(annotatedMatches, notify.ChannelNATS), s.natsConfig)
+		notify.KafkaMatches(s.routes.Filter(annotatedMatches, notify.ChannelKafka), s.kafkaConfig)
+		notify.MQTTMatches(s.routes.Filter(annotatedMatches, notify.ChannelMQTT), s.mqttConfig)
+
+		if *icsFile != "" {
+			if n, err := notify.WriteCalendar(*icsFile, annotatedMatches); err != nil {
+				log.Printf("Error writing calendar: %v", err)
+			} else {
+				log.Printf("Wrote %d key date(s) to %s", n, *icsFile)
+			}
+		}
+		span.End(nil)
+	}
+
+	if *reportHTML != "" {
+		if path, err := notify.WriteHTMLReport(*reportHTML, date, annotatedMatches); err != nil {
+			log.Printf("Error writing HTML report: %v", err)
+		} else {
+			log.Printf("Wrote HTML report of %d match(es) to %s", len(annotatedMatches), path)
+		}
+	}
+
+	uploader.uploadMatches(ctx, annotatedMatches)
+
+	s.history.RecordNotifications(annotatedMatches)
+	log.Printf("Saved history to: %s.", s.history.HistoryFilePath())
+
+	var downloaded int64
+	for _, n := range asx.BandwidthUsage() {
+		downloaded += n
+	}
+	log.Printf("Downloaded %s this run.", notify.FormatBytes(downloaded))
+	if asx.BandwidthExceeded() {
+		log.Printf("Warning: Bandwidth cap of %d MiB was reached.", *maxBandwidthMB)
+		notify.SyslogStatus(s.syslogConfig, notify.SeverityWarning, "bandwidth cap of %d MiB reached", *maxBandwidthMB)
+	}
+
+	if !fetchStats.Complete() {
+		log.Printf("Warning: Incomplete feed. Received %d of %d reported announcements (%d parsed). Results may be truncated.", fetchStats.Received, fetchStats.Reported, fetchStats.Parsed)
+		notify.SyslogStatus(s.syslogConfig, notify.SeverityWarning, "incomplete feed: received %d of %d reported announcements", fetchStats.Received, fetchStats.Reported)
+	}
+
+	notify.SyslogStatus(s.syslogConfig, notify.SeverityInfo, "scan of %s complete: %d announcements, %d new matches, %s downloaded",
+		date, totalAnns, len(annotatedMatches), notify.FormatBytes(downloaded))
+
+	summary := notify.RunSummary{
+		Date:          date,
+		Duration:      time.Since(result.StartedAt),
+		Reported:      fetchStats.Reported,
+		Received:      fetchStats.Received,
+		Announcements: totalAnns,
+		Processed:     stats.Processed,
+		Downloaded:    stats.Downloaded,
+		Cached:        stats.Cached,
+		Bandwidth:     asx.BandwidthUsage(),
+		Matches:       annotatedMatches,
+		Failures:      stats.Failures,
+		Usage:         ai.RunUsage(),
+	}
+	if !*quiet && *outputFormat == "text" {
+		notify.ReportRunSummary(summary)
+	}
+	if *emailSummary {
+		if err := notify.EmailRunSummary(summary, s.emailConfig); err != nil {
+			log.Printf("Error emailing run summary: %v", err)
+		}
+	}
+
+	return result
+}
+
+// errorReporter returns the reporter as an asx.ErrorReporter, keeping a nil reporter a nil interface.
+func (s *scanner) errorReporter() asx.ErrorReporter {
+	if s.reporter == nil {
+		return nil
+	}
+	return s.reporter
+}
+
+// addPriceReactions attaches the market's reaction since release to each match.
+func addPriceReactions(ctx context.Context, matches []types.AnnotatedMatch) {
+	for i := range matches {
+		m := &matches[i].Match
+		reaction, err := prices.Fetch(ctx, m.Ticker, m.DateTime)
+		if err != nil {
+			log.Printf("Warning: Could not fetch price reaction for %s: %v", m.Ticker, err)
+			continue
+		}
+		m.Reaction = reaction
+	}
+}
+
+// addShortInterest attaches each match's short position from the latest ASIC report.
+func addShortInterest(ctx context.Context, matches []types.AnnotatedMatch, now time.Time) {
+	report, err := shorts.Latest(ctx, paths.CacheDir(), now)
+	if err != nil {
+		log.Printf("Warning: Could not load ASIC short positions: %v", err)
+		return
+	}
+	for i := range matches {
+		if p, ok := report.Lookup(matches[i].Match.Ticker); ok {
+			matches[i].Match.Short = p
+		}
+	}
+}
+
+func parseCompanyFilter(sectorsList, minCap, maxCap string) asx.CompanyFilter {
+	f := asx.CompanyFilter{Sectors: parseKeywords(sectorsList)}
+
+	var err error
+	if f.MinMarketCap, err = parseMarketCap(minCap); err != nil {
+		log.Fatalf("Invalid -min-market-cap: %v", err)
+	}
+	if f.MaxMarketCap, err = parseMarketCap(maxCap); err != nil {
+		log.Fatalf("Invalid -max-market-cap: %v", err)
+	}
+	if f.MaxMarketCap > 0 && f.MinMarketCap > f.MaxMarketCap {
+		log.Fatalf("-min-market-cap is greater than -max-market-cap")
+	}
+	return f
+}
+
+// parseMarketCap parses an AUD amount such as "500m", "$1.5b", "750k" or "2000000".
+func parseMarketCap(amount string) (float64, error) {
+	s := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(amount), "$"))
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := 1.0
+	for _, suffix := range []struct {
+		unit string
+		mult float64
+	}{{"bn", 1e9}, {"b", 1e9}, {"m", 1e6}, {"k", 1e3}} {
+		if rest, ok := strings.CutSuffix(s, suffix.unit); ok {
+			s, multiplier = rest, suffix.mult
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%q is not an amount like '500m' or '2b'", amount)
+	}
+	return v * multiplier, nil
+}
+
+func parseGeoFilter(statesList, commoditiesList string) geo.Filter {
+	var f geo.Filter
+	for _, st := range strings.Split(statesList, ",") {
+		if strings.TrimSpace(st) == "" {
+			continue
+		}
+		code := geo.NormaliseState(st)
+		if code == "" {
+			log.Fatalf("Unknown state %q in -states (use codes such as WA, NSW, QLD)", strings.TrimSpace(st))
+		}
+		f.States = append(f.States, code)
+	}
+	for _, c := range strings.Split(commoditiesList, ",") {
+		if strings.TrimSpace(c) != "" {
+			f.Commodities = append(f.Commodities, geo.NormaliseCommodity(c))
+		}
+	}
+	if f.Active() {
+		log.Printf("Filtering for states [%s] and commodities [%s]", strings.Join(f.States, ","), strings.Join(f.Commodities, ","))
+	}
+	return f
+}
+
+// archiver returns the archive as an asx.Archiver, keeping a nil archive a nil interface.
+func (s *scanner) archiver() asx.Archiver {
+	if s.archive == nil {
+		return nil
+	}
+	return s.archive
+}
+
+// previousReports returns where -changes looks up earlier reports, nil when it is off.
+func (s *scanner) previousReports() asx.PreviousReports {
+	if !*showChanges {
+		return nil
+	}
+	return s.archive
+}
+
+func applyPreNotifyHooks(ctx context.Context, runner *hooks.Runner, matches []types.AnnotatedMatch) []types.AnnotatedMatch {
+	if !runner.Has(hooks.PreNotify) {
+		return matches
+	}
+
+	var kept []types.AnnotatedMatch
+	for _, am := range matches {
+		p := &hooks.Payload{Stage: hooks.PreNotify, Match: &am.Match, Analysis: am.Analysis}
+		if !runner.Run(ctx, p) {
+			continue
+		}
+		if p.Match != nil {
+			am.Match = *p.Match
+		}
+		am.Analysis = p.Analysis
+		kept = append(kept, am)
+	}
+	return kept
+}
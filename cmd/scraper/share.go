@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/history"
+	"github.com/shanehull/annscraper/internal/notify"
+)
+
+// shareToken signs a notification ID so share links can't be guessed from the ID alone.
+func shareToken(key []byte, id string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// shareURL builds the public link for a notification.
+func shareURL(baseURL string, key []byte, id string) string {
+	return fmt.Sprintf("%s/share/%s?token=%s", strings.TrimRight(baseURL, "/"), url.PathEscape(id), shareToken(key, id))
+}
+
+// shareBaseURL is -share-url, or a localhost URL on the -share-addr port.
+func shareBaseURL() string {
+	if *shareURLFlag != "" {
+		return *shareURLFlag
+	}
+	_, port, err := net.SplitHostPort(*shareAddr)
+	if err != nil || port == "" {
+		port = "8080"
+	}
+	return "http://localhost:" + port
+}
+
+// shareHandler serves read-only match pages, rendered like the email, to anyone holding a valid token.
+func shareHandler(historyManager *history.Manager, key []byte) http.Handler {
+	renderer := notify.NewHTMLEmailRenderer()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /share/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		token := r.URL.Query().Get("token")
+		if !hmac.Equal([]byte(token), []byte(shareToken(key, id))) {
+			http.NotFound(w, r)
+			return
+		}
+
+		n, err := historyManager.Notification(id)
+		if err != nil || n.Deleted() {
+			http.NotFound(w, r)
+			return
+		}
+
+		msg, err := renderer.Render(notify.NotificationData{Match: n.Match.Match, Analysis: n.Match.Analysis})
+		if err != nil {
+			log.Printf("Error rendering share page for %s: %v", id, err)
+			http.Error(w, "failed to render page", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("X-Robots-Tag", "noindex")
+		_, _ = w.Write([]byte(msg.HTML))
+	})
+	return mux
+}
+
+// serveShares listens on -share-addr until the server is shut down. It returns nil when sharing is off.
+func serveShares(historyManager *history.Manager) *http.Server {
+	if *shareAddr == "" {
+		return nil
+	}
+
+	key, err := historyManager.ShareKey()
+	if err != nil {
+		log.Fatalf("Fatal error: %v", err)
+	}
+
+	server := &http.Server{Addr: *shareAddr, Handler: shareHandler(historyManager, key)}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Error serving share pages: %v", err)
+		}
+	}()
+
+	log.Printf("Serving share pages on %s (links use %s).", *shareAddr, shareBaseURL())
+	return server
+}
+
+// printShareLink prints the share URL for a stored notification.
+func printShareLink(historyManager *history.Manager, id string) {
+	n, err := historyManager.Notification(id)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if n.Deleted() {
+		log.Fatalf("Error: notification %s is deleted.", id)
+	}
+
+	key, err := historyManager.ShareKey()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	fmt.Println(shareURL(shareBaseURL(), key, id))
+}
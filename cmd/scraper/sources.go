@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/asx"
+	"github.com/shanehull/annscraper/internal/notify"
+)
+
+// checkSources sends a single notification when ASX requests start failing and another when
+// they recover, rather than one failure per announcement.
+func (s *scanner) checkSources() {
+	degraded := asx.DegradedSources()
+
+	switch {
+	case len(degraded) > 0 && !s.sourceDegraded:
+		s.sourceDegraded = true
+
+		var lines []string
+		for _, host := range slices.Sorted(maps.Keys(degraded)) {
+			lines = append(lines, fmt.Sprintf("%s: %s", host, degraded[host]))
+		}
+		log.Printf("Warning: Source degraded, backing off: %s", strings.Join(lines, "; "))
+		notify.SyslogStatus(s.syslogConfig, notify.SeverityWarning, "source degraded: %s", strings.Join(lines, "; "))
+		if err := notify.EmailStatus("ASX Scraper: source degraded",
+			"Requests to the following sources are failing repeatedly and have been paused:\n\n"+
+				strings.Join(lines, "\n")+"\n\nAlerts may be delayed until they recover.", s.emailConfig); err != nil {
+			log.Printf("Warning: failed to send source degraded notification: %v", err)
+		}
+
+	case len(degraded) == 0 && s.sourceDegraded:
+		s.sourceDegraded = false
+
+		log.Printf("Sources have recovered.")
+		notify.SyslogStatus(s.syslogConfig, notify.SeverityInfo, "sources recovered")
+		if err := notify.EmailStatus("ASX Scraper: sources recovered",
+			"Requests to the ASX are succeeding again.", s.emailConfig); err != nil {
+			log.Printf("Warning: failed to send source recovered notification: %v", err)
+		}
+	}
+}
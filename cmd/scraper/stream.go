@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/history"
+	"github.com/shanehull/annscraper/internal/schema"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	streamBuffer    = 64
+	streamKeepalive = 30 * time.Second
+)
+
+// matchStream fans out new matches to Server-Sent Events subscribers.
+type matchStream struct {
+	mutex       sync.Mutex
+	subscribers map[chan types.AnnotatedMatch]struct{}
+}
+
+func newMatchStream() *matchStream {
+	return &matchStream{subscribers: make(map[chan types.AnnotatedMatch]struct{})}
+}
+
+func (s *matchStream) subscribe() chan types.AnnotatedMatch {
+	ch := make(chan types.AnnotatedMatch, streamBuffer)
+	s.mutex.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mutex.Unlock()
+	return ch
+}
+
+func (s *matchStream) unsubscribe(ch chan types.AnnotatedMatch) {
+	s.mutex.Lock()
+	delete(s.subscribers, ch)
+	s.mutex.Unlock()
+}
+
+// publish sends matches to every subscriber. Subscribers that have fallen a full buffer behind
+// miss matches rather than stalling the scan.
+func (s *matchStream) publish(matches []types.AnnotatedMatch) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for ch := range s.subscribers {
+		for _, am := range matches {
+			select {
+			case ch <- am:
+			default:
+				log.Printf("Warning: Stream subscriber is not keeping up, dropped %s match.", am.Match.Ticker)
+			}
+		}
+	}
+}
+
+// ServeHTTP streams each new match as a "match" event whose data is the AnnotatedMatch JSON.
+func (s *matchStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case am := <-ch:
+			data, err := schema.MarshalMatch(am)
+			if err != nil {
+				log.Printf("Error encoding streamed match: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: match\nid: %s\ndata: %s\n\n", history.NotificationID(am.Match), data)
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// serveStream serves the match stream on -stream-addr, if set.
+func serveStream(stream *matchStream) *http.Server {
+	if *streamAddr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /stream", stream)
+	server := &http.Server{Addr: *streamAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Error serving match stream: %v", err)
+		}
+	}()
+
+	log.Printf("Streaming matches as Server-Sent Events on http://%s/stream.", *streamAddr)
+	return server
+}
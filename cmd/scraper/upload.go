@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/asx"
+	"github.com/shanehull/annscraper/internal/s3"
+	"github.com/shanehull/annscraper/internal/schema"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// runUploader stores one run's match documents and match JSON in object storage under
+// runs/DATE/RUN-TIMESTAMP/.
+type runUploader struct {
+	client *s3.Client
+	prefix string
+}
+
+func newS3ClientFromFlags() *s3.Client {
+	if *s3Bucket == "" {
+		return nil
+	}
+
+	client, err := s3.New(s3.Config{
+		Endpoint:  *s3Endpoint,
+		Region:    *s3Region,
+		Bucket:    *s3Bucket,
+		Prefix:    *s3Prefix,
+		PathStyle: *s3PathStyle,
+	}.WithEnv())
+	if err != nil {
+		log.Fatalf("Fatal error configuring S3 upload: %v", err)
+	}
+	log.Printf("Uploading matches and documents to bucket %s.", *s3Bucket)
+	return client
+}
+
+// newRunUploader returns nil when client is nil so it can be passed straight to asx.ProcessParams.
+func newRunUploader(client *s3.Client, date string, startedAt time.Time) *runUploader {
+	if client == nil {
+		return nil
+	}
+	return &runUploader{
+		client: client,
+		prefix: fmt.Sprintf("runs/%s/%s/", date, startedAt.UTC().Format("20060102T150405Z")),
+	}
+}
+
+// documentStore returns the uploader as an asx.DocumentStore, keeping a nil uploader a nil interface.
+func (u *runUploader) documentStore() asx.DocumentStore {
+	if u == nil {
+		return nil
+	}
+	return u
+}
+
+// StoreDocument uploads a match's attachment.
+func (u *runUploader) StoreDocument(ctx context.Context, ann types.Announcement, data []byte, contentType string) error {
+	return u.client.Put(ctx, u.prefix+"documents/"+asx.DocumentPath(ann, contentType), data, contentType)
+}
+
+// uploadMatches writes the run's reported matches as JSON.
+func (u *runUploader) uploadMatches(ctx context.Context, matches []types.AnnotatedMatch) {
+	if u == nil {
+		return
+	}
+
+	docs := make([]json.RawMessage, 0, len(matches))
+	for _, am := range matches {
+		doc, err := schema.MarshalMatch(am)
+		if err != nil {
+			log.Printf("Error encoding matches for upload: %v", err)
+			return
+		}
+		docs = append(docs, doc)
+	}
+	data, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding matches for upload: %v", err)
+		return
+	}
+
+	if err := u.client.Put(ctx, u.prefix+"matches.json", data, "application/json"); err != nil {
+		log.Printf("Error uploading matches: %v", err)
+		return
+	}
+	log.Printf("Uploaded %d match(es) to %smatches.json.", len(matches), u.prefix)
+}
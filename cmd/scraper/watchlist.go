@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/asx"
+	"github.com/shanehull/annscraper/internal/paths"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// readWatchlist reads a file of tickers, one per line. Blank lines and anything after '#'
+// are ignored, so entries can carry a note: "BHP  # iron ore".
+func readWatchlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tickers []string
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.ToUpper(strings.TrimSpace(line))
+		if line == "" {
+			continue
+		}
+		if strings.ContainsAny(line, " \t,") {
+			return nil, fmt.Errorf("%s:%d: expected one ticker per line, got %q", path, lineNo, line)
+		}
+		if !slices.Contains(tickers, line) {
+			tickers = append(tickers, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tickers, nil
+}
+
+// loadTickers parses a comma-separated ticker list, or reads "@path" in the -watchlist format.
+func loadTickers(spec string) ([]string, error) {
+	if path, ok := strings.CutPrefix(spec, "@"); ok {
+		return readWatchlist(path)
+	}
+	return parseTickers(spec), nil
+}
+
+// excludeTickers returns the announcements not lodged by one of the excluded tickers,
+// leaving announcements itself untouched.
+func excludeTickers(announcements []types.Announcement, excluded []string) []types.Announcement {
+	var kept []types.Announcement
+	for _, ann := range announcements {
+		if !slices.Contains(excluded, ann.Ticker) {
+			kept = append(kept, ann)
+		}
+	}
+	return kept
+}
+
+// dropBoilerplate returns the announcements that aren't routine fund or plan notices, keeping
+// those of the watched tickers, and leaves announcements itself untouched.
+func dropBoilerplate(announcements []types.Announcement, watched []string) []types.Announcement {
+	var kept []types.Announcement
+	for _, ann := range announcements {
+		if !asx.IsBoilerplate(ann.Title) || slices.Contains(watched, ann.Ticker) {
+			kept = append(kept, ann)
+		}
+	}
+	return kept
+}
+
+// filterTitles returns the announcements whose title matches include (if set) and doesn't
+// match exclude (if set), leaving announcements itself untouched.
+func filterTitles(announcements []types.Announcement, include, exclude *regexp.Regexp) []types.Announcement {
+	var kept []types.Announcement
+	for _, ann := range announcements {
+		if (include == nil || include.MatchString(ann.Title)) && (exclude == nil || !exclude.MatchString(ann.Title)) {
+			kept = append(kept, ann)
+		}
+	}
+	return kept
+}
+
+// reloadWatchlist re-reads -watchlist so edits apply to the next scan without a restart.
+// On error the previous watchlist is kept.
+func (s *scanner) reloadWatchlist() {
+	if *watchlistPath == "" {
+		return
+	}
+
+	tickers, err := readWatchlist(*watchlistPath)
+	if err != nil {
+		log.Printf("Warning: Could not read watchlist, keeping %d previous ticker(s): %v", len(s.watchlist), err)
+		return
+	}
+	if !slices.Equal(tickers, s.watchlist) {
+		log.Printf("Loaded %d ticker(s) from watchlist %s: [%s]", len(tickers), *watchlistPath, strings.Join(tickers, ","))
+		s.processed = watermark{} // check the day's earlier announcements for newly added tickers
+	}
+	s.watchlist = tickers
+}
+
+// reloadDirectory refreshes the ASX company directory used for -companies and company names.
+// On error the previous directory is kept.
+func (s *scanner) reloadDirectory() {
+	directory, err := asx.LoadDirectory(paths.CacheDir())
+	if err != nil {
+		log.Printf("Warning: Could not load the ASX company directory: %v", err)
+		return
+	}
+	s.directory = directory
+
+	if len(s.companies) > 0 {
+		codes := directory.MatchNames(s.companies)
+		if len(codes) == 0 {
+			log.Printf("Warning: No listed companies match -companies [%s]", strings.Join(s.companies, ","))
+		} else if !slices.Equal(codes, s.companyTickers) {
+			log.Printf("Companies [%s] resolved to tickers: [%s]", strings.Join(s.companies, ","), strings.Join(codes, ","))
+			s.processed = watermark{} // check the day's earlier announcements for newly resolved tickers
+		}
+		s.companyTickers = codes
+	}
+}
+
+// allTickers returns the -tickers flag combined with the current watchlist and the tickers
+// resolved from -companies.
+func (s *scanner) allTickers() []string {
+	tickers := slices.Clone(s.tickers)
+	for _, t := range slices.Concat(s.watchlist, s.companyTickers) {
+		if !slices.Contains(tickers, t) {
+			tickers = append(tickers, t)
+		}
+	}
+	return tickers
+}
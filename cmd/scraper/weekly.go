@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/archive"
+	"github.com/shanehull/annscraper/internal/history"
+	"github.com/shanehull/annscraper/internal/notify"
+)
+
+// weeklyWrapHour is the local hour after which the daemon sends the weekly wrap on -weekly-wrap day.
+const weeklyWrapHour = 17
+
+// buildWeeklyWrap collects the matches notified between from and to, and the archived
+// announcement count when the archive is enabled.
+func buildWeeklyWrap(ctx context.Context, historyManager *history.Manager, from, to time.Time) (notify.WeeklyWrap, error) {
+	wrap := notify.WeeklyWrap{From: from, To: to, Scanned: -1}
+
+	notifications, err := historyManager.Notifications(false)
+	if err != nil {
+		return wrap, err
+	}
+	for _, n := range notifications {
+		if !n.SentAt.Before(from) && n.SentAt.Before(to) {
+			wrap.Matches = append(wrap.Matches, n.Match)
+		}
+	}
+
+	if *archiveEnabled {
+		if a, err := archive.Open(*archiveDB); err != nil {
+			log.Printf("Warning: archive unavailable for weekly wrap: %v", err)
+		} else if n, err := a.Count(ctx, from, to); err != nil {
+			log.Printf("Warning: %v", err)
+		} else {
+			wrap.Scanned = n
+		}
+	}
+
+	return wrap, nil
+}
+
+// sendWeeklyWrap emails the wrap if email is configured, otherwise prints it.
+func sendWeeklyWrap(wrap notify.WeeklyWrap) error {
+	cfg := emailConfigFromFlags()
+	if cfg.Enabled {
+		return notify.EmailWeeklyWrap(wrap, cfg)
+	}
+
+	msg, err := notify.RenderWeeklyWrap(wrap)
+	if err != nil {
+		return err
+	}
+	fmt.Println(msg.Text)
+	return nil
+}
+
+// runWeeklyCommand builds and sends the wrap for the period ending now.
+func runWeeklyCommand(sinceStr string) {
+	now := time.Now()
+	from, err := parseSince(sinceStr, now)
+	if err != nil {
+		log.Fatalf("Invalid -since: %v", err)
+	}
+
+	wrap, err := buildWeeklyWrap(context.Background(), openHistory(), from, now)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if err := sendWeeklyWrap(wrap); err != nil {
+		log.Fatalf("Error sending weekly wrap: %v", err)
+	}
+}
+
+// parseWeekday accepts "fri", "Friday" and so on. An empty string returns ok=false.
+func parseWeekday(s string) (time.Weekday, bool, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, false, nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true, nil
+		}
+	}
+	return 0, false, fmt.Errorf("unknown weekday %q", s)
+}
+
+// weeklyWrapDue reports whether the wrap should be sent now: on the configured weekday after
+// weeklyWrapHour, and not already sent in the last six days.
+func weeklyWrapDue(now time.Time, day time.Weekday, lastSent time.Time) bool {
+	if now.Weekday() != day || now.Hour() < weeklyWrapHour {
+		return false
+	}
+	return lastSent.IsZero() || now.Sub(lastSent) > 6*24*time.Hour
+}
+
+// maybeSendWeeklyWrap is called by the daemon after each scan.
+func (d *daemon) maybeSendWeeklyWrap(ctx context.Context) {
+	if !d.weeklyEnabled {
+		return
+	}
+
+	now := d.scanner.clock.Now().In(d.scanner.loc)
+	if !weeklyWrapDue(now, d.weeklyDay, d.scanner.history.LastWeeklyWrap()) {
+		return
+	}
+
+	wrap, err := buildWeeklyWrap(ctx, d.scanner.history, now.AddDate(0, 0, -7), now)
+	if err != nil {
+		log.Printf("Error building weekly wrap: %v", err)
+		return
+	}
+	if err := sendWeeklyWrap(wrap); err != nil {
+		log.Printf("Error sending weekly wrap: %v", err)
+		return
+	}
+	if err := d.scanner.history.SetLastWeeklyWrap(now); err != nil {
+		log.Printf("Warning: failed to record weekly wrap: %v", err)
+	}
+}
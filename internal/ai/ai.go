@@ -5,9 +5,13 @@ financial analysis of ASX announcements.
 package ai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"google.golang.org/genai"
 )
@@ -17,31 +21,247 @@ type CatalystObservation struct {
 	Details  string `json:"details"`
 }
 
+// KeyDate is a dated corporate event mentioned in an announcement.
+type KeyDate struct {
+	Event string `json:"event"`
+	Date  string `json:"date"` // YYYY-MM-DD
+	Type  string `json:"type"` // one of KeyDateTypes
+}
+
+// Project is a named resource project mentioned in an announcement.
+type Project struct {
+	Name      string `json:"name"`
+	Location  string `json:"location"`  // region or district, e.g. "Pilbara"
+	State     string `json:"state"`     // Australian state/territory code, or the country if overseas
+	Commodity string `json:"commodity"` // primary commodity, e.g. "gold"
+}
+
+// ThesisFit is the model's judgement of how well an announcement fits the user's investment thesis.
+type ThesisFit struct {
+	Fits   bool   `json:"fits"`
+	Reason string `json:"reason"`
+}
+
+var KeyDateTypes = []string{"record", "ex", "meeting", "completion", "payment", "other"}
+
 type AIAnalysis struct {
 	Summary            []string              `json:"summary"`
 	PotentialCatalysts []CatalystObservation `json:"potential_catalysts"`
+	KeyDates           []KeyDate             `json:"key_dates"`
+	Projects           []Project             `json:"projects,omitempty"`
+	RelevanceScore     int                   `json:"relevance_score"` // 0-100, how actionable the announcement is
+	Confidence         int                   `json:"confidence"`      // 0-100, the model's confidence in its score
+	ThesisFit          *ThesisFit            `json:"thesis_fit,omitempty"`
+	Usage              *Usage                `json:"usage,omitempty"` // set locally, not by the model
+	Model              string                `json:"model,omitempty"` // set locally: the model that produced the analysis
 }
 
-func GenerateSummary(ctx context.Context, ticker string, text string, historicAnnouncementsList []string, apiKey string, modelName string) (*AIAnalysis, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("gemini API key is required")
+// HistoricDocument is the condensed text of one of the company's earlier announcements.
+type HistoricDocument struct {
+	Title string
+	Date  string // YYYY-MM-DD
+	URL   string
+	Text  string
+}
+
+// Request is an announcement to analyse.
+type Request struct {
+	Ticker        string
+	Text          string             // extracted document text
+	PDF           []byte             // raw PDF to send instead of the text where possible, nil = text only
+	Historic      []string           // recent announcements by the company, as "Title - URL"
+	HistoricTexts []HistoricDocument // text of the most recent of those, nil = links only
+	Changes       string             // what changed since the previous report of the same kind, "" = none
+	APIKey        string
+	Models        []string // tried in order, each failure falling back to the next
+}
+
+// GenerateSummary analyses an announcement. With req.PDF set the raw PDF is uploaded
+// through the Gemini Files API so the model can read tables and figures natively, falling
+// back to the extracted text if the upload fails. Each model call is bounded by the
+// timeout set with SetTimeout as well as by ctx.
+func GenerateSummary(ctx context.Context, req Request) (*AIAnalysis, error) {
+	if len(req.Models) == 0 {
+		return nil, fmt.Errorf("no model configured")
+	}
+	client, err := newClient(ctx, req.APIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	promptData := PromptData{
+		Ticker:                req.Ticker,
+		Text:                  req.Text,
+		HistoricAnnouncements: req.Historic,
+		HistoricTexts:         req.HistoricTexts,
+		Changes:               req.Changes,
+	}
+	if req.PDF == nil {
+		return generateWithFallback(ctx, client, req.Models, promptData, nil)
+	}
+
+	// The Files API is only available on the Gemini API; Vertex AI accepts the PDF inline.
+	if backend.Backend == BackendVertex {
+		promptData.Attached = true
+		return generateWithFallback(ctx, client, req.Models, promptData, []*genai.Part{
+			genai.NewPartFromBytes(req.PDF, "application/pdf"),
+		})
+	}
+
+	uploadCtx, cancel := withCallTimeout(ctx)
+	file, err := client.Files.Upload(uploadCtx, bytes.NewReader(req.PDF), &genai.UploadFileConfig{
+		MIMEType:    "application/pdf",
+		DisplayName: req.Ticker + ".pdf",
+	})
+	cancel()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("Warning: PDF upload failed for %s, falling back to extracted text: %v", req.Ticker, err)
+		return generateWithFallback(ctx, client, req.Models, promptData, nil)
 	}
+	defer func() {
+		// Clean up even when ctx was cancelled, but don't let a hung request block the caller.
+		deleteCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fileDeleteTimeout)
+		defer cancel()
+		if _, err := client.Files.Delete(deleteCtx, file.Name, nil); err != nil {
+			log.Printf("Warning: Failed to delete uploaded file %s: %v", file.Name, err)
+		}
+	}()
+
+	promptData.Attached = true
+	return generateWithFallback(ctx, client, req.Models, promptData, []*genai.Part{
+		genai.NewPartFromURI(file.URI, file.MIMEType),
+	})
+}
+
+// fileDeleteTimeout bounds the cleanup of an uploaded PDF.
+const fileDeleteTimeout = 30 * time.Second
 
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+const (
+	BackendGemini = "gemini"
+	BackendVertex = "vertex"
+)
+
+// BackendConfig selects the API used for analysis. Vertex AI authenticates with
+// Application Default Credentials (e.g. a service account) unless an API key is supplied.
+type BackendConfig struct {
+	Backend  string
+	Project  string
+	Location string
+}
+
+var (
+	backend = BackendConfig{Backend: BackendGemini}
+	thesis  string
+)
+
+// SetThesis sets a free-text investment thesis that each analysis is judged against. Empty disables it.
+func SetThesis(t string) {
+	thesis = strings.TrimSpace(t)
+}
+
+// PromptFingerprint identifies the settings that change an analysis, for use in cache keys:
+// the model chain, the prompt templates, whether the PDF itself is analysed and the thesis.
+func PromptFingerprint(models []string, pdf bool) string {
+	mode := "text"
+	if pdf {
+		mode = "pdf"
+	}
+	return strings.Join([]string{strings.Join(models, ","), templatesHash(), mode, thesis}, "\x00")
+}
+
+// SetBackend configures the backend used for all subsequent Gemini calls.
+func SetBackend(cfg BackendConfig) error {
+	switch cfg.Backend {
+	case BackendGemini:
+	case BackendVertex:
+		if cfg.Project == "" {
+			return fmt.Errorf("vertex backend requires a project")
+		}
+		if cfg.Location == "" {
+			return fmt.Errorf("vertex backend requires a location")
+		}
+	default:
+		return fmt.Errorf("unknown AI backend %q (expected %q or %q)", cfg.Backend, BackendGemini, BackendVertex)
+	}
+	backend = cfg
+	return nil
+}
+
+// Enabled reports whether analysis can run: an API key is set or Vertex AI is configured.
+func Enabled(apiKey string) bool {
+	return apiKey != "" || backend.Backend == BackendVertex
+}
+
+func newClient(ctx context.Context, apiKey string) (*genai.Client, error) {
+	cfg := &genai.ClientConfig{
 		APIKey:  apiKey,
 		Backend: genai.BackendGeminiAPI,
-	})
+	}
+
+	if backend.Backend == BackendVertex {
+		cfg.Backend = genai.BackendVertexAI
+		cfg.Project = backend.Project
+		cfg.Location = backend.Location
+	} else if apiKey == "" {
+		return nil, fmt.Errorf("gemini API key is required")
+	}
+
+	client, err := genai.NewClient(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gemini client: %w", err)
+		return nil, fmt.Errorf("failed to create %s client: %w", backend.Backend, err)
 	}
+	return client, nil
+}
+
+// generateWithFallback tries each model in turn until one produces an analysis. Quota errors
+// move straight on to the next model instead of waiting out the backoff.
+func generateWithFallback(ctx context.Context, client *genai.Client, models []string, promptData PromptData, extraParts []*genai.Part) (*AIAnalysis, error) {
+	var lastErr error
+	for i, modelName := range models {
+		fallback := i < len(models)-1
+		analysis, err := generate(ctx, client, modelName, promptData, extraParts, fallback)
+		if err == nil {
+			analysis.Model = modelName
+			return analysis, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		lastErr = err
+		if fallback {
+			log.Printf("Warning: %s failed for %s, falling back to %s: %v", modelName, promptData.Ticker, models[i+1], err)
+		}
+	}
+	return nil, lastErr
+}
 
-	contents := genai.Text(
-		buildUserPrompt(text, historicAnnouncementsList),
-	)
+// generate renders the prompts, calls the model and decodes the structured analysis.
+// Any extra parts (e.g. an uploaded document) are sent ahead of the user prompt. With
+// quotaFallback set, quota errors are returned without retrying.
+func generate(ctx context.Context, client *genai.Client, modelName string, promptData PromptData, extraParts []*genai.Part, quotaFallback bool) (*AIAnalysis, error) {
+	promptData.Thesis = thesis
+
+	userPrompt, err := buildUserPrompt(promptData)
+	if err != nil {
+		return nil, err
+	}
+
+	systemPrompt, err := buildSystemPrompt(promptData)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := append(extraParts, genai.NewPartFromText(userPrompt))
+	contents := []*genai.Content{
+		genai.NewContentFromParts(parts, genai.RoleUser),
+	}
 
 	systemContent := &genai.Content{
 		Parts: []*genai.Part{
-			{Text: systemInstruction},
+			{Text: systemPrompt},
 		},
 	}
 
@@ -52,12 +272,12 @@ func GenerateSummary(ctx context.Context, ticker string, text string, historicAn
 		},
 	}
 
-	resp, err := client.Models.GenerateContent(ctx, modelName, contents, &genai.GenerateContentConfig{
+	resp, err := generateContent(ctx, client, modelName, contents, &genai.GenerateContentConfig{
 		SystemInstruction: systemContent,
 		ResponseMIMEType:  "application/json",
-		ResponseSchema:    getResponseSchema(),
+		ResponseSchema:    getResponseSchema(thesis != ""),
 		Tools:             tools,
-	})
+	}, quotaFallback)
 	if err != nil {
 		return nil, fmt.Errorf("gemini API call failed: %w", err)
 	}
@@ -68,11 +288,12 @@ func GenerateSummary(ctx context.Context, ticker string, text string, historicAn
 	if err := json.Unmarshal([]byte(respText), &analysis); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal gemini JSON response: %w. Raw text: %s", err, respText)
 	}
+	analysis.Usage = recordUsage(modelName, resp.UsageMetadata)
 
 	return &analysis, nil
 }
 
-func getResponseSchema() *genai.Schema {
+func getResponseSchema(withThesis bool) *genai.Schema {
 	catalystSchema := &genai.Schema{
 		Type: genai.TypeObject,
 		Properties: map[string]*genai.Schema{
@@ -82,9 +303,44 @@ func getResponseSchema() *genai.Schema {
 		Required: []string{"category", "details"},
 	}
 
-	return &genai.Schema{
+	keyDateSchema := &genai.Schema{
 		Type: genai.TypeObject,
 		Properties: map[string]*genai.Schema{
+			"event": {Type: genai.TypeString, Description: "Short description of the event, e.g. 'Scheme meeting'."},
+			"date":  {Type: genai.TypeString, Description: "The event date in YYYY-MM-DD format."},
+			"type":  {Type: genai.TypeString, Enum: KeyDateTypes, Description: "The kind of date."},
+		},
+		Required: []string{"event", "date", "type"},
+	}
+
+	projectSchema := &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"name":      {Type: genai.TypeString, Description: "Project or asset name, e.g. 'Pilgangoora'."},
+			"location":  {Type: genai.TypeString, Description: "Region or district, e.g. 'Pilbara'."},
+			"state":     {Type: genai.TypeString, Description: "Australian state/territory code (WA, NSW, QLD, VIC, SA, TAS, NT, ACT), or the country if outside Australia."},
+			"commodity": {Type: genai.TypeString, Description: "Primary commodity in lower case, e.g. 'gold', 'lithium'."},
+		},
+		Required: []string{"name", "location", "state", "commodity"},
+	}
+
+	scoreMin, scoreMax := 0.0, 100.0
+
+	schema := &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"relevance_score": {
+				Type:        genai.TypeInteger,
+				Minimum:     &scoreMin,
+				Maximum:     &scoreMax,
+				Description: "0-100 rating of how actionable and significant the announcement is for a special situations investor.",
+			},
+			"confidence": {
+				Type:        genai.TypeInteger,
+				Minimum:     &scoreMin,
+				Maximum:     &scoreMax,
+				Description: "0-100 confidence in the relevance score given the available information.",
+			},
 			"summary": {
 				Type:        genai.TypeArray,
 				Items:       &genai.Schema{Type: genai.TypeString},
@@ -95,7 +351,31 @@ func getResponseSchema() *genai.Schema {
 				Items:       catalystSchema,
 				Description: "A list of specific, actionable observations.",
 			},
+			"key_dates": {
+				Type:        genai.TypeArray,
+				Items:       keyDateSchema,
+				Description: "Future dates stated in the document (record, ex, meeting, completion, payment dates).",
+			},
+			"projects": {
+				Type:        genai.TypeArray,
+				Items:       projectSchema,
+				Description: "Resource projects the announcement is about. Empty for non-resource companies.",
+			},
 		},
-		Required: []string{"summary", "potential_catalysts"},
+		Required: []string{"summary", "potential_catalysts", "key_dates", "relevance_score", "confidence"},
 	}
+
+	if withThesis {
+		schema.Properties["thesis_fit"] = &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"fits":   {Type: genai.TypeBoolean, Description: "Whether the announcement fits the investment thesis."},
+				"reason": {Type: genai.TypeString, Description: "One or two sentences explaining the judgement."},
+			},
+			Required: []string{"fits", "reason"},
+		}
+		schema.Required = append(schema.Required, "thesis_fit")
+	}
+
+	return schema
 }
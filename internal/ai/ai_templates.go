@@ -1,8 +1,12 @@
 package ai
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"strings"
+	"text/template"
 )
 
 const systemInstruction = `
@@ -130,6 +134,12 @@ For all "potential_catalysts", the "details" field MUST contain specific, verifi
 7.  **Insider Holdings:** Exact share counts, percentages, or transaction sizes for insider/major investor activity.
 8.  **Tax Implications:** Quantifiable tax benefits or impacts that affect valuation.
 
+Rate the announcement with a "relevance_score" from 0 to 100, where 0 is routine administration with no investment relevance and 100 is a clear, quantified special situation that exceeds the hurdle rates below. Give your "confidence" in that score from 0 to 100.
+
+For "key_dates", list every future date explicitly stated in the document that an investor would act on (record, ex, meeting, completion or payment dates). Only include dates that appear in the text, never estimate them.
+
+For "projects", list the mining or energy projects the announcement concerns with their region, state and primary commodity. Leave it empty for companies outside the resources sector.
+
 Avoid generic statements... All claims must be tied to a number, date, or specific condition. Exclude 'business-as-usual' operational updates (e.g., routine project progress, general market outlooks, or standard appointment of minor consultants) unless they explicitly trigger one of the provided formulas. If there are no actionable catalysts, do not return any.
 
 Any spreads, discounts and expected returns must be significant enough to account for risk. As a rule of thumb, a 20% hurdle rate should be the absolute minimum. For Net-Net's or distressed securities, the hurdle rate should be well above 30%.
@@ -181,24 +191,110 @@ Before taking any action (either tool calls _or_ responses to the user), you mus
 9. Inhibit your response: only take an action after all the above reasoning is completed. Once you've taken an action, you cannot take it back.
 `
 
-var userPromptTemplate = `
-Analyze the following document text:
+const userPromptTemplate = `
+{{if .Attached}}Analyze the attached PDF document.
+{{else}}Analyze the following document text:
 --
-%s
+{{.Text}}
 ---
+{{end}}
+{{if .Changes}}
+This is a recurring report. Lines added (+) and removed (-) since the company's previous one:
+{{.Changes}}
 
-
-You can also find links to the PDFs for the previous 3 months of price sensitive company announcements below:
-%s
+Call out material changes in the summary.
+{{end}}
+You can also find links to the PDFs for the company's recent price sensitive announcements below:
+{{join .HistoricAnnouncements "\n"}}
 
 You must use these links to gather any additional context about the company and its recent corporate actions.
-`
+{{if .HistoricTexts}}
+The links may not be fetchable, so the condensed text of the most recent of these announcements follows:
+{{range .HistoricTexts}}
+### {{.Title}} ({{.Date}})
+{{.Text}}
+{{end}}{{end}}{{if .Thesis}}
+The reader is looking for opportunities matching this investment thesis:
+"{{.Thesis}}"
+
+In "thesis_fit", judge whether this announcement and company fit the thesis, citing the specific facts that support or contradict it.
+{{end}}`
+
+// PromptData is the data available to the system and user prompt templates.
+type PromptData struct {
+	Ticker                string
+	Text                  string
+	HistoricAnnouncements []string
+	HistoricTexts         []HistoricDocument // condensed text of recent announcements, when enabled
+	Changes               string             // changes since the previous report of the same kind
+	Attached              bool               // the original PDF is attached to the request
+	Thesis                string             // optional investment thesis to judge fit against
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+var (
+	systemTmpl = template.Must(template.New("system").Funcs(templateFuncs).Parse(systemInstruction))
+	userTmpl   = template.Must(template.New("user").Funcs(templateFuncs).Parse(userPromptTemplate))
+)
 
-func buildUserPrompt(text string, historicAnnouncementsList []string) string {
-	historicAnnouncements := strings.Join(historicAnnouncementsList, "\n")
+// LoadPromptTemplates overrides the baked-in prompts with Go templates read from disk.
+// Empty paths keep the defaults. Templates can reference {{.Ticker}}, {{.Text}}, {{.Attached}},
+// {{.Thesis}}, {{.HistoricAnnouncements}} (use {{join .HistoricAnnouncements "\n"}} for a list)
+// {{.HistoricTexts}}, whose entries have .Title, .Date, .URL and .Text, and {{.Changes}}.
+func LoadPromptTemplates(systemPath, userPath string) error {
+	if systemPath != "" {
+		t, err := parsePromptFile("system", systemPath)
+		if err != nil {
+			return err
+		}
+		systemTmpl = t
+	}
+
+	if userPath != "" {
+		t, err := parsePromptFile("user", userPath)
+		if err != nil {
+			return err
+		}
+		userTmpl = t
+	}
+
+	return nil
+}
+
+// templatesHash identifies the prompt templates in use, baked-in or loaded.
+func templatesHash() string {
+	sum := sha256.Sum256([]byte(systemTmpl.Root.String() + "\x00" + userTmpl.Root.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+func parsePromptFile(name, path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s prompt file %s: %w", name, path, err)
+	}
+
+	t, err := template.New(name).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s prompt template %s: %w", name, path, err)
+	}
+	return t, nil
+}
+
+func buildSystemPrompt(data PromptData) (string, error) {
+	return executePrompt(systemTmpl, data)
+}
+
+func buildUserPrompt(data PromptData) (string, error) {
+	return executePrompt(userTmpl, data)
+}
 
-	return fmt.Sprintf(userPromptTemplate,
-		text,
-		historicAnnouncements,
-	)
+func executePrompt(t *template.Template, data PromptData) (string, error) {
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", t.Name(), err)
+	}
+	return sb.String(), nil
 }
@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// Embed returns an embedding for each text from a Gemini embedding model, through the
+// embedding rate limiter and the configured backend.
+func Embed(ctx context.Context, apiKey, modelName string, texts []string) ([][]float32, error) {
+	client, err := newClient(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+
+	if err := embedLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	callCtx, cancel := withCallTimeout(ctx)
+	defer cancel()
+	resp, err := client.Models.EmbedContent(callCtx, modelName, contents, &genai.EmbedContentConfig{
+		TaskType: "SEMANTIC_SIMILARITY",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gemini embedding call failed: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini returned %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, e := range resp.Embeddings {
+		vectors[i] = e.Values
+	}
+	return vectors, nil
+}
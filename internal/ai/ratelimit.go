@@ -0,0 +1,198 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+const (
+	maxRetries     = 5
+	baseRetryDelay = 2 * time.Second
+	maxRetryDelay  = 90 * time.Second
+)
+
+// RateLimiter spaces out requests so that no more than a fixed number start per minute.
+type RateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerMinute calls per minute. 0 = unlimited.
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	var interval time.Duration
+	if requestsPerMinute > 0 {
+		interval = time.Minute / time.Duration(requestsPerMinute)
+	}
+	return &RateLimiter{interval: interval}
+}
+
+// Wait blocks until the next request slot is available or the context is cancelled.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.interval == 0 {
+		return nil
+	}
+
+	l.mutex.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mutex.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var (
+	limiter      = NewRateLimiter(0)
+	embedLimiter = NewRateLimiter(0)
+	callTimeout  = 2 * time.Minute
+)
+
+// SetTimeout bounds each Gemini request (each attempt, when retrying). 0 = no limit
+// beyond the caller's context.
+func SetTimeout(d time.Duration) {
+	callTimeout = d
+}
+
+// withCallTimeout derives the context for a single Gemini request.
+func withCallTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if callTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, callTimeout)
+}
+
+// SetRequestsPerMinute configures the shared limiter used for all Gemini calls. 0 = unlimited.
+func SetRequestsPerMinute(requestsPerMinute int) {
+	limiter = NewRateLimiter(requestsPerMinute)
+}
+
+// SetEmbedRequestsPerMinute configures the limiter for embedding calls, which have their
+// own quota and would otherwise hold up analysis. 0 = unlimited.
+func SetEmbedRequestsPerMinute(requestsPerMinute int) {
+	embedLimiter = NewRateLimiter(requestsPerMinute)
+}
+
+// generateContent calls the Gemini API through the shared rate limiter, retrying
+// quota and transient server errors with exponential backoff. Quota errors are not
+// retried when quotaFallback is set, leaving the caller to try another model.
+func generateContent(ctx context.Context, client *genai.Client, modelName string, contents []*genai.Content, config *genai.GenerateContentConfig, quotaFallback bool) (*genai.GenerateContentResponse, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		callCtx, cancel := withCallTimeout(ctx)
+		resp, err := client.Models.GenerateContent(callCtx, modelName, contents, config)
+		cancel()
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		lastErr = err
+
+		if quotaFallback && isQuotaError(err) {
+			return nil, fmt.Errorf("gemini quota exhausted: %w", err)
+		}
+
+		delay, retryable := retryDelay(err, attempt)
+		if !retryable || attempt == maxRetries {
+			break
+		}
+
+		log.Printf("Gemini request failed (attempt %d/%d), retrying in %s: %v", attempt+1, maxRetries+1, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	if isQuotaError(lastErr) {
+		return nil, fmt.Errorf("gemini quota exhausted after %d attempts: %w", maxRetries+1, lastErr)
+	}
+	return nil, lastErr
+}
+
+// retryDelay decides whether err is worth retrying and how long to wait before doing so.
+// Server-provided RetryInfo delays take precedence over exponential backoff.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		// The request hit the per-call timeout rather than the caller's deadline.
+		return min(baseRetryDelay<<attempt, maxRetryDelay), true
+	}
+
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+
+	switch {
+	case isQuotaError(err):
+	case apiErr.Code == http.StatusInternalServerError,
+		apiErr.Code == http.StatusServiceUnavailable,
+		apiErr.Code == http.StatusGatewayTimeout:
+	default:
+		return 0, false
+	}
+
+	if d, ok := serverRetryDelay(apiErr); ok {
+		return min(d, maxRetryDelay), true
+	}
+
+	return min(baseRetryDelay<<attempt, maxRetryDelay), true
+}
+
+// isQuotaError reports whether err is a 429 / RESOURCE_EXHAUSTED response.
+func isQuotaError(err error) bool {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusTooManyRequests || apiErr.Status == "RESOURCE_EXHAUSTED"
+}
+
+// serverRetryDelay extracts the retryDelay from a google.rpc.RetryInfo error detail, if present.
+func serverRetryDelay(apiErr genai.APIError) (time.Duration, bool) {
+	for _, detail := range apiErr.Details {
+		raw, ok := detail["retryDelay"].(string)
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err == nil && d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
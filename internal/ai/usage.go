@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// modelPricing is the approximate USD price per million input and output tokens.
+// Thinking tokens are billed as output. Grounding/search charges are not included.
+var modelPricing = map[string]struct{ input, output float64 }{
+	"gemini-3-pro-preview":  {2.00, 12.00},
+	"gemini-2.5-pro":        {1.25, 10.00},
+	"gemini-2.5-flash":      {0.30, 2.50},
+	"gemini-2.5-flash-lite": {0.10, 0.40},
+	"gemini-2.0-flash":      {0.10, 0.40},
+	"gemini-2.0-flash-lite": {0.075, 0.30},
+}
+
+// Usage records token consumption and estimated cost for one or more Gemini calls.
+type Usage struct {
+	Model        string  `json:"model"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+	Priced       bool    `json:"priced"` // false if the model has no known pricing
+}
+
+var (
+	usageMutex   sync.Mutex
+	usageByModel = make(map[string]*Usage)
+)
+
+// EstimateCost returns the estimated USD cost of a call and whether the model's pricing is known.
+func EstimateCost(modelName string, inputTokens, outputTokens int) (float64, bool) {
+	price, ok := modelPricing[strings.TrimPrefix(modelName, "models/")]
+	if !ok {
+		return 0, false
+	}
+	return (float64(inputTokens)*price.input + float64(outputTokens)*price.output) / 1_000_000, true
+}
+
+// recordUsage adds a response's usage metadata to the run totals and returns the usage of that call.
+func recordUsage(modelName string, md *genai.GenerateContentResponseUsageMetadata) *Usage {
+	if md == nil {
+		return nil
+	}
+
+	in := int(md.PromptTokenCount + md.ToolUsePromptTokenCount)
+	out := int(md.CandidatesTokenCount + md.ThoughtsTokenCount)
+	cost, priced := EstimateCost(modelName, in, out)
+
+	call := &Usage{
+		Model:        modelName,
+		Calls:        1,
+		InputTokens:  in,
+		OutputTokens: out,
+		CostUSD:      cost,
+		Priced:       priced,
+	}
+
+	usageMutex.Lock()
+	defer usageMutex.Unlock()
+
+	total, ok := usageByModel[modelName]
+	if !ok {
+		total = &Usage{Model: modelName, Priced: priced}
+		usageByModel[modelName] = total
+	}
+	total.Calls++
+	total.InputTokens += in
+	total.OutputTokens += out
+	total.CostUSD += cost
+
+	return call
+}
+
+// RunUsage returns the accumulated usage for this run, one entry per model, sorted by model name.
+func RunUsage() []Usage {
+	usageMutex.Lock()
+	defer usageMutex.Unlock()
+
+	usage := make([]Usage, 0, len(usageByModel))
+	for _, u := range usageByModel {
+		usage = append(usage, *u)
+	}
+	slices.SortFunc(usage, func(a, b Usage) int { return strings.Compare(a.Model, b.Model) })
+	return usage
+}
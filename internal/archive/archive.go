@@ -0,0 +1,362 @@
+/*
+Package archive stores every scraped announcement and its extracted text in a local SQLite
+database with an FTS5 index, so past announcements can be searched without re-downloading them.
+
+The database is driven through the sqlite3 command line tool, in the same way PDF extraction
+relies on pdftotext, to keep the binary free of cgo.
+*/
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/paths"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	sqliteBinary = "sqlite3"
+	timeLayout   = time.RFC3339
+	titleWeight  = 5.0 // BM25 weight of title hits relative to body text
+
+	// schemaVersion is stored in PRAGMA user_version. Version 1 is the base tables, version 2
+	// the stemmed full-text index. Bump it when adding a migration to Open.
+	schemaVersion = 2
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS announcements (
+	id              INTEGER PRIMARY KEY,
+	url             TEXT NOT NULL UNIQUE,
+	ticker          TEXT NOT NULL,
+	title           TEXT NOT NULL,
+	published       TEXT NOT NULL,
+	price_sensitive INTEGER NOT NULL,
+	text            TEXT NOT NULL,
+	archived_at     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS announcements_published ON announcements (published);
+CREATE TRIGGER IF NOT EXISTS announcements_ai AFTER INSERT ON announcements BEGIN
+	INSERT INTO announcements_fts (rowid, title, text) VALUES (new.id, new.title, new.text);
+END;
+`
+
+// ftsTokenizer stems English words (so "acquire" finds "acquired" and "acquiring") and folds
+// case and diacritics.
+const ftsTokenizer = "porter unicode61 remove_diacritics 2"
+
+// ftsSchema (re)creates the full-text index. Archives created before stemming was added are
+// migrated by dropping the index and rebuilding it from the stored text.
+var ftsSchema = fmt.Sprintf(`
+CREATE VIRTUAL TABLE IF NOT EXISTS announcements_fts USING fts5(
+	title, text, content='announcements', content_rowid='id', tokenize='%s'
+);
+`, ftsTokenizer)
+
+// Archive buffers announcements and writes them to the database in batches.
+type Archive struct {
+	path    string
+	mutex   sync.Mutex
+	pending []record
+}
+
+type record struct {
+	ann  types.Announcement
+	text string
+}
+
+// Result is a single search hit or listed announcement.
+type Result struct {
+	Ticker         string    `json:"ticker"`
+	Title          string    `json:"title"`
+	URL            string    `json:"url"`
+	Published      time.Time `json:"published"`
+	PriceSensitive bool      `json:"price_sensitive"`
+	Snippet        string    `json:"snippet"`         // matched text with hits in [brackets]
+	Score          float64   `json:"score,omitempty"` // BM25 relevance, higher is better
+}
+
+// row is a search hit as printed by sqlite3 -json.
+type row struct {
+	Ticker         string  `json:"ticker"`
+	Title          string  `json:"title"`
+	URL            string  `json:"url"`
+	Published      string  `json:"published"`
+	PriceSensitive int     `json:"price_sensitive"`
+	Snippet        string  `json:"snippet"`
+	Score          float64 `json:"score"`
+}
+
+const defaultFileName = "archive.db"
+
+// DefaultPath returns the archive location alongside the report history.
+func DefaultPath() string {
+	return filepath.Join(paths.DataDir(), defaultFileName)
+}
+
+// Open creates the database and schema at path if needed. An empty path opens DefaultPath,
+// first moving an archive left in the temp directory by earlier versions.
+func Open(path string) (*Archive, error) {
+	if _, err := exec.LookPath(sqliteBinary); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH (install sqlite3 to use the archive): %w", sqliteBinary, err)
+	}
+
+	if path == "" {
+		path = DefaultPath()
+		if moved, err := paths.MigrateLegacy(filepath.Dir(path), defaultFileName); err != nil {
+			log.Printf("Warning: failed to move the archive from %s: %v", paths.LegacyDir(), err)
+		} else if moved {
+			log.Printf("Moved the archive from %s to %s.", paths.LegacyDir(), path)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	ctx := context.Background()
+	a := &Archive{path: path}
+
+	version, err := a.userVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive version: %w", err)
+	}
+	if version > schemaVersion {
+		return nil, fmt.Errorf("archive %s has schema version %d but this build supports up to %d; upgrade annscraper", path, version, schemaVersion)
+	}
+	if version == schemaVersion {
+		return a, nil
+	}
+
+	if _, err := a.exec(ctx, schema); err != nil {
+		return nil, fmt.Errorf("failed to initialise archive: %w", err)
+	}
+	if err := a.migrateIndex(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialise search index: %w", err)
+	}
+	if _, err := a.exec(ctx, fmt.Sprintf("PRAGMA user_version = %d;", schemaVersion)); err != nil {
+		return nil, fmt.Errorf("failed to record archive version: %w", err)
+	}
+	return a, nil
+}
+
+func (a *Archive) userVersion(ctx context.Context) (int, error) {
+	out, err := a.exec(ctx, "PRAGMA user_version;")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// migrateIndex creates the full-text index, rebuilding it if it uses an outdated tokenizer. The
+// rebuild runs in one write transaction, so an interrupted migration leaves the old index in
+// place and concurrent writers wait rather than inserting into a half-built one.
+func (a *Archive) migrateIndex(ctx context.Context) error {
+	out, err := a.exec(ctx, "SELECT sql FROM sqlite_master WHERE name = 'announcements_fts';")
+	if err != nil {
+		return err
+	}
+
+	current := strings.TrimSpace(string(out))
+	if strings.Contains(current, ftsTokenizer) {
+		return nil
+	}
+
+	var sql strings.Builder
+	sql.WriteString("BEGIN IMMEDIATE;\n")
+	if current != "" {
+		sql.WriteString("DROP TABLE announcements_fts;\n")
+	}
+	sql.WriteString(ftsSchema)
+	if current != "" {
+		sql.WriteString("INSERT INTO announcements_fts (announcements_fts) VALUES ('rebuild');\n")
+	}
+	sql.WriteString("COMMIT;\n")
+	_, err = a.exec(ctx, sql.String())
+	return err
+}
+
+// Path returns the database file path.
+func (a *Archive) Path() string {
+	return a.path
+}
+
+// Archive queues an announcement and its extracted text. Call Flush to persist queued entries.
+// Announcements already in the archive are ignored when flushed.
+func (a *Archive) Archive(ann types.Announcement, text string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.pending = append(a.pending, record{ann: ann, text: text})
+}
+
+// Flush writes all queued announcements in a single transaction and returns how many were queued.
+func (a *Archive) Flush(ctx context.Context) (int, error) {
+	a.mutex.Lock()
+	pending := a.pending
+	a.pending = nil
+	a.mutex.Unlock()
+
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	var sql strings.Builder
+	sql.WriteString("BEGIN;\n")
+	archivedAt := quote(time.Now().UTC().Format(timeLayout))
+	for _, r := range pending {
+		sensitive := 0
+		if r.ann.IsPriceSensitive {
+			sensitive = 1
+		}
+		fmt.Fprintf(&sql, "INSERT OR IGNORE INTO announcements (url, ticker, title, published, price_sensitive, text, archived_at) VALUES (%s, %s, %s, %s, %d, %s, %s);\n",
+			quote(r.ann.PDFURL), quote(r.ann.Ticker), quote(r.ann.Title),
+			quote(r.ann.DateTime.UTC().Format(timeLayout)), sensitive, quote(r.text), archivedAt)
+	}
+	sql.WriteString("COMMIT;\n")
+
+	if _, err := a.exec(ctx, sql.String()); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return len(pending), nil
+}
+
+// Search runs an FTS5 query (plain words, "quoted phrases", OR, NEAR, prefix*) over titles and
+// text of announcements published at or after since, best matches first. Words are stemmed, and
+// title hits are weighted above body hits.
+func (a *Archive) Search(ctx context.Context, query string, since time.Time, limit int) ([]Result, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	sql := fmt.Sprintf(`SELECT a.ticker, a.title, a.url, a.published, a.price_sensitive,
+	snippet(announcements_fts, 1, '[', ']', '...', 16) AS snippet,
+	-bm25(announcements_fts, %g, 1.0) AS score
+FROM announcements_fts JOIN announcements a ON a.id = announcements_fts.rowid
+WHERE announcements_fts MATCH %s AND a.published >= %s
+ORDER BY score DESC LIMIT %d;`, titleWeight, quote(query), quote(since.UTC().Format(timeLayout)), limit)
+
+	out, err := a.exec(ctx, sql, "-json")
+	if err != nil {
+		return nil, fmt.Errorf("archive search failed: %w", err)
+	}
+	return parseResults(out)
+}
+
+// Announcements lists archived announcements published at or after since, newest first,
+// optionally for a single ticker. The snippet is the start of the text.
+func (a *Archive) Announcements(ctx context.Context, ticker string, since time.Time, limit int) ([]Result, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	where := "published >= " + quote(since.UTC().Format(timeLayout))
+	if ticker != "" {
+		where += " AND ticker = " + quote(strings.ToUpper(ticker))
+	}
+
+	sql := fmt.Sprintf(`SELECT ticker, title, url, published, price_sensitive, substr(text, 1, 300) AS snippet
+FROM announcements WHERE %s ORDER BY published DESC LIMIT %d;`, where, limit)
+
+	out, err := a.exec(ctx, sql, "-json")
+	if err != nil {
+		return nil, fmt.Errorf("archive listing failed: %w", err)
+	}
+	return parseResults(out)
+}
+
+// Previous returns the ticker's most recent announcement published before before whose
+// lowercased title contains one of titleTerms, with its full text in Snippet. It returns nil
+// if there is none.
+func (a *Archive) Previous(ctx context.Context, ticker string, titleTerms []string, before time.Time) (*Result, error) {
+	if len(titleTerms) == 0 {
+		return nil, nil
+	}
+	var terms []string
+	for _, t := range titleTerms {
+		terms = append(terms, fmt.Sprintf("instr(lower(title), %s) > 0", quote(strings.ToLower(t))))
+	}
+
+	sql := fmt.Sprintf(`SELECT ticker, title, url, published, price_sensitive, text AS snippet
+FROM announcements WHERE ticker = %s AND published < %s AND (%s) ORDER BY published DESC LIMIT 1;`,
+		quote(strings.ToUpper(ticker)), quote(before.UTC().Format(timeLayout)), strings.Join(terms, " OR "))
+
+	out, err := a.exec(ctx, sql, "-json")
+	if err != nil {
+		return nil, fmt.Errorf("archive lookup failed: %w", err)
+	}
+	results, err := parseResults(out)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// parseResults converts sqlite3 -json output to results.
+func parseResults(out []byte) ([]Result, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+
+	var rows []row
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse search results: %w", err)
+	}
+
+	results := make([]Result, 0, len(rows))
+	for _, r := range rows {
+		published, _ := time.Parse(timeLayout, r.Published)
+		results = append(results, Result{
+			Ticker:         r.Ticker,
+			Title:          r.Title,
+			URL:            r.URL,
+			Published:      published,
+			PriceSensitive: r.PriceSensitive == 1,
+			Snippet:        r.Snippet,
+			Score:          r.Score,
+		})
+	}
+	return results, nil
+}
+
+// Count returns how many announcements published in [from, to) are archived.
+func (a *Archive) Count(ctx context.Context, from, to time.Time) (int, error) {
+	out, err := a.exec(ctx, fmt.Sprintf("SELECT COUNT(*) FROM announcements WHERE published >= %s AND published < %s;",
+		quote(from.UTC().Format(timeLayout)), quote(to.UTC().Format(timeLayout))))
+	if err != nil {
+		return 0, fmt.Errorf("archive count failed: %w", err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// exec feeds sql to the sqlite3 tool on stdin and returns its output.
+func (a *Archive) exec(ctx context.Context, sql string, args ...string) ([]byte, error) {
+	args = append(args, "-bail", a.path)
+	cmd := exec.CommandContext(ctx, sqliteBinary, args...)
+	cmd.Stdin = strings.NewReader(sql)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// quote renders s as an SQL string literal.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,160 @@
+package archive
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "''"},
+		{"Quarterly Activities Report", "'Quarterly Activities Report'"},
+		{"Chairman's address", "'Chairman''s address'"},
+		{"'); DROP TABLE announcements; --", "'''); DROP TABLE announcements; --'"},
+		{"nul\x00byte", "'nulbyte'"},
+	}
+	for _, tt := range tests {
+		if got := quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// openTestArchive opens an archive in a temporary directory holding anns, each with the text
+// in texts.
+func openTestArchive(t *testing.T, anns []types.Announcement, texts []string) *Archive {
+	t.Helper()
+	if _, err := exec.LookPath(sqliteBinary); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+
+	a, err := Open(filepath.Join(t.TempDir(), "archive.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for i, ann := range anns {
+		a.Archive(ann, texts[i])
+	}
+	if _, err := a.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	return a
+}
+
+func testAnnouncement(ticker, title string) types.Announcement {
+	return types.Announcement{
+		Ticker:   ticker,
+		Title:    title,
+		PDFURL:   "https://www.asx.com.au/" + ticker + ".pdf",
+		DateTime: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func tickers(results []Result) string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.Ticker)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSearchStemming(t *testing.T) {
+	a := openTestArchive(t,
+		[]types.Announcement{testAnnouncement("AAA", "Project update"), testAnnouncement("BBB", "Quarterly report")},
+		[]string{"The company acquired a lithium project in Western Australia.", "Drilling continued at the gold project."})
+
+	tests := []struct {
+		query, want string
+	}{
+		{"acquire", "AAA"},
+		{"acquiring", "AAA"},
+		{"ACQUIRES", "AAA"},
+		{"drilled", "BBB"},
+	}
+	for _, tt := range tests {
+		got, err := a.Search(context.Background(), tt.query, time.Time{}, 10)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", tt.query, err)
+		}
+		if tickers(got) != tt.want {
+			t.Errorf("Search(%q) = %s, want %s", tt.query, tickers(got), tt.want)
+		}
+	}
+}
+
+func TestSearchRanksTitleHits(t *testing.T) {
+	a := openTestArchive(t,
+		[]types.Announcement{testAnnouncement("BODY", "Quarterly activities report"), testAnnouncement("TITLE", "Lithium offtake agreement")},
+		[]string{"Exploration continued across the portfolio, including early lithium sampling.", "The company has signed a binding agreement."})
+
+	got, err := a.Search(context.Background(), "lithium", time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if tickers(got) != "TITLE,BODY" {
+		t.Errorf("Search order = %s, want TITLE,BODY", tickers(got))
+	}
+	if len(got) == 2 && got[0].Score <= got[1].Score {
+		t.Errorf("scores %v, %v are not descending", got[0].Score, got[1].Score)
+	}
+}
+
+func TestOpenMigratesIndex(t *testing.T) {
+	if _, err := exec.LookPath(sqliteBinary); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "archive.db")
+
+	// A version 1 archive: the base tables with an unstemmed index.
+	old := &Archive{path: path}
+	if _, err := old.exec(ctx, `CREATE VIRTUAL TABLE announcements_fts USING fts5(
+	title, text, content='announcements', content_rowid='id', tokenize='unicode61'
+);`+schema+"PRAGMA user_version = 1;"); err != nil {
+		t.Fatalf("creating version 1 archive: %v", err)
+	}
+	old.Archive(testAnnouncement("OLD", "Acquisition completed"), "The company acquired the remaining interest.")
+	if _, err := old.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got, err := old.Search(ctx, "acquire", time.Time{}, 10); err != nil || len(got) != 0 {
+		t.Fatalf("unstemmed Search = %s, %v; want no results", tickers(got), err)
+	}
+
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if version, err := a.userVersion(ctx); err != nil || version != schemaVersion {
+		t.Errorf("user_version = %d, %v; want %d", version, err, schemaVersion)
+	}
+	out, err := a.exec(ctx, "SELECT sql FROM sqlite_master WHERE name = 'announcements_fts';")
+	if err != nil || !strings.Contains(string(out), ftsTokenizer) {
+		t.Errorf("index schema = %q, %v; want the %q tokenizer", out, err, ftsTokenizer)
+	}
+
+	got, err := a.Search(ctx, "acquire", time.Time{}, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if tickers(got) != "OLD" {
+		t.Errorf("Search after migration = %s, want the rebuilt OLD row", tickers(got))
+	}
+
+	// Rows archived after the migration are indexed by the trigger.
+	a.Archive(testAnnouncement("NEW", "Acquiring a second project"), "Terms agreed.")
+	if _, err := a.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got, _ := a.Search(ctx, "acquire", time.Time{}, 10); len(got) != 2 {
+		t.Errorf("Search after insert = %s, want OLD and NEW", tickers(got))
+	}
+}
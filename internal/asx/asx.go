@@ -5,54 +5,256 @@ package asx
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
+	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/clock"
+	"github.com/shanehull/annscraper/internal/forms"
+	"github.com/shanehull/annscraper/internal/geo"
+	"github.com/shanehull/annscraper/internal/hooks"
+	"github.com/shanehull/annscraper/internal/semantic"
+	"github.com/shanehull/annscraper/internal/telemetry"
 	"github.com/shanehull/annscraper/internal/types"
 )
 
 const (
-	markitAnnouncementsURL = "https://asx.api.markitdigital.com/asx-research/1.0/markets/announcements"
-	markitPDFBaseURL       = "https://cdn-api.markitdigital.com/apiman-gateway/ASX/asx-research/1.0/file"
-	pdfProcessingTimeout   = 120 * time.Second // 2 minutes for PDF text extraction
+	markitAnnouncementsURL  = "https://asx.api.markitdigital.com/asx-research/1.0/markets/announcements"
+	markitCompanyURL        = "https://asx.api.markitdigital.com/asx-research/1.0/companies"
+	markitPDFBaseURL        = "https://cdn-api.markitdigital.com/apiman-gateway/ASX/asx-research/1.0/file"
+	pdfProcessingTimeout    = 120 * time.Second // 2 minutes for PDF text extraction
+	defaultHistoricLookback = 90 * 24 * time.Hour
 )
 
 var client = &http.Client{
-	Timeout: 180 * time.Second, // 3 minutes for large PDF downloads
+	Timeout:   180 * time.Second, // 3 minutes for large PDF downloads
+	Transport: &countingTransport{base: &breakerTransport{base: transport}},
+	Jar:       newCookieJar(),
 }
 
 type markitAnnouncementsResponse struct {
 	Data struct {
+		Count int `json:"count"`
 		Items []struct {
 			Companies []struct {
 				SymbolDisplay string `json:"symbolDisplay"`
 			} `json:"companies"`
-			Date        string `json:"date"`
-			DocumentKey string `json:"documentKey"`
-			Headline    string `json:"headline"`
-			Symbol      string `json:"symbol"`
+			Date             string `json:"date"`
+			DocumentKey      string `json:"documentKey"`
+			Headline         string `json:"headline"`
+			IsPriceSensitive bool   `json:"isPriceSensitive"`
+			Symbol           string `json:"symbol"`
 		} `json:"items"`
 	} `json:"data"`
 }
 
+// AnalysisCache stores AI analyses keyed by a hash of the extracted document text.
+type AnalysisCache interface {
+	CachedAnalysis(key string) (*ai.AIAnalysis, bool)
+	CacheAnalysis(key string, analysis *ai.AIAnalysis)
+}
+
+// DocumentStore receives the attachment of every match, e.g. to upload it to object storage.
+type DocumentStore interface {
+	StoreDocument(ctx context.Context, ann types.Announcement, data []byte, contentType string) error
+}
+
+// Archiver receives every announcement whose attachment was extracted, matched or not.
+type Archiver interface {
+	Archive(ann types.Announcement, text string)
+}
+
+// ErrorReporter receives announcements that failed to process, for error tracking.
+type ErrorReporter interface {
+	ReportAnnouncementError(ann types.Announcement, err error)
+}
+
+type ProcessParams struct {
+	Keywords      []string
+	Tickers       []string
+	FilterFn      func(types.Announcement, []string, bool) []string
+	GeminiAPIKey  string
+	Models        []string      // tried in order, falling back to the next on failure
+	AnalysisCache AnalysisCache // nil = no caching
+	Archiver      Archiver      // nil = don't archive
+	Halts         HaltTracker   // nil = don't pair trading halts with their follow-up
+	Hooks         *hooks.Runner // nil = no hooks
+	Errors        ErrorReporter // nil = only log processing errors
+	AnalyzePDF    bool          // upload the raw PDF to Gemini instead of sending extracted text
+
+	HistoricLookback time.Duration // how far back to fetch the company's announcements for AI context, 0 = 90 days
+	HistoricTexts    int           // give the AI the text of this many of them rather than just links, 0 = links only
+	Clock            clock.Clock   // nil = system clock
+
+	MinResultChange    float64 // % change in structured revenue/NPAT/EPS that counts as a match, 0 = off
+	MinFundingQuarters float64 // Appendix 4C/5B reports with fewer quarters of funding count as a match, 0 = off
+
+	AISelection AISelection // restricts which matches are sent for AI analysis, zero value = all
+
+	SaveDocumentsDir string        // write each match's attachment to DIR/TICKER/DATE_Title.ext, "" = off
+	DocumentStore    DocumentStore // nil = don't store match attachments elsewhere
+
+	Snippet SnippetOptions // context shown around keyword matches
+
+	// TitlesFirst only downloads documents whose title matches a keyword, announcements of
+	// configured tickers, halt follow-ups and likely results or cash flow reports. Keywords
+	// that appear only in a document's body are missed.
+	TitlesFirst bool
+
+	Layout LayoutMode // when to extract PDFs with their layout preserved, zero value = never
+
+	Stats *ProcessStats // nil = don't collect statistics for the run summary
+
+	Semantic *semantic.Matcher // also match concept queries by embedding similarity, nil = off
+
+	// Previous finds the company's last report of the same kind, so recurring reports (4C/5B,
+	// quarterly activities, presentations) show what changed since. nil = off.
+	Previous PreviousReports
+
+	// Progress is called, one call at a time, as each announcement finishes processing.
+	// nil = log each announcement as it starts.
+	Progress func(ProgressEvent)
+
+	// AIWorkers is how many matches are analysed by AI at once, 0 = 2. Analysis runs after a
+	// match's document worker has finished, so slow AI calls don't hold up downloads.
+	AIWorkers int
+}
+
+// defaultAIWorkers is the size of the AI analysis pool when ProcessParams.AIWorkers is unset.
+const defaultAIWorkers = 2
+
+// aiJob is a match waiting for AI analysis.
+type aiJob struct {
+	match    *types.Match
+	text     string
+	document []byte // raw PDF to upload, nil = analyse text
+}
+
+// ProgressEvent reports an announcement that has finished processing.
+type ProgressEvent struct {
+	Done    int    `json:"done"`
+	Total   int    `json:"total"`
+	Ticker  string `json:"ticker"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Matched bool   `json:"matched"`
+	Error   string `json:"error,omitempty"` // why the announcement was skipped or failed
+}
+
+// AISelection limits AI analysis to the matches most worth spending quota on.
+// All set conditions must hold for a match to be analysed.
+type AISelection struct {
+	PriceSensitiveOnly bool     // only price sensitive announcements
+	TickerMatchesOnly  bool     // only announcements matched by ticker
+	Keywords           []string // only matches that found at least one of these keywords
+}
+
+// Allows reports whether match should be sent for AI analysis. Announcements that lift a
+// trading halt are always analysed.
+func (s AISelection) Allows(match *types.Match) bool {
+	if match.FollowsHalt != nil {
+		return true
+	}
+	if s.PriceSensitiveOnly && !match.IsPriceSensitive {
+		return false
+	}
+	if s.TickerMatchesOnly && !match.TickerMatched {
+		return false
+	}
+	if len(s.Keywords) > 0 && !slices.ContainsFunc(match.KeywordsFound, func(kw string) bool {
+		return slices.Contains(s.Keywords, kw)
+	}) {
+		return false
+	}
+	return true
+}
+
+// FetchStats describes how many announcements the feed reported versus how many rows were read.
+type FetchStats struct {
+	Reported int // total reported by the feed, 0 if not exposed
+	Received int // raw rows received across all pages
+	Parsed   int // announcements kept after parsing and date filtering
+
+	// Unchanged is set, with no announcements returned, when FetchParams.IfChanged found the
+	// feed unchanged since the last fetch.
+	Unchanged bool
+}
+
+// Complete reports whether the rows received match the feed's reported total.
+// It returns true when the feed does not expose a total.
+func (s FetchStats) Complete() bool {
+	return s.Reported == 0 || s.Received >= s.Reported
+}
+
 type FetchParams struct {
 	Date               string
+	Ticker             string    // fetch a single company's announcements instead of the market feed
+	Since              time.Time // stop paging once announcements are older than this, zero = no limit
 	PriceSensitiveOnly bool
 	MaxResults         int // 0 = unlimited
+
+	// FallbackTickers are fetched one by one from per-company sources such as the ASX
+	// company API when the market feed fails, so watched companies are still covered.
+	FallbackTickers []string
+
+	// IfChanged makes a conditional request for the first feed page and returns no
+	// announcements, with FetchStats.Unchanged set, when it hasn't changed since the last
+	// fetch in this process.
+	IfChanged bool
 }
 
 func FetchAnnouncements(params FetchParams) ([]types.Announcement, error) {
+	announcements, _, err := FetchAnnouncementsWithStats(params)
+	return announcements, err
+}
+
+// FetchAnnouncementsWithStats fetches announcements like FetchAnnouncements and also
+// returns completeness statistics, logging a warning when fewer rows were read than the feed reported.
+// Sources are tried in the order set by SetSources until one succeeds.
+func FetchAnnouncementsWithStats(params FetchParams) ([]types.Announcement, FetchStats, error) {
+	var errs []error
+	for i, source := range sources {
+		announcements, stats, err := fetchFromSource(source, params)
+		if errors.Is(err, errNoTickers) {
+			continue
+		}
+		if err == nil {
+			if len(errs) > 0 {
+				log.Printf("Fetched %d announcements from fallback source %s.", len(announcements), source)
+			}
+			return announcements, stats, nil
+		}
+
+		errs = append(errs, err)
+		if i < len(sources)-1 {
+			log.Printf("Warning: %v. Trying the next announcement source.", err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil, FetchStats{}, fmt.Errorf("no announcement source can serve this request (sources: %s)", strings.Join(sources, ","))
+	}
+	return nil, FetchStats{}, errors.Join(errs...)
+}
+
+func fetchMarkitAnnouncements(params FetchParams) ([]types.Announcement, FetchStats, error) {
 	var allAnnouncements []types.Announcement
+	var stats FetchStats
 	pageSize := 100
 	page := 0
 	var targetDate time.Time
@@ -62,13 +264,16 @@ func FetchAnnouncements(params FetchParams) ([]types.Announcement, error) {
 		var err error
 		targetDate, err = time.Parse("2006-01-02", params.Date)
 		if err != nil {
-			return nil, fmt.Errorf("invalid date format: %s (expected YYYY-MM-DD)", params.Date)
+			return nil, stats, fmt.Errorf("invalid date format: %s (expected YYYY-MM-DD)", params.Date)
 		}
 	}
 
 	for {
 		var url string
-		if params.Date != "" {
+		if params.Ticker != "" {
+			url = fmt.Sprintf("%s/%s/announcements?page=%d&itemsPerPage=%d&priceSensitiveOnly=%v",
+				markitCompanyURL, strings.ToLower(params.Ticker), page, pageSize, params.PriceSensitiveOnly)
+		} else if params.Date != "" {
 			url = fmt.Sprintf("%s?summaryCountsDate=%s&page=%d&itemsPerPage=%d&priceSensitiveOnly=%v",
 				markitAnnouncementsURL, params.Date, page, pageSize, params.PriceSensitiveOnly)
 		} else {
@@ -76,14 +281,29 @@ func FetchAnnouncements(params FetchParams) ([]types.Announcement, error) {
 				markitAnnouncementsURL, page, pageSize, params.PriceSensitiveOnly)
 		}
 
-		announcements, hasMore, err := fetchAnnouncements(url, targetDate)
+		announcements, info, err := fetchAnnouncements(url, targetDate, params.PriceSensitiveOnly, params.IfChanged && page == 0)
+		if errors.Is(err, errFeedUnchanged) {
+			return nil, FetchStats{Unchanged: true}, nil
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch announcements page %d: %w", page, err)
+			return nil, stats, fmt.Errorf("failed to fetch announcements page %d: %w", page, err)
+		}
+
+		reachedSince := false
+		if !params.Since.IsZero() {
+			announcements = slices.DeleteFunc(announcements, func(a types.Announcement) bool {
+				return a.DateTime.Before(params.Since)
+			})
+			reachedSince = info.oldest.Before(params.Since)
 		}
 
 		allAnnouncements = append(allAnnouncements, announcements...)
+		stats.Received += info.items
+		if info.total > stats.Reported {
+			stats.Reported = info.total
+		}
 
-		if !hasMore || len(announcements) < pageSize {
+		if info.items == 0 || reachedSince || len(announcements) < pageSize {
 			break
 		}
 
@@ -95,46 +315,167 @@ func FetchAnnouncements(params FetchParams) ([]types.Announcement, error) {
 		page++
 	}
 
-	return allAnnouncements, nil
+	stats.Parsed = len(allAnnouncements)
+	return allAnnouncements, stats, nil
+}
+
+// recoverAnnouncement turns a panic while processing ann into a processing failure, reported
+// like any other, so one bad document doesn't take down the run. It must be deferred directly.
+func recoverAnnouncement(ann types.Announcement, params ProcessParams) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	err := fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+	log.Printf("Error processing %s (%s): %v", ann.Ticker, ann.Title, err)
+	params.Stats.countFailure(FailureProcessing)
+	if params.Errors != nil {
+		params.Errors.ReportAnnouncementError(ann, err)
+	}
 }
 
-func ProcessAnnouncements(ctx context.Context, announcements []types.Announcement, keywords []string, tickers []string, filterFn func(types.Announcement, []string, bool) []string, geminiAPIKey string, modelName string) []types.AnnotatedMatch {
+func ProcessAnnouncements(ctx context.Context, announcements []types.Announcement, params ProcessParams) []types.AnnotatedMatch {
 	var wg sync.WaitGroup
 	matchChan := make(chan types.AnnotatedMatch)
+	// Buffered so they never block a document worker. Urgent matches are analysed first.
+	urgentAI := make(chan aiJob, len(announcements))
+	bulkAI := make(chan aiJob, len(announcements))
 
 	sem := make(chan struct{}, 10) // Concurrency limit
 	total := len(announcements)
 	processedCount := 0
 	var processedMutex sync.Mutex
+	unsupported := make(map[string]int) // content type -> count, guarded by processedMutex
+	skippedForBandwidth := 0
+	skippedForSize := 0
+	skippedForOutage := 0
+
+	lifted := pairHalts(announcements, params.Halts)
+	if len(lifted) > 0 {
+		log.Printf("Found %d announcement(s) following a trading halt", len(lifted))
+	}
+
+	if params.TitlesFirst {
+		announcements = titleCandidates(announcements, lifted, params)
+		log.Printf("Title pass: %d of %d announcement(s) need their document downloaded", len(announcements), total)
+		total = len(announcements)
+	}
 
-	for _, ann := range announcements {
+	var aiWG sync.WaitGroup
+	for range cmp.Or(params.AIWorkers, defaultAIWorkers) {
+		aiWG.Go(func() {
+			for {
+				job, ok := nextAIJob(urgentAI, bulkAI)
+				if !ok {
+					return
+				}
+				func() {
+					defer recoverAnnouncement(job.match.Announcement, params)
+
+					annotated, err := analyseMatch(ctx, job, params)
+					if err != nil {
+						ann := job.match.Announcement
+						log.Printf("Error processing %s (%s): %v", ann.Ticker, ann.Title, err)
+						params.Stats.countFailure(FailureProcessing)
+						if params.Errors != nil {
+							params.Errors.ReportAnnouncementError(ann, err)
+						}
+						return
+					}
+					matchChan <- annotated
+				}()
+			}
+		})
+	}
+
+	for _, ann := range prioritise(announcements, lifted, params.Tickers) {
 		sem <- struct{}{}
 
 		wg.Go(func() {
 			defer func() { <-sem }()
+			defer recoverAnnouncement(ann, params)
+
+			params.Stats.countProcessed()
+			if params.Progress == nil {
+				processedMutex.Lock()
+				processedCount++
+				log.Printf("Processing... %d/%d (%s) ", processedCount, total, ann.Ticker)
+				processedMutex.Unlock()
+			}
 
-			processedMutex.Lock()
-			processedCount++
-			log.Printf("Processing... %d/%d (%s) ", processedCount, total, ann.Ticker)
-			processedMutex.Unlock()
+			var followsHalt *types.Announcement
+			if halt, ok := lifted[ann.PDFURL]; ok {
+				followsHalt = &halt
+			}
 
-			match, analysis, err := filterAndAnnotate(ctx, ann, keywords, tickers, filterFn, geminiAPIKey, modelName)
+			annCtx, span := telemetry.Start(ctx, "announcement", "ticker", ann.Ticker, "url", ann.PDFURL)
+			match, job, err := filterAndAnnotate(annCtx, ann, followsHalt, params)
+			span.SetAttr("matched", match != nil)
+			span.End(err)
+			if params.Progress != nil {
+				event := ProgressEvent{Total: total, Ticker: ann.Ticker, Title: ann.Title, URL: ann.PDFURL, Matched: match != nil}
+				if err != nil {
+					event.Error = err.Error()
+				}
+				processedMutex.Lock()
+				processedCount++
+				event.Done = processedCount
+				params.Progress(event)
+				processedMutex.Unlock()
+			}
+			var unsupportedErr *unsupportedDocumentError
+			if errors.As(err, &unsupportedErr) {
+				processedMutex.Lock()
+				unsupported[unsupportedErr.contentType]++
+				processedMutex.Unlock()
+				params.Stats.countFailure(FailureUnsupported)
+				return
+			}
+			if errors.Is(err, errBandwidthExceeded) {
+				processedMutex.Lock()
+				skippedForBandwidth++
+				processedMutex.Unlock()
+				params.Stats.countFailure(FailureBandwidth)
+				return
+			}
+			if errors.Is(err, errDocumentTooLarge) {
+				processedMutex.Lock()
+				skippedForSize++
+				processedMutex.Unlock()
+				params.Stats.countFailure(FailureSize)
+				return
+			}
+			if errors.Is(err, errSourceDegraded) {
+				processedMutex.Lock()
+				skippedForOutage++
+				processedMutex.Unlock()
+				params.Stats.countFailure(FailureSourceDegraded)
+				return
+			}
 			if err != nil {
 				log.Printf("Error processing %s (%s): %v", ann.Ticker, ann.Title, err)
+				params.Stats.countFailure(FailureProcessing)
+				if params.Errors != nil {
+					params.Errors.ReportAnnouncementError(ann, err)
+				}
 				return
 			}
 
-			if match != nil {
-				matchChan <- types.AnnotatedMatch{
-					Match:    *match,
-					Analysis: analysis,
-				}
+			if job != nil && job.urgent() {
+				urgentAI <- *job
+			} else if job != nil {
+				bulkAI <- *job
+			} else if match != nil {
+				matchChan <- types.AnnotatedMatch{Match: *match}
 			}
 		})
 	}
 
 	go func() {
 		wg.Wait()
+		close(urgentAI)
+		close(bulkAI)
+		aiWG.Wait()
 		close(matchChan)
 	}()
 
@@ -145,44 +486,203 @@ func ProcessAnnouncements(ctx context.Context, announcements []types.Announcemen
 
 	log.Printf("Done processing")
 
+	for contentType, count := range unsupported {
+		log.Printf("Skipped %d announcement(s) with unsupported attachment type %s", count, contentType)
+	}
+	if skippedForBandwidth > 0 {
+		log.Printf("Warning: Skipped %d announcement(s) after reaching the bandwidth cap", skippedForBandwidth)
+	}
+	if skippedForSize > 0 {
+		log.Printf("Warning: Skipped %d announcement(s) with documents over the size cap", skippedForSize)
+	}
+	if skippedForOutage > 0 {
+		log.Printf("Warning: Skipped %d announcement(s) while the document source was degraded", skippedForOutage)
+	}
+
 	return annotatedMatches
 }
 
-func filterAndAnnotate(ctx context.Context, ann types.Announcement, keywords []string, tickers []string, filterFn func(types.Announcement, []string, bool) []string, geminiAPIKey string, modelName string) (*types.Match, *ai.AIAnalysis, error) {
-	tickerMatch := isTickerMatch(ann.Ticker, tickers)
+// filterAndAnnotate downloads and matches an announcement. Matches selected for AI analysis
+// are returned with the job to queue for it.
+func filterAndAnnotate(ctx context.Context, ann types.Announcement, followsHalt *types.Announcement, params ProcessParams) (*types.Match, *aiJob, error) {
+	pre := &hooks.Payload{Stage: hooks.PreDownload, Announcement: &ann}
+	if !params.Hooks.Run(ctx, pre) {
+		return nil, nil, nil
+	}
+	if pre.Announcement != nil {
+		ann = *pre.Announcement
+	}
+
+	tickerMatch := isTickerMatch(ann.Ticker, params.Tickers)
 
-	text, err := extractTextFromPDF(ann.PDFURL)
+	_, span := telemetry.Start(ctx, "download")
+	doc, err := loadDocument(ann.PDFURL)
+	span.End(err)
 	if err != nil {
-		return nil, nil, fmt.Errorf("PDF text extraction failed: %w", err)
+		return nil, nil, err
 	}
+	params.Stats.countDocument(doc.cached)
+	defer doc.close()
 
-	foundKeywords := findKeywords(ann.Title, text, keywords)
+	_, span = telemetry.Start(ctx, "extract", "content_type", doc.contentType)
+	text, financials, err := loadDocumentText(ann.PDFURL, doc, params.Layout.appliesTo(ann.Title))
+	span.End(err)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if params.Archiver != nil {
+		params.Archiver.Archive(ann, text)
+	}
+
+	if params.Hooks.Has(hooks.PostExtract) {
+		post := &hooks.Payload{Stage: hooks.PostExtract, Announcement: &ann, Text: text}
+		if !params.Hooks.Run(ctx, post) {
+			return nil, nil, nil
+		}
+		text = post.Text
+	}
+
+	foundKeywords := findKeywords(ann.Title, text, params.Keywords)
+	var concepts []semantic.Result
+	if params.Semantic != nil {
+		embedCtx, span := telemetry.Start(ctx, "embed")
+		concepts, err = params.Semantic.Match(embedCtx, ann.Title, text)
+		span.End(err)
+		if err != nil {
+			log.Printf("Warning: Semantic matching failed for %s: %v", ann.Ticker, err)
+		}
+		for _, c := range concepts {
+			foundKeywords = append(foundKeywords, conceptKeyword(c.Query))
+		}
+	}
+	if financials != nil {
+		foundKeywords = append(foundKeywords, financials.Exceeding(params.MinResultChange)...)
+	}
+
+	if followsHalt != nil {
+		foundKeywords = append(foundKeywords, HaltLiftedKeyword)
+	}
+
+	var cashFlow *forms.CashFlow
+	if forms.IsCashFlowReport(ann.Title, text) {
+		if cashFlow, err = forms.ParseCashFlow(text); err != nil {
+			log.Printf("Warning: Could not parse quarterly cash flow for %s: %v", ann.Ticker, err)
+		} else if cashFlow.BelowFunding(params.MinFundingQuarters) {
+			foundKeywords = append(foundKeywords, fmt.Sprintf("funding %.1f quarters", cashFlow.QuartersFunding))
+		}
+	}
 
 	if len(foundKeywords) == 0 && !tickerMatch {
 		return nil, nil, nil
 	}
 
-	newKeywords := applyHistoryFilter(ann, foundKeywords, tickerMatch, filterFn)
+	newKeywords := applyHistoryFilter(ann, foundKeywords, tickerMatch, params.FilterFn)
 	if len(newKeywords) == 0 {
 		return nil, nil, nil
 	}
 
 	finalKeywords, isPlaceholderMatch := normalizePlaceholder(newKeywords)
-	contextSnippet := buildContextSnippet(ann, text, finalKeywords, isPlaceholderMatch)
+	contextSnippet := buildContextSnippet(ann, text, finalKeywords, isPlaceholderMatch, params.Snippet)
+	if contextSnippet == "" && len(concepts) > 0 {
+		contextSnippet = condenseText(concepts[0].Passage, maxConceptSnippet)
+	}
+	if financials != nil {
+		contextSnippet = financials.Summary()
+	}
+	if cashFlow != nil {
+		contextSnippet = cashFlow.Summary()
+	}
+
+	var insider *forms.DirectorInterest
+	if forms.IsAppendix3Y(ann.Title, text) {
+		if insider, err = forms.ParseAppendix3Y(text); err != nil {
+			log.Printf("Warning: Could not parse Appendix 3Y for %s: %v", ann.Ticker, err)
+		} else {
+			contextSnippet = ""
+		}
+	}
+
+	var changes *types.Changes
+	if params.Previous != nil {
+		changes = findChanges(ctx, ann, text, params.Previous)
+	}
 
 	match := &types.Match{
 		Announcement:  ann,
 		KeywordsFound: finalKeywords,
 		TickerMatched: tickerMatch,
 		Context:       contextSnippet,
+		Financials:    financials,
+		Insider:       insider,
+		CashFlow:      cashFlow,
+		FollowsHalt:   followsHalt,
+		KeywordPages:  keywordPages(text, finalKeywords),
+		DocumentHash:  documentHash(text),
+		Changes:       changes,
+		Geo:           geo.Tag(ann.Title + "\n" + text),
+	}
+
+	postMatch := &hooks.Payload{Stage: hooks.PostMatch, Match: match, Text: text}
+	if !params.Hooks.Run(ctx, postMatch) {
+		return nil, nil, nil
+	}
+	if postMatch.Match != nil {
+		match = postMatch.Match
 	}
 
-	analysis, err := runAIAnalysis(ctx, ann.Ticker, text, geminiAPIKey, modelName)
+	if params.SaveDocumentsDir != "" {
+		if path, err := saveDocument(params.SaveDocumentsDir, match.Announcement, doc); err != nil {
+			log.Printf("Warning: Failed to save document for %s: %v", ann.Ticker, err)
+		} else {
+			log.Printf("Saved %s document to %s", ann.Ticker, path)
+		}
+	}
+
+	if params.DocumentStore != nil {
+		if data, err := doc.bytes(); err != nil {
+			log.Printf("Warning: Failed to read document for %s: %v", ann.Ticker, err)
+		} else if err := params.DocumentStore.StoreDocument(ctx, match.Announcement, data, doc.contentType); err != nil {
+			log.Printf("Warning: Failed to store document for %s: %v", ann.Ticker, err)
+		}
+	}
+
+	var document []byte
+	if params.AnalyzePDF && doc.contentType == contentTypePDF {
+		if document, err = doc.bytes(); err != nil {
+			log.Printf("Warning: Failed to read PDF for %s, analysing extracted text: %v", ann.Ticker, err)
+			document = nil
+		}
+	}
+
+	if !params.AISelection.Allows(match) || !ai.Enabled(params.GeminiAPIKey) {
+		return match, nil, nil
+	}
+	return match, &aiJob{match: match, text: text, document: document}, nil
+}
+
+// analyseMatch runs AI analysis for a queued match.
+func analyseMatch(ctx context.Context, job aiJob, params ProcessParams) (types.AnnotatedMatch, error) {
+	match := job.match
+	var changes string
+	if match.Changes != nil {
+		changes = match.Changes.Summary()
+	}
+	analysis, err := runAIAnalysis(ctx, match.Ticker, job.text, job.document, changes, params)
 	if err != nil {
-		return nil, nil, fmt.Errorf("AI analysis failed: %w", err)
+		return types.AnnotatedMatch{}, fmt.Errorf("AI analysis failed: %w", err)
 	}
 
-	return match, analysis, nil
+	if analysis != nil {
+		for _, p := range analysis.Projects {
+			match.Geo = match.Geo.Merge(geo.FromProject(p.Location, p.State, p.Commodity))
+		}
+	}
+	annotated := types.AnnotatedMatch{Match: *match, Analysis: analysis}
+	if analysis != nil {
+		annotated.Model = analysis.Model
+	}
+	return annotated, nil
 }
 
 func isTickerMatch(ticker string, tickers []string) bool {
@@ -205,8 +705,26 @@ func findKeywords(title, text string, keywords []string) []string {
 	var found []string
 	lowerTitle := strings.ToLower(title)
 	lowerText := strings.ToLower(text)
+	var titleWords, textWords []string // tokenised on demand for proximity expressions
 
 	for _, kw := range keywords {
+		if p, ok := parseProximity(kw); ok {
+			if titleWords == nil && textWords == nil {
+				titleWords, textWords = wordsOf(title), wordsOf(text)
+			}
+			if p.matches(titleWords) || p.matches(textWords) {
+				found = append(found, kw)
+			}
+			continue
+		}
+
+		if term, minCount := parseMinCount(kw); minCount > 1 {
+			if strings.Count(lowerTitle, term)+strings.Count(lowerText, term) >= minCount {
+				found = append(found, kw)
+			}
+			continue
+		}
+
 		if strings.Contains(lowerTitle, kw) {
 			found = append(found, kw)
 		} else if strings.Contains(lowerText, kw) {
@@ -231,13 +749,13 @@ func normalizePlaceholder(keywords []string) (finalKeywords []string, isPlacehol
 	return keywords, false
 }
 
-func buildContextSnippet(ann types.Announcement, text string, keywords []string, isPlaceholderMatch bool) string {
+func buildContextSnippet(ann types.Announcement, text string, keywords []string, isPlaceholderMatch bool, opts SnippetOptions) string {
 	if len(keywords) > 0 {
-		keyword := keywords[0]
+		keyword := snippetTerm(keywords[0])
 		if strings.Contains(strings.ToLower(ann.Title), keyword) {
 			return ann.Title + " (Match found in title)"
 		}
-		return getSnippet(text, keyword)
+		return getSnippet(text, keyword, opts)
 	}
 	if isPlaceholderMatch {
 		return fmt.Sprintf("Match found based on ticker %s only.", ann.Ticker)
@@ -245,43 +763,100 @@ func buildContextSnippet(ann types.Announcement, text string, keywords []string,
 	return ""
 }
 
-func runAIAnalysis(ctx context.Context, ticker, text, geminiAPIKey, modelName string) (*ai.AIAnalysis, error) {
-	if geminiAPIKey == "" {
+// runAIAnalysis analyses the extracted text, or the raw PDF when pdfBytes is non-nil, along
+// with any changes since the previous report of the same kind.
+func runAIAnalysis(ctx context.Context, ticker, text string, pdfBytes []byte, changes string, params ProcessParams) (*ai.AIAnalysis, error) {
+	if !ai.Enabled(params.GeminiAPIKey) {
 		return nil, nil
 	}
 
+	cacheKey := documentHash(text + changes + ai.PromptFingerprint(params.Models, pdfBytes != nil))
+	if params.AnalysisCache != nil {
+		if cached, ok := params.AnalysisCache.CachedAnalysis(cacheKey); ok {
+			log.Printf("Using cached AI analysis for %s (%s)", ticker, cacheKey[:12])
+			return cached, nil
+		}
+	}
+
+	lookback := params.HistoricLookback
+	if lookback == 0 {
+		lookback = defaultHistoricLookback
+	}
+
 	historicAnnouncements, err := FetchAnnouncements(FetchParams{
+		Ticker:             ticker,
+		Since:              clock.OrSystem(params.Clock).Now().Add(-lookback),
 		PriceSensitiveOnly: true,
-		MaxResults:         100,
 	})
 	if err != nil {
 		log.Printf("Warning: Failed to fetch historic announcements for %s: %v", ticker, err)
 	}
 
-	// Filter by ticker
 	var historicList []string
 	for _, a := range historicAnnouncements {
-		if a.Ticker == ticker {
-			historicList = append(historicList, fmt.Sprintf("%s - %s", a.Title, a.PDFURL))
-		}
+		historicList = append(historicList, fmt.Sprintf("%s - %s", a.Title, a.PDFURL))
 	}
 
 	var recentHistoric []string
+	var historicTexts []ai.HistoricDocument
 	if len(historicList) > 1 {
 		recentHistoric = historicList[1:]
+		if params.HistoricTexts > 0 {
+			historicTexts = historicDocuments(historicAnnouncements[1:], params.HistoricTexts)
+		}
 	}
 
-	analysis, err := ai.GenerateSummary(ctx, ticker, text, recentHistoric, geminiAPIKey, modelName)
+	ctx, span := telemetry.Start(ctx, "ai", "model", strings.Join(params.Models, ","))
+	analysis, err := ai.GenerateSummary(ctx, ai.Request{
+		Ticker:        ticker,
+		Text:          text,
+		PDF:           pdfBytes,
+		Historic:      recentHistoric,
+		HistoricTexts: historicTexts,
+		Changes:       changes,
+		APIKey:        params.GeminiAPIKey,
+		Models:        params.Models,
+	})
+	span.End(err)
 	if err != nil {
 		return nil, fmt.Errorf("AI summary failed: %w", err)
 	}
+
+	if params.AnalysisCache != nil {
+		params.AnalysisCache.CacheAnalysis(cacheKey, analysis)
+	}
 	return analysis, nil
 }
 
-func fetchAnnouncements(url string, targetDate time.Time) ([]types.Announcement, bool, error) {
-	resp, err := client.Get(url)
+// documentHash returns the hex encoded SHA-256 of the extracted document text.
+func documentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// pageInfo holds the raw row count of a feed page and the total reported by the feed.
+type pageInfo struct {
+	items  int
+	total  int
+	oldest time.Time // earliest announcement date on the page
+}
+
+// fetchAnnouncements fetches one feed page. A conditional fetch returns errFeedUnchanged when
+// the page is the same as last time.
+func fetchAnnouncements(url string, targetDate time.Time, priceSensitiveOnly, conditional bool) ([]types.Announcement, pageInfo, error) {
+	var info pageInfo
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to fetch URL %s: %w", url, err)
+		return nil, info, err
+	}
+	if conditional {
+		addConditionalHeaders(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, info, fmt.Errorf("failed to fetch URL %s: %w", url, err)
 	}
 	defer func() {
 		if err = resp.Body.Close(); err != nil {
@@ -289,15 +864,29 @@ func fetchAnnouncements(url string, targetDate time.Time) ([]types.Announcement,
 		}
 	}()
 
+	if conditional && resp.StatusCode == http.StatusNotModified {
+		return nil, info, errFeedUnchanged
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, false, fmt.Errorf("received non-OK status code %d from %s", resp.StatusCode, url)
+		return nil, info, fmt.Errorf("received non-OK status code %d from %s", resp.StatusCode, url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, info, fmt.Errorf("failed to read response from %s: %w", url, err)
+	}
+	if conditional && !feedChanged(url, resp, body) {
+		return nil, info, errFeedUnchanged
 	}
 
 	var respData markitAnnouncementsResponse
-	if err = json.NewDecoder(resp.Body).Decode(&respData); err != nil {
-		return nil, false, fmt.Errorf("failed to parse JSON from %s: %w", url, err)
+	if err = json.Unmarshal(body, &respData); err != nil {
+		return nil, info, fmt.Errorf("failed to parse JSON from %s: %w", url, err)
 	}
 
+	info.items = len(respData.Data.Items)
+	info.total = respData.Data.Count
+
 	var announcements []types.Announcement
 	for _, item := range respData.Data.Items {
 		if item.DocumentKey == "" {
@@ -306,13 +895,17 @@ func fetchAnnouncements(url string, targetDate time.Time) ([]types.Announcement,
 
 		// Parse date
 		var itemDate time.Time
-		if t, err := time.Parse(time.RFC3339, item.Date); err == nil {
+		if t, err := parseMarketTime(item.Date); err == nil {
 			itemDate = t
 		} else {
 			log.Printf("Warning: Failed to parse date string '%s': %v", item.Date, err)
 			continue
 		}
 
+		if info.oldest.IsZero() || itemDate.Before(info.oldest) {
+			info.oldest = itemDate
+		}
+
 		// Filter by target date if provided (compare date part only)
 		if !targetDate.IsZero() {
 			if itemDate.Year() != targetDate.Year() || itemDate.Month() != targetDate.Month() || itemDate.Day() != targetDate.Day() {
@@ -323,7 +916,7 @@ func fetchAnnouncements(url string, targetDate time.Time) ([]types.Announcement,
 		ann := types.Announcement{
 			Ticker:           item.Symbol,
 			Title:            item.Headline,
-			IsPriceSensitive: true, // Markit API indicates price sensitive by filtering
+			IsPriceSensitive: item.IsPriceSensitive || priceSensitiveOnly,
 			DateTime:         itemDate,
 			PDFURL:           fmt.Sprintf("%s/%s", markitPDFBaseURL, item.DocumentKey),
 		}
@@ -331,13 +924,11 @@ func fetchAnnouncements(url string, targetDate time.Time) ([]types.Announcement,
 		announcements = append(announcements, ann)
 	}
 
-	// Check if there are more results
-	hasMore := len(respData.Data.Items) > 0
-	return announcements, hasMore, nil
+	return announcements, info, nil
 }
 
-func getSnippet(fullText string, keyword string) string {
-	const contextSize = 50
+func getSnippet(fullText string, keyword string, opts SnippetOptions) string {
+	contextSize := cmp.Or(opts.Window, defaultSnippetWindow)
 
 	lowerText := strings.ToLower(fullText)
 	lowerKeyword := strings.ToLower(keyword)
@@ -349,39 +940,31 @@ func getSnippet(fullText string, keyword string) string {
 
 	start := max(index-contextSize, 0)
 	end := min(index+len(lowerKeyword)+contextSize, len(fullText))
+	startOK, endOK := start == 0, end == len(fullText)
 
-	snippet := fullText[start:end]
+	if opts.Sentences {
+		from, to, fromOK, toOK := sentenceBounds(fullText, index, index+len(lowerKeyword), contextSize*maxSentenceWindows)
+		if fromOK {
+			start, startOK = from, true
+		}
+		if toOK {
+			end, endOK = to, true
+		}
+	}
 
-	if start > 0 {
+	snippet := strings.TrimSpace(fullText[start:end])
+
+	if !startOK {
 		snippet = "... " + snippet
 	}
-	if end < len(fullText) {
+	if !endOK {
 		snippet = snippet + " ..."
 	}
 
 	return strings.ReplaceAll(snippet, "\n", " ")
 }
 
-func extractTextFromPDF(pdfURL string) (string, error) {
-	resp, err := client.Get(pdfURL)
-	if err != nil {
-		return "", fmt.Errorf("failed initial GET to %s: %w", pdfURL, err)
-	}
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil {
-			log.Printf("Warning: failed to close response body for %s: %v", pdfURL, cerr)
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to download PDF: received status code %d from %s", resp.StatusCode, pdfURL)
-	}
-
-	pdfBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read PDF response body: %w", err)
-	}
-
+func extractTextFromPDF(doc *document, layout bool) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), pdfProcessingTimeout)
 	defer cancel()
 
@@ -389,38 +972,41 @@ func extractTextFromPDF(pdfURL string) (string, error) {
 	errChan := make(chan error, 1)
 
 	go func() {
-		tmpFile, err := os.CreateTemp("", "asx_pdf_*.pdf")
+		// PDFs are piped in over stdin where the extractor supports it, so it never opens a
+		// path that the document's cleanup may remove.
+		args, tmpFile, err := extractor.command(doc, layout)
 		if err != nil {
-			errChan <- fmt.Errorf("failed to create temporary file: %w", err)
+			errChan <- err
 			return
 		}
-		tmpFileName := tmpFile.Name()
-		err = tmpFile.Close()
-		if err != nil {
-			errChan <- fmt.Errorf("failed to close temporary file: %w", err)
+		if tmpFile != "" {
+			defer func() {
+				if rerr := os.Remove(tmpFile); rerr != nil {
+					log.Printf("Warning: failed to remove temp file %s: %v", tmpFile, rerr)
+				}
+			}()
 		}
-		defer func() {
-			if rerr := os.Remove(tmpFileName); rerr != nil {
-				log.Printf("Warning: failed to remove temp file %s: %v", tmpFileName, rerr)
-			}
-		}()
 
-		if err := os.WriteFile(tmpFileName, pdfBytes, 0o644); err != nil {
-			errChan <- fmt.Errorf("failed to write PDF bytes to temp file: %w", err)
-			return
+		cmd := exec.CommandContext(ctx, extractor.binary, args...)
+		if extractor.stdin {
+			stdin, err := doc.open()
+			if err != nil {
+				errChan <- fmt.Errorf("failed to open PDF: %w", err)
+				return
+			}
+			defer stdin.Close()
+			cmd.Stdin = stdin
 		}
 
-		cmd := exec.CommandContext(ctx, "pdftotext", "-raw", tmpFileName, "-")
-
 		var out bytes.Buffer
 		var stderr bytes.Buffer
 		cmd.Stdout = &out
 		cmd.Stderr = &stderr
 
 		if err := cmd.Run(); err != nil {
-			cmdErr := fmt.Errorf("pdftotext failed: %v. Stderr: %s", err, strings.TrimSpace(stderr.String()))
+			cmdErr := fmt.Errorf("%s failed: %v. Stderr: %s", extractor.binary, err, strings.TrimSpace(stderr.String()))
 			if strings.Contains(cmdErr.Error(), "not found") {
-				errChan <- fmt.Errorf("pdftotext binary not found. Please ensure poppler-utils is installed. Error: %s", strings.TrimSpace(stderr.String()))
+				errChan <- fmt.Errorf("%s binary not found. Please ensure it is installed (pdftotext comes with poppler-utils). Error: %s", extractor.binary, strings.TrimSpace(stderr.String()))
 			} else {
 				errChan <- cmdErr
 			}
@@ -430,7 +1016,7 @@ func extractTextFromPDF(pdfURL string) (string, error) {
 		text := out.String()
 
 		if strings.TrimSpace(text) == "" {
-			errChan <- fmt.Errorf("pdftotext extracted empty text string. File may be image-based or protected")
+			errChan <- fmt.Errorf("%s extracted empty text string. File may be image-based or protected", extractor.binary)
 			return
 		}
 
@@ -0,0 +1,134 @@
+package asx
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	asxCompanyURL      = "https://www.asx.com.au/asx/1/company"
+	asxCompanyPageSize = 20 // the most the endpoint returns per request
+)
+
+// asxAnnouncementsResponse is the ASX company API's announcement list.
+type asxAnnouncementsResponse struct {
+	Data []struct {
+		DocumentReleaseDate string `json:"document_release_date"`
+		URL                 string `json:"url"`
+		Header              string `json:"header"`
+		MarketSensitive     bool   `json:"market_sensitive"`
+		IssuerCode          string `json:"issuer_code"`
+	} `json:"data"`
+}
+
+// fetchASXAnnouncements fetches each ticker's latest announcements from the ASX company API,
+// applying the date, since and price sensitivity filters of params.
+func fetchASXAnnouncements(tickers []string, params FetchParams) ([]types.Announcement, error) {
+	var targetDate string
+	if params.Date != "" {
+		if _, err := time.Parse("2006-01-02", params.Date); err != nil {
+			return nil, fmt.Errorf("invalid date format: %s (expected YYYY-MM-DD)", params.Date)
+		}
+		targetDate = params.Date
+	}
+
+	var all []types.Announcement
+	for _, ticker := range tickers {
+		announcements, err := fetchASXCompanyAnnouncements(ticker, params.PriceSensitiveOnly)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ann := range announcements {
+			if targetDate != "" && ann.DateTime.In(sydney()).Format("2006-01-02") != targetDate {
+				continue
+			}
+			if !params.Since.IsZero() && ann.DateTime.Before(params.Since) {
+				continue
+			}
+			all = append(all, ann)
+		}
+	}
+
+	slices.SortStableFunc(all, func(a, b types.Announcement) int { return b.DateTime.Compare(a.DateTime) })
+	if params.MaxResults > 0 && len(all) > params.MaxResults {
+		all = all[:params.MaxResults]
+	}
+	return all, nil
+}
+
+func fetchASXCompanyAnnouncements(ticker string, priceSensitiveOnly bool) ([]types.Announcement, error) {
+	url := fmt.Sprintf("%s/%s/announcements?count=%d&market_sensitive=%t",
+		asxCompanyURL, strings.ToUpper(ticker), asxCompanyPageSize, priceSensitiveOnly)
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-OK status code %d from %s", resp.StatusCode, url)
+	}
+
+	var respData asxAnnouncementsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON from %s: %w", url, err)
+	}
+
+	var announcements []types.Announcement
+	for _, item := range respData.Data {
+		if item.URL == "" {
+			continue
+		}
+		released, err := parseASXTime(item.DocumentReleaseDate)
+		if err != nil {
+			log.Printf("Warning: Failed to parse date string '%s': %v", item.DocumentReleaseDate, err)
+			continue
+		}
+		announcements = append(announcements, types.Announcement{
+			Ticker:           strings.ToUpper(cmp.Or(item.IssuerCode, ticker)),
+			Title:            item.Header,
+			IsPriceSensitive: item.MarketSensitive || priceSensitiveOnly,
+			DateTime:         released,
+			PDFURL:           item.URL,
+		})
+	}
+	return announcements, nil
+}
+
+// parseASXTime parses release times, which the API writes without a colon in the offset
+// ("2025-03-14T08:30:05+1100").
+func parseASXTime(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02T15:04:05-0700", s); err == nil {
+		return t.In(sydney()), nil
+	}
+	return parseMarketTime(s)
+}
+
+// parseMarketTime parses an RFC 3339 release time, or one without an offset as Sydney time,
+// and returns it in Sydney time so its date and clock time are the market's.
+func parseMarketTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(sydney()), nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", s, sydney())
+}
+
+// sydney returns the ASX's time zone, or UTC if the zone database is unavailable.
+var sydney = sync.OnceValue(func() *time.Location {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+})
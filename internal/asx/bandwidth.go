@@ -0,0 +1,102 @@
+package asx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	BandwidthFeed      = "feed"
+	BandwidthDocuments = "documents"
+	BandwidthOther     = "other"
+)
+
+var errBandwidthExceeded = errors.New("bandwidth cap reached, skipping download")
+
+// bandwidthMeter counts response bytes per category across all requests made by the package client.
+type bandwidthMeter struct {
+	mutex    sync.Mutex
+	counts   map[string]int64
+	total    atomic.Int64
+	maxBytes atomic.Int64
+}
+
+var bandwidth = &bandwidthMeter{counts: make(map[string]int64)}
+
+func (b *bandwidthMeter) add(category string, n int64) {
+	b.total.Add(n)
+	b.mutex.Lock()
+	b.counts[category] += n
+	b.mutex.Unlock()
+}
+
+// exceeded reports whether the soft cap has been reached.
+func (b *bandwidthMeter) exceeded() bool {
+	limit := b.maxBytes.Load()
+	return limit > 0 && b.total.Load() >= limit
+}
+
+// SetMaxBandwidth sets a soft cap in bytes. Once reached, no further documents are downloaded
+// although in-flight downloads and feed requests complete. 0 = unlimited.
+func SetMaxBandwidth(maxBytes int64) {
+	bandwidth.maxBytes.Store(maxBytes)
+}
+
+// BandwidthUsage returns the bytes downloaded so far in this run, by category.
+func BandwidthUsage() map[string]int64 {
+	bandwidth.mutex.Lock()
+	defer bandwidth.mutex.Unlock()
+
+	usage := make(map[string]int64, len(bandwidth.counts))
+	for k, v := range bandwidth.counts {
+		usage[k] = v
+	}
+	return usage
+}
+
+// BandwidthExceeded reports whether the soft cap was reached during the run.
+func BandwidthExceeded() bool {
+	return bandwidth.exceeded()
+}
+
+// countingTransport wraps a RoundTripper and meters every response body read.
+type countingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, category: bandwidthCategory(req.URL.String())}
+	return resp, nil
+}
+
+func bandwidthCategory(url string) string {
+	switch {
+	case strings.HasPrefix(url, markitAnnouncementsURL), strings.HasPrefix(url, markitCompanyURL), strings.HasPrefix(url, asxCompanyURL):
+		return BandwidthFeed
+	case strings.HasPrefix(url, markitPDFBaseURL):
+		return BandwidthDocuments
+	default:
+		return BandwidthOther
+	}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	category string
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		bandwidth.add(b.category, int64(n))
+	}
+	return n, err
+}
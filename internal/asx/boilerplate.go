@@ -0,0 +1,18 @@
+package asx
+
+import "regexp"
+
+// boilerplateTitle matches routine fund and plan notices that dominate the daily feed: NTA
+// and NAV statements, ETF basket (portfolio composition) files and DRP price notices.
+var boilerplateTitle = regexp.MustCompile(`(?i)(` +
+	`\bNTA\b|net tangible assets?\b|` +
+	`\b(daily|weekly|monthly|indicative) (fund )?(net asset value|NAV)\b|\biNAV\b|` +
+	`\bportfolio composition( file)?\b|\bPCF\b|\b(ETF|fund) (basket|holdings)\b|\bdaily (fund|portfolio) (update|holdings)\b|` +
+	`\bDRP (issue |allotment )?price\b|\b(dividend|distribution) reinvestment (plan )?(issue |allotment )?price\b` +
+	`)`)
+
+// IsBoilerplate reports whether an announcement title is a routine fund or plan notice:
+// a daily NTA or NAV statement, an ETF basket file or a DRP price notice.
+func IsBoilerplate(title string) bool {
+	return boilerplateTitle.MatchString(title)
+}
@@ -0,0 +1,169 @@
+package asx
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const maxBreakerCooldown = 30 * time.Minute
+
+// errSourceDegraded is returned without making a request while a host's circuit is open.
+var errSourceDegraded = errors.New("source degraded after repeated errors, skipping request")
+
+// circuitBreaker stops requests to a host after repeated failures, so an outage costs one
+// probe per cooldown instead of a failure for every announcement. The cooldown doubles each
+// time a probe fails, up to maxBreakerCooldown.
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	threshold int // consecutive failures that open the circuit, 0 = disabled
+	cooldown  time.Duration
+	hosts     map[string]*hostCircuit
+}
+
+type hostCircuit struct {
+	failures  int
+	openUntil time.Time
+	cooldown  time.Duration
+	probing   bool // a request is testing whether the host has recovered
+	lastError string
+}
+
+var breaker = &circuitBreaker{threshold: 5, cooldown: time.Minute, hosts: make(map[string]*hostCircuit)}
+
+// SetCircuitBreaker opens a host's circuit after threshold consecutive failed requests
+// (errors, 5xx responses or maintenance pages) and probes it again after cooldown.
+// A threshold of 0 disables the breaker.
+func SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	breaker.threshold = threshold
+	breaker.cooldown = cooldown
+}
+
+// DegradedSources returns the hosts whose circuit is open, with the last error seen from each.
+func DegradedSources() map[string]string {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	degraded := make(map[string]string)
+	for host, c := range breaker.hosts {
+		if c.open() {
+			degraded[host] = c.lastError
+		}
+	}
+	return degraded
+}
+
+// open reports whether the circuit has tripped and not yet recovered.
+func (c *hostCircuit) open() bool {
+	return !c.openUntil.IsZero()
+}
+
+// allow reports whether a request to host may be made, letting a single probe through once
+// the cooldown has passed.
+func (b *circuitBreaker) allow(host string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c := b.hosts[host]
+	if b.threshold <= 0 || c == nil || !c.open() {
+		return true
+	}
+	if c.probing || time.Now().Before(c.openUntil) {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+// record updates host's circuit with the outcome of a request.
+func (b *circuitBreaker) record(host string, failure error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.threshold <= 0 {
+		return
+	}
+	c := b.hosts[host]
+	if c == nil {
+		c = &hostCircuit{}
+		b.hosts[host] = c
+	}
+
+	if failure == nil {
+		if c.open() {
+			log.Printf("%s has recovered, resuming requests.", host)
+		}
+		*c = hostCircuit{}
+		return
+	}
+
+	c.failures++
+	c.lastError = failure.Error()
+	switch {
+	case c.probing:
+		c.probing = false
+		c.cooldown = min(c.cooldown*2, maxBreakerCooldown)
+		c.openUntil = time.Now().Add(c.cooldown)
+		log.Printf("Warning: %s is still failing (%v), next attempt in %s.", host, failure, c.cooldown)
+	case !c.open() && c.failures >= b.threshold:
+		c.cooldown = b.cooldown
+		c.openUntil = time.Now().Add(c.cooldown)
+		log.Printf("Warning: %s failed %d times in a row (%v), pausing requests for %s.", host, c.failures, failure, c.cooldown)
+	}
+}
+
+// abandon releases a probe whose request was cancelled before the host answered.
+func (b *circuitBreaker) abandon(host string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if c := b.hosts[host]; c != nil {
+		c.probing = false
+	}
+}
+
+// breakerTransport applies the circuit breaker to every request made by the package client.
+type breakerTransport struct {
+	base http.RoundTripper
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if !breaker.allow(host) {
+		return nil, errSourceDegraded
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		if req.Context().Err() == nil {
+			breaker.record(host, err)
+		} else {
+			breaker.abandon(host)
+		}
+		return nil, err
+	}
+	breaker.record(host, responseFailure(req, resp))
+	return resp, nil
+}
+
+// responseFailure returns why a response indicates the source is unhealthy: a server error,
+// or an HTML maintenance page served in place of the JSON feed.
+func responseFailure(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if bandwidthCategory(req.URL.String()) == BandwidthFeed && resp.StatusCode == http.StatusOK {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if slices.Contains([]string{"text/html", "application/xhtml+xml"}, mediaType) {
+			return errors.New("maintenance page served instead of the feed")
+		}
+	}
+	return nil
+}
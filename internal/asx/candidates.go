@@ -0,0 +1,48 @@
+package asx
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/forms"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// resultsTitle matches lodgements likely to carry structured financial results.
+var resultsTitle = regexp.MustCompile(`(?i)appendix 4[de]|results|financial (report|statements)|annual report|half[- ]year|accounts`)
+
+// titleCandidates is the first stage of a two-stage scan: it keeps only the announcements whose
+// documents could produce a match, judged without downloading anything.
+func titleCandidates(announcements []types.Announcement, lifted map[string]types.Announcement, params ProcessParams) []types.Announcement {
+	var candidates []types.Announcement
+	for _, ann := range announcements {
+		if isTitleCandidate(ann, lifted, params) {
+			candidates = append(candidates, ann)
+		}
+	}
+	return candidates
+}
+
+func isTitleCandidate(ann types.Announcement, lifted map[string]types.Announcement, params ProcessParams) bool {
+	if isTickerMatch(ann.Ticker, params.Tickers) {
+		return true
+	}
+	if _, ok := lifted[ann.PDFURL]; ok {
+		return true
+	}
+
+	lowerTitle := strings.ToLower(ann.Title)
+	for _, kw := range params.Keywords {
+		if strings.Contains(lowerTitle, snippetTerm(kw)) {
+			return true
+		}
+	}
+
+	if params.MinFundingQuarters > 0 && forms.IsCashFlowReport(ann.Title, "") {
+		return true
+	}
+	if params.MinResultChange > 0 && resultsTitle.MatchString(ann.Title) {
+		return true
+	}
+	return false
+}
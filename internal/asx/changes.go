@@ -0,0 +1,122 @@
+package asx
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/archive"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	maxChangedLines = 20 // added or removed lines kept per report
+	minChangedLine  = 12 // shorter lines (page numbers, headings, lone figures) are ignored
+)
+
+// PreviousReports looks up a company's earlier announcements, such as the archive.
+type PreviousReports interface {
+	Previous(ctx context.Context, ticker string, titleTerms []string, before time.Time) (*archive.Result, error)
+}
+
+// recurringReport is a kind of report a company lodges on a schedule, recognised by its title.
+type recurringReport struct {
+	kind  string
+	terms []string // lowercase title substrings
+}
+
+// recurringReports are compared with the company's previous report of the same kind. More
+// specific kinds come first, since a quarterly activities report often names its 4C too.
+var recurringReports = []recurringReport{
+	{"Appendix 4C", []string{"appendix 4c", "4c quarterly", "quarterly cash flow"}},
+	{"Appendix 5B", []string{"appendix 5b", "5b quarterly"}},
+	{"quarterly activities report", []string{"quarterly activities", "quarterly report", "quarterly update"}},
+	{"monthly update", []string{"monthly update", "monthly report", "monthly activities"}},
+	{"investor presentation", []string{"investor presentation", "corporate presentation", "presentation"}},
+	{"half year report", []string{"half year", "half-year", "appendix 4d"}},
+	{"annual report", []string{"annual report", "appendix 4e"}},
+}
+
+// reportKind returns the recurring report kind of title, or nil.
+func reportKind(title string) *recurringReport {
+	lower := strings.ToLower(title)
+	for i, r := range recurringReports {
+		for _, term := range r.terms {
+			if strings.Contains(lower, term) {
+				return &recurringReports[i]
+			}
+		}
+	}
+	return nil
+}
+
+// findChanges compares a recurring report with the company's previous one of the same kind.
+// It returns nil for other announcements, when there is no previous report or when nothing
+// changed.
+func findChanges(ctx context.Context, ann types.Announcement, text string, reports PreviousReports) *types.Changes {
+	kind := reportKind(ann.Title)
+	if kind == nil {
+		return nil
+	}
+
+	previous, err := reports.Previous(ctx, ann.Ticker, kind.terms, ann.DateTime)
+	if err != nil {
+		log.Printf("Warning: Failed to look up the previous %s for %s: %v", kind.kind, ann.Ticker, err)
+		return nil
+	}
+	if previous == nil || previous.URL == ann.PDFURL {
+		return nil
+	}
+
+	added, removed := diffLines(previous.Snippet, text)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	return &types.Changes{
+		Kind:          kind.kind,
+		PreviousTitle: previous.Title,
+		PreviousURL:   previous.URL,
+		PreviousDate:  previous.Published,
+		Added:         added,
+		Removed:       removed,
+	}
+}
+
+// diffLines returns the lines of newText missing from oldText and those of oldText missing
+// from newText, in document order and capped at maxChangedLines. Repeated lines are
+// counted, and whitespace differences are ignored.
+func diffLines(oldText, newText string) (added, removed []string) {
+	oldLines, newLines := changeLines(oldText), changeLines(newText)
+
+	remaining := make(map[string]int, len(oldLines))
+	for _, line := range oldLines {
+		remaining[line]++
+	}
+	for _, line := range newLines {
+		if remaining[line] > 0 {
+			remaining[line]--
+		} else if len(added) < maxChangedLines {
+			added = append(added, line)
+		}
+	}
+	for _, line := range oldLines {
+		if remaining[line] > 0 {
+			remaining[line]--
+			if len(removed) < maxChangedLines {
+				removed = append(removed, line)
+			}
+		}
+	}
+	return added, removed
+}
+
+func changeLines(text string) []string {
+	var lines []string
+	for line := range strings.Lines(text) {
+		if line = strings.Join(strings.Fields(line), " "); len(line) >= minChangedLine {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,58 @@
+package asx
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// errFeedUnchanged is returned by fetchAnnouncements when a conditional request finds the
+// feed page unchanged since it was last fetched.
+var errFeedUnchanged = errors.New("feed unchanged since last fetch")
+
+// feedValidator holds what identifies the last response for a feed URL: the server's
+// validators where it sends them, and a hash of the body where it doesn't.
+type feedValidator struct {
+	etag         string
+	lastModified string
+	hash         string
+}
+
+var (
+	feedMutex      sync.Mutex
+	feedValidators = make(map[string]feedValidator)
+)
+
+// addConditionalHeaders adds If-None-Match and If-Modified-Since from the last response for
+// the request's URL.
+func addConditionalHeaders(req *http.Request) {
+	feedMutex.Lock()
+	v, ok := feedValidators[req.URL.String()]
+	feedMutex.Unlock()
+	if !ok {
+		return
+	}
+	if v.etag != "" {
+		req.Header.Set("If-None-Match", v.etag)
+	}
+	if v.lastModified != "" {
+		req.Header.Set("If-Modified-Since", v.lastModified)
+	}
+}
+
+// feedChanged records the response for url and reports whether its body differs from the
+// last one fetched.
+func feedChanged(url string, resp *http.Response, body []byte) bool {
+	v := feedValidator{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		hash:         documentHash(string(body)),
+	}
+
+	feedMutex.Lock()
+	defer feedMutex.Unlock()
+
+	previous, ok := feedValidators[url]
+	feedValidators[url] = v
+	return !ok || previous.hash != v.hash
+}
@@ -0,0 +1,285 @@
+package asx
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	markitDirectoryURL    = markitCompanyURL + "/directory/file"
+	asxListedCompaniesURL = "https://www.asx.com.au/asx/research/ASXListedCompanies.csv"
+	directoryFileName     = "asx_companies.csv"
+	directoryMaxAge       = 24 * time.Hour
+)
+
+// Company is an entry in the ASX listed company directory.
+type Company struct {
+	Code          string
+	Name          string
+	IndustryGroup string  // GICS industry group, e.g. "Capital Goods"
+	Sector        string  // GICS sector derived from the industry group, e.g. "Industrials"
+	MarketCap     float64 // AUD, 0 = unknown
+}
+
+// gicsSectors maps GICS industry groups, including names retired in 2023, to their sector.
+var gicsSectors = map[string]string{
+	"energy":                             "Energy",
+	"materials":                          "Materials",
+	"capital goods":                      "Industrials",
+	"commercial & professional services": "Industrials",
+	"transportation":                     "Industrials",
+	"automobiles & components":           "Consumer Discretionary",
+	"consumer durables & apparel":        "Consumer Discretionary",
+	"consumer services":                  "Consumer Discretionary",
+	"consumer discretionary distribution & retail": "Consumer Discretionary",
+	"retailing":                                      "Consumer Discretionary",
+	"consumer staples distribution & retail":         "Consumer Staples",
+	"food & staples retailing":                       "Consumer Staples",
+	"food, beverage & tobacco":                       "Consumer Staples",
+	"household & personal products":                  "Consumer Staples",
+	"health care equipment & services":               "Health Care",
+	"pharmaceuticals, biotechnology & life sciences": "Health Care",
+	"banks":                           "Financials",
+	"financial services":              "Financials",
+	"diversified financials":          "Financials",
+	"insurance":                       "Financials",
+	"software & services":             "Information Technology",
+	"technology hardware & equipment": "Information Technology",
+	"semiconductors & semiconductor equipment": "Information Technology",
+	"telecommunication services":               "Communication Services",
+	"media & entertainment":                    "Communication Services",
+	"media":                                    "Communication Services",
+	"utilities":                                "Utilities",
+	"real estate":                              "Real Estate",
+	"equity real estate investment trusts (reits)": "Real Estate",
+	"real estate management & development":         "Real Estate",
+}
+
+// Directory maps ASX codes to listed companies.
+type Directory struct {
+	companies map[string]Company
+}
+
+// LoadDirectory returns the ASX company directory, downloading it at most once a day and
+// falling back to a stale copy in cacheDir if the download fails.
+func LoadDirectory(cacheDir string) (*Directory, error) {
+	path := filepath.Join(cacheDir, directoryFileName)
+
+	info, statErr := os.Stat(path)
+	if statErr != nil || time.Since(info.ModTime()) > directoryMaxAge {
+		data, err := downloadDirectory()
+		if err == nil {
+			if err := os.MkdirAll(cacheDir, 0o755); err == nil {
+				if err := writeFileAtomic(path, data); err != nil {
+					log.Printf("Warning: failed to cache company directory: %v", err)
+				}
+			}
+			return parseDirectory(data)
+		}
+		if statErr != nil {
+			return nil, err
+		}
+		log.Printf("Warning: %v. Using company directory cached %s.", err, info.ModTime().Format("2006-01-02 15:04"))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached company directory: %w", err)
+	}
+	return parseDirectory(data)
+}
+
+// downloadDirectory fetches the Markit company directory, which includes market caps, and
+// falls back to the ASX listed companies file without them.
+func downloadDirectory() ([]byte, error) {
+	data, err := downloadCSV(markitDirectoryURL)
+	if err == nil {
+		return data, nil
+	}
+	log.Printf("Warning: %v. Falling back to the ASX listed companies file (no market caps).", err)
+	return downloadCSV(asxListedCompaniesURL)
+}
+
+func downloadCSV(url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download company directory: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download company directory: received status code %d from %s", resp.StatusCode, url)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseDirectory reads the directory CSV. The file starts with a title line before the
+// header, so columns are located by header name rather than position.
+func parseDirectory(data []byte) (*Directory, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	codeCol, nameCol, groupCol, capCol := -1, -1, -1, -1
+	d := &Directory{companies: make(map[string]Company)}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse company directory: %w", err)
+		}
+
+		if codeCol < 0 {
+			for i, h := range record {
+				switch strings.ToLower(strings.TrimSpace(h)) {
+				case "asx code":
+					codeCol = i
+				case "company name":
+					nameCol = i
+				case "gics industry group":
+					groupCol = i
+				case "market cap":
+					capCol = i
+				}
+			}
+			if nameCol < 0 {
+				codeCol = -1
+			}
+			continue
+		}
+
+		if codeCol >= len(record) || nameCol >= len(record) {
+			continue
+		}
+		code := strings.ToUpper(strings.TrimSpace(record[codeCol]))
+		if code == "" {
+			continue
+		}
+		c := Company{Code: code, Name: strings.TrimSpace(record[nameCol])}
+		if groupCol >= 0 && groupCol < len(record) {
+			c.IndustryGroup = strings.TrimSpace(record[groupCol])
+			c.Sector = gicsSectors[strings.ToLower(c.IndustryGroup)]
+		}
+		if capCol >= 0 && capCol < len(record) {
+			c.MarketCap, _ = strconv.ParseFloat(strings.NewReplacer(",", "", "$", "").Replace(strings.TrimSpace(record[capCol])), 64)
+		}
+		d.companies[code] = c
+	}
+
+	if codeCol < 0 {
+		return nil, errors.New("company directory has no 'ASX code' and 'Company name' header")
+	}
+	return d, nil
+}
+
+// Lookup returns the company listed under code.
+func (d *Directory) Lookup(code string) (Company, bool) {
+	if d == nil {
+		return Company{}, false
+	}
+	c, ok := d.companies[strings.ToUpper(code)]
+	return c, ok
+}
+
+// MatchNames returns the codes of companies whose name contains any of the given substrings,
+// case-insensitively.
+func (d *Directory) MatchNames(names []string) []string {
+	if d == nil {
+		return nil
+	}
+
+	var codes []string
+	for code, c := range d.companies {
+		name := strings.ToLower(c.Name)
+		for _, n := range names {
+			if strings.Contains(name, strings.ToLower(n)) {
+				codes = append(codes, code)
+				break
+			}
+		}
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Annotate fills in the company name, sector and market cap of each announcement.
+func (d *Directory) Annotate(announcements []types.Announcement) {
+	if d == nil {
+		return
+	}
+	for i := range announcements {
+		if c, ok := d.companies[announcements[i].Ticker]; ok {
+			announcements[i].CompanyName = c.Name
+			announcements[i].Sector = cmp.Or(c.Sector, c.IndustryGroup)
+			announcements[i].MarketCap = c.MarketCap
+		}
+	}
+}
+
+// CompanyFilter restricts announcements by their company's sector and market cap.
+type CompanyFilter struct {
+	Sectors      []string // GICS sectors or industry groups, case-insensitive
+	MinMarketCap float64  // AUD, 0 = no minimum
+	MaxMarketCap float64  // AUD, 0 = no maximum
+}
+
+// Active reports whether the filter restricts anything.
+func (f CompanyFilter) Active() bool {
+	return len(f.Sectors) > 0 || f.MinMarketCap > 0 || f.MaxMarketCap > 0
+}
+
+// Allows reports whether c satisfies the filter. Unknown sectors and market caps pass, since
+// newly listed companies are often missing from the directory.
+func (f CompanyFilter) Allows(c Company) bool {
+	if len(f.Sectors) > 0 && (c.Sector != "" || c.IndustryGroup != "") &&
+		!slices.ContainsFunc(f.Sectors, func(s string) bool {
+			return strings.EqualFold(s, c.Sector) || strings.EqualFold(s, c.IndustryGroup)
+		}) {
+		return false
+	}
+	if c.MarketCap > 0 {
+		if f.MinMarketCap > 0 && c.MarketCap < f.MinMarketCap {
+			return false
+		}
+		if f.MaxMarketCap > 0 && c.MarketCap > f.MaxMarketCap {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterAnnouncements drops announcements from companies outside filter, before any
+// documents are downloaded.
+func (d *Directory) FilterAnnouncements(announcements []types.Announcement, filter CompanyFilter) []types.Announcement {
+	if d == nil || !filter.Active() {
+		return announcements
+	}
+	return slices.DeleteFunc(announcements, func(a types.Announcement) bool {
+		c, ok := d.companies[a.Ticker]
+		return ok && !filter.Allows(c)
+	})
+}
+
+// Len returns the number of companies in the directory.
+func (d *Directory) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.companies)
+}
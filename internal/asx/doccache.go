@@ -0,0 +1,232 @@
+package asx
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cacheDataExt = ".doc"
+	cacheTypeExt = ".type"
+	cacheTextExt = ".txt"
+	// Layout text is cached separately so switching -layout modes doesn't reuse the wrong text.
+	cacheLayoutExt = ".ltxt"
+)
+
+// documentCache keeps downloaded attachments and their extracted text on disk, evicting the
+// least recently used documents once the total size exceeds maxBytes.
+type documentCache struct {
+	mutex    sync.Mutex
+	dir      string
+	maxBytes int64
+}
+
+var docCache *documentCache // nil = caching disabled
+
+// SetDocumentCache enables the on-disk document cache in dir, capped at maxBytes (0 = unlimited).
+// An empty dir disables caching.
+func SetDocumentCache(dir string, maxBytes int64) error {
+	if dir == "" {
+		docCache = nil
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create document cache directory: %w", err)
+	}
+	docCache = &documentCache{dir: dir, maxBytes: maxBytes}
+	return nil
+}
+
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// documentID derives the cache key from an announcement URL. Markit URLs end in the ASX
+// document key; anything else falls back to a hash of the URL.
+func documentID(url string) string {
+	base := path.Base(strings.TrimRight(strings.SplitN(url, "?", 2)[0], "/"))
+	if base != "" && base != "." && base != "/" && !unsafeKeyChars.MatchString(base) && len(base) <= 128 {
+		return base
+	}
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *documentCache) path(id, ext string) string {
+	return filepath.Join(c.dir, id+ext)
+}
+
+// document returns the cached attachment for url, if any.
+func (c *documentCache) document(url string) (*document, bool) {
+	if c == nil {
+		return nil, false
+	}
+	id := documentID(url)
+
+	data, err := os.ReadFile(c.path(id, cacheDataExt))
+	if err != nil {
+		return nil, false
+	}
+	contentType, err := os.ReadFile(c.path(id, cacheTypeExt))
+	if err != nil {
+		return nil, false
+	}
+
+	c.touch(id)
+	return &document{data: data, contentType: string(contentType)}, true
+}
+
+// storeDocument saves a downloaded attachment and evicts old entries if over the size cap.
+func (c *documentCache) storeDocument(url string, doc *document) {
+	if c == nil {
+		return
+	}
+	id := documentID(url)
+
+	if err := writeFileAtomic(c.path(id, cacheTypeExt), []byte(doc.contentType)); err != nil {
+		log.Printf("Warning: failed to cache document %s: %v", id, err)
+		return
+	}
+	if err := doc.writeFile(c.path(id, cacheDataExt)); err != nil {
+		log.Printf("Warning: failed to cache document %s: %v", id, err)
+		return
+	}
+
+	c.evict()
+}
+
+// textExt returns the extension of cached text extracted in reading order or with layout.
+func textExt(layout bool) string {
+	if layout {
+		return cacheLayoutExt
+	}
+	return cacheTextExt
+}
+
+// text returns the cached extracted text for url, if any.
+func (c *documentCache) text(url string, layout bool) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	data, err := os.ReadFile(c.path(documentID(url), textExt(layout)))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// storeText saves the extracted text for url.
+func (c *documentCache) storeText(url string, layout bool, text string) {
+	if c == nil {
+		return
+	}
+	id := documentID(url)
+	if err := writeFileAtomic(c.path(id, textExt(layout)), []byte(text)); err != nil {
+		log.Printf("Warning: failed to cache text for %s: %v", id, err)
+	}
+}
+
+// touch marks a document as recently used.
+func (c *documentCache) touch(id string) {
+	now := time.Now()
+	_ = os.Chtimes(c.path(id, cacheDataExt), now, now)
+}
+
+// evict removes the least recently used documents until the cache fits within maxBytes.
+func (c *documentCache) evict() {
+	if c.maxBytes <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type cached struct {
+		id      string
+		size    int64
+		usedAt  time.Time
+		related []string
+	}
+
+	byID := make(map[string]*cached)
+	var total int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(e.Name())
+		id := strings.TrimSuffix(e.Name(), ext)
+
+		entry := byID[id]
+		if entry == nil {
+			entry = &cached{id: id}
+			byID[id] = entry
+		}
+		entry.size += info.Size()
+		entry.related = append(entry.related, e.Name())
+		if ext == cacheDataExt {
+			entry.usedAt = info.ModTime()
+		}
+		total += info.Size()
+	}
+
+	if total <= c.maxBytes {
+		return
+	}
+
+	lru := make([]*cached, 0, len(byID))
+	for _, entry := range byID {
+		lru = append(lru, entry)
+	}
+	slices.SortFunc(lru, func(a, b *cached) int { return cmp.Compare(a.usedAt.UnixNano(), b.usedAt.UnixNano()) })
+
+	for _, entry := range lru {
+		if total <= c.maxBytes {
+			break
+		}
+		for _, name := range entry.related {
+			_ = os.Remove(filepath.Join(c.dir, name))
+		}
+		total -= entry.size
+	}
+}
+
+// writeFileAtomic writes data via a temporary file so readers never see partial content.
+func writeFileAtomic(name string, data []byte) error {
+	return writeReaderAtomic(name, bytes.NewReader(data))
+}
+
+// writeReaderAtomic is writeFileAtomic for content streamed from r.
+func writeReaderAtomic(name string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
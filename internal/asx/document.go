@@ -0,0 +1,366 @@
+package asx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/shanehull/annscraper/internal/types"
+	"github.com/shanehull/annscraper/internal/xbrl"
+)
+
+const (
+	contentTypePDF   = "application/pdf"
+	contentTypeXBRL  = "application/xbrl+xml"
+	contentTypeHTML  = "text/html"
+	contentTypeXHTML = "application/xhtml+xml"
+	contentTypeText  = "text/plain"
+)
+
+// document is a downloaded announcement attachment. Downloaded PDFs stay in a temporary file
+// rather than memory; other attachments are small and held in data.
+type document struct {
+	data        []byte
+	path        string // temporary file holding the attachment, "" when data is set
+	contentType string
+	cached      bool // served from the document cache rather than downloaded
+}
+
+// errDocumentTooLarge is returned for attachments over the size set by SetDocumentLimits.
+var errDocumentTooLarge = errors.New("document exceeds the size cap, skipping")
+
+// Limits set by SetDocumentLimits, 0 = unlimited.
+var (
+	maxDocumentBytes int64
+	maxPDFPages      int
+)
+
+// SetDocumentLimits skips attachments larger than maxBytes and extracts text from at most the
+// first maxPages pages of a PDF. 0 = unlimited.
+func SetDocumentLimits(maxBytes int64, maxPages int) {
+	maxDocumentBytes = maxBytes
+	maxPDFPages = maxPages
+}
+
+// bytes returns the attachment's contents, reading them from disk for downloaded PDFs.
+func (d *document) bytes() ([]byte, error) {
+	if d.path == "" {
+		return d.data, nil
+	}
+	return os.ReadFile(d.path)
+}
+
+// open returns a reader over the attachment's contents, streaming a downloaded PDF from disk.
+func (d *document) open() (io.ReadCloser, error) {
+	if d.path == "" {
+		return io.NopCloser(bytes.NewReader(d.data)), nil
+	}
+	return os.Open(d.path)
+}
+
+// writeFile atomically writes the attachment to name without loading a downloaded PDF into memory.
+func (d *document) writeFile(name string) error {
+	if d.path == "" {
+		return writeFileAtomic(name, d.data)
+	}
+	f, err := os.Open(d.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeReaderAtomic(name, f)
+}
+
+// close removes the temporary file of a downloaded PDF.
+func (d *document) close() {
+	if d.path == "" {
+		return
+	}
+	if err := os.Remove(d.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to remove temp file %s: %v", d.path, err)
+	}
+}
+
+// unsupportedDocumentError is returned for attachments the pipeline cannot read.
+type unsupportedDocumentError struct {
+	contentType string
+}
+
+func (e *unsupportedDocumentError) Error() string {
+	return fmt.Sprintf("unsupported attachment type %s", e.contentType)
+}
+
+// loadDocument returns the attachment at url from the document cache, downloading it on a miss.
+func loadDocument(url string) (*document, error) {
+	if doc, ok := docCache.document(url); ok {
+		doc.cached = true
+		return doc, nil
+	}
+
+	if bandwidth.exceeded() {
+		return nil, errBandwidthExceeded
+	}
+
+	doc, err := downloadDocument(url)
+	if err != nil {
+		return nil, fmt.Errorf("document download failed: %w", err)
+	}
+
+	docCache.storeDocument(url, doc)
+	return doc, nil
+}
+
+// loadDocumentText extracts text from doc, reusing cached PDF text. Other formats are cheap
+// to convert (and XBRL must be re-parsed for its figures) so only PDF text is cached.
+// layout extracts PDFs with their physical layout preserved and tables reconstructed.
+func loadDocumentText(url string, doc *document, layout bool) (string, *xbrl.Financials, error) {
+	if doc.contentType != contentTypePDF {
+		return extractDocumentText(doc, layout)
+	}
+
+	if text, ok := docCache.text(url, layout); ok {
+		return normalizeText(text), nil, nil
+	}
+
+	text, financials, err := extractDocumentText(doc, layout)
+	if err != nil {
+		return "", nil, err
+	}
+
+	docCache.storeText(url, layout, text)
+	return text, financials, nil
+}
+
+// downloadDocument downloads an attachment, accepting the ASX terms first if they are
+// served in its place.
+func downloadDocument(url string) (*document, error) {
+	started := time.Now()
+	doc, err := fetchDocument(url)
+	if err != nil || doc.contentType != contentTypeHTML {
+		return doc, err
+	}
+
+	form, ok := parseTermsForm(doc.data, url)
+	if !ok {
+		return doc, nil
+	}
+	if err := terms.accept(form, started); err != nil {
+		return nil, err
+	}
+	return fetchDocument(url)
+}
+
+func fetchDocument(url string) (*document, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed initial GET to %s: %w", url, err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			log.Printf("Warning: failed to close response body for %s: %v", url, cerr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download document: received status code %d from %s", resp.StatusCode, url)
+	}
+	if maxDocumentBytes > 0 && resp.ContentLength > maxDocumentBytes {
+		return nil, errDocumentTooLarge
+	}
+
+	tmp, err := os.CreateTemp("", "asx_doc_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	doc := &document{path: tmp.Name()}
+
+	body := io.Reader(resp.Body)
+	if maxDocumentBytes > 0 {
+		body = io.LimitReader(resp.Body, maxDocumentBytes+1)
+	}
+	n, err := io.Copy(tmp, body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		doc.close()
+		return nil, fmt.Errorf("failed to read document response body: %w", err)
+	}
+	if maxDocumentBytes > 0 && n > maxDocumentBytes {
+		doc.close()
+		return nil, errDocumentTooLarge
+	}
+
+	head, err := readHead(doc.path, 4096)
+	if err != nil {
+		doc.close()
+		return nil, err
+	}
+	doc.contentType = detectContentType(head, resp.Header.Get("Content-Type"))
+	if doc.contentType == contentTypePDF {
+		return doc, nil
+	}
+
+	data, err := os.ReadFile(doc.path)
+	doc.close()
+	if err != nil {
+		return nil, err
+	}
+	return &document{data: data, contentType: doc.contentType}, nil
+}
+
+// readHead returns up to n bytes from the start of a file.
+func readHead(name string, n int) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, n)
+	read, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:read], nil
+}
+
+// documentExtensions maps content types to file extensions for saved documents.
+var documentExtensions = map[string]string{
+	contentTypePDF:   ".pdf",
+	contentTypeXBRL:  ".xml",
+	contentTypeHTML:  ".html",
+	contentTypeXHTML: ".xhtml",
+	contentTypeText:  ".txt",
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^\p{L}\p{N} ._()&,-]+`)
+
+// DocumentPath returns the relative path a saved document is stored under:
+// TICKER/YYYY-MM-DD_Title.ext.
+func DocumentPath(ann types.Announcement, contentType string) string {
+	title := strings.Join(strings.Fields(unsafeFileNameChars.ReplaceAllString(ann.Title, " ")), " ")
+	if runes := []rune(title); len(runes) > 120 {
+		title = strings.TrimSpace(string(runes[:120]))
+	}
+	if title == "" {
+		title = documentID(ann.PDFURL)
+	}
+
+	ext, ok := documentExtensions[contentType]
+	if !ok {
+		ext = ".bin"
+	}
+
+	ticker := strings.ToUpper(unsafeFileNameChars.ReplaceAllString(ann.Ticker, ""))
+	return path.Join(ticker, ann.DateTime.Format("2006-01-02")+"_"+title+ext)
+}
+
+// saveDocument writes doc to dir/DocumentPath and returns the path.
+// An existing file of the same name is left untouched.
+func saveDocument(dir string, ann types.Announcement, doc *document) (string, error) {
+	name := filepath.Join(dir, filepath.FromSlash(DocumentPath(ann, doc.contentType)))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	return name, doc.writeFile(name)
+}
+
+// detectContentType prefers magic bytes over the server's header, which is often generic.
+func detectContentType(data []byte, header string) string {
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return contentTypePDF
+	}
+	if xbrl.IsXBRL(data) {
+		return contentTypeXBRL
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	return mediaType
+}
+
+// extractDocumentText converts a document into searchable text. Structured results are
+// returned alongside the text when the document is XBRL.
+func extractDocumentText(doc *document, layout bool) (string, *xbrl.Financials, error) {
+	switch doc.contentType {
+	case contentTypePDF:
+		text, err := extractTextFromPDF(doc, layout)
+		if err != nil {
+			return "", nil, fmt.Errorf("PDF text extraction failed: %w", err)
+		}
+		text = normalizeText(text)
+		if layout {
+			text = reconstructTables(text)
+		}
+		return text, nil, nil
+	case contentTypeXBRL:
+		financials, err := xbrl.Parse(doc.data)
+		if err != nil {
+			return "", nil, fmt.Errorf("structured data parsing failed: %w", err)
+		}
+		return financials.Summary(), financials, nil
+	case contentTypeHTML, contentTypeXHTML:
+		text := htmlToText(doc.data)
+		if strings.TrimSpace(text) == "" {
+			return "", nil, fmt.Errorf("HTML attachment contained no text")
+		}
+		return normalizeText(text), nil, nil
+	case contentTypeText:
+		return normalizeText(string(doc.data)), nil, nil
+	default:
+		return "", nil, &unsupportedDocumentError{contentType: doc.contentType}
+	}
+}
+
+// htmlToText returns the visible text of an HTML document, one block per line.
+func htmlToText(data []byte) string {
+	var sb strings.Builder
+	z := html.NewTokenizer(bytes.NewReader(data))
+	skip := 0
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return sb.String()
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script", "style", "head":
+				skip++
+			case "br", "p", "div", "tr", "li", "h1", "h2", "h3", "h4", "table":
+				sb.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script", "style", "head":
+				skip = max(skip-1, 0)
+			}
+		case html.TextToken:
+			if skip == 0 {
+				if text := strings.TrimSpace(string(z.Text())); text != "" {
+					sb.WriteString(text + " ")
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package asx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// inputPlaceholder marks where the PDF path goes in a custom extractor command.
+const inputPlaceholder = "{input}"
+
+// pdfExtractor is an external command that writes the text of a PDF to stdout.
+type pdfExtractor struct {
+	binary  string
+	options []string // user-supplied options, or the tool's defaults
+	layout  []string // options for layout extraction, nil = same as options
+	stdin   bool     // the tool can read the PDF from stdin
+	args    func(options []string, input string, maxPages int) []string
+}
+
+// extractorTemplate describes how to invoke a known extractor.
+type extractorTemplate struct {
+	defaults []string
+	layout   []string
+	stdin    bool
+	args     func(options []string, input string, maxPages int) []string
+}
+
+// extractorTemplates are the extractors that can be selected by name alone.
+var extractorTemplates = map[string]extractorTemplate{
+	"pdftotext": {
+		defaults: []string{"-raw"},
+		layout:   []string{"-layout"},
+		stdin:    true,
+		args: func(options []string, input string, maxPages int) []string {
+			args := slices.Clone(options)
+			if maxPages > 0 {
+				args = append(args, "-l", strconv.Itoa(maxPages))
+			}
+			return append(args, input, "-")
+		},
+	},
+	"mutool": {
+		defaults: []string{"draw", "-F", "txt"},
+		layout:   []string{"draw", "-F", "txt", "-O", "preserve-whitespace"},
+		args: func(options []string, input string, maxPages int) []string {
+			args := append(slices.Clone(options), "-o", "-", input)
+			if maxPages > 0 {
+				args = append(args, "1-"+strconv.Itoa(maxPages))
+			}
+			return args
+		},
+	},
+}
+
+// extractor is the command used for PDF text extraction.
+var extractor = mustParseExtractor("pdftotext")
+
+// SetPDFExtractor selects the command used to extract text from PDFs. The spec is a known
+// extractor ("pdftotext", "mutool") optionally followed by options replacing its defaults,
+// e.g. "pdftotext -layout", or any command whose arguments contain {input} for the PDF path,
+// e.g. "pdfium_text {input}". Custom commands must write the text to stdout.
+func SetPDFExtractor(spec string) error {
+	e, err := parseExtractor(spec)
+	if err != nil {
+		return err
+	}
+	extractor = e
+	return nil
+}
+
+func parseExtractor(spec string) (*pdfExtractor, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty PDF extractor command")
+	}
+	binary, options := fields[0], fields[1:]
+
+	for _, o := range options {
+		if strings.Contains(o, inputPlaceholder) {
+			return &pdfExtractor{
+				binary:  binary,
+				options: options,
+				args: func(options []string, input string, _ int) []string {
+					args := make([]string, len(options))
+					for i, o := range options {
+						args[i] = strings.ReplaceAll(o, inputPlaceholder, input)
+					}
+					return args
+				},
+			}, nil
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(binary), ".exe")
+	tmpl, ok := extractorTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown PDF extractor %q (use %s in its arguments to mark the PDF path)", name, inputPlaceholder)
+	}
+	// User-supplied options replace both the default and the layout options.
+	layout := options
+	if len(options) == 0 {
+		options, layout = tmpl.defaults, tmpl.layout
+	}
+	return &pdfExtractor{binary: binary, options: options, layout: layout, stdin: tmpl.stdin, args: tmpl.args}, nil
+}
+
+func mustParseExtractor(spec string) *pdfExtractor {
+	e, err := parseExtractor(spec)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// command returns the arguments to extract doc, and a temporary file to remove afterwards
+// when doc is only held in memory and the tool can't read stdin. Tools that can read stdin
+// are given "-" and should be fed doc.open().
+func (e *pdfExtractor) command(doc *document, layout bool) (args []string, tmpFile string, err error) {
+	input := doc.path
+	switch {
+	case e.stdin:
+		input = "-"
+	case input == "":
+		f, err := os.CreateTemp("", "asx_pdf_*.pdf")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		tmpFile = f.Name()
+		_, err = f.Write(doc.data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(tmpFile)
+			return nil, "", fmt.Errorf("failed to write PDF bytes to temp file: %w", err)
+		}
+		input = tmpFile
+	}
+	options := e.options
+	if layout && e.layout != nil {
+		options = e.layout
+	}
+	return e.args(options, input, maxPDFPages), tmpFile, nil
+}
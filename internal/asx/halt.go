@@ -0,0 +1,51 @@
+package asx
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// HaltLiftedKeyword is reported for the first announcement a company lodges after a trading
+// halt, which usually carries the news the halt was called for.
+const HaltLiftedKeyword = "trading halt lifted"
+
+// HaltTracker remembers trading halts across runs so the announcement that ends one can be flagged.
+type HaltTracker interface {
+	RecordHalt(ann types.Announcement)
+	// LiftHalt returns the open halt that ann is the first follow-up to, if any.
+	LiftHalt(ann types.Announcement) (halt types.Announcement, ok bool)
+}
+
+var haltTitle = regexp.MustCompile(`(?i)\b(trading halt|pause in trading|suspension from (official )?quotation|voluntary suspension)\b`)
+
+// IsTradingHalt reports whether an announcement title calls a trading halt or suspension.
+func IsTradingHalt(title string) bool {
+	return haltTitle.MatchString(title)
+}
+
+// pairHalts walks announcements in lodgement order, recording halts and returning the halt
+// each follow-up announcement lifts, keyed by announcement URL.
+func pairHalts(announcements []types.Announcement, tracker HaltTracker) map[string]types.Announcement {
+	if tracker == nil {
+		return nil
+	}
+
+	ordered := slices.Clone(announcements)
+	slices.SortStableFunc(ordered, func(a, b types.Announcement) int {
+		return a.DateTime.Compare(b.DateTime)
+	})
+
+	lifted := make(map[string]types.Announcement)
+	for _, ann := range ordered {
+		if IsTradingHalt(ann.Title) {
+			tracker.RecordHalt(ann)
+			continue
+		}
+		if halt, ok := tracker.LiftHalt(ann); ok {
+			lifted[ann.PDFURL] = halt
+		}
+	}
+	return lifted
+}
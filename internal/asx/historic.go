@@ -0,0 +1,73 @@
+package asx
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// maxHistoricChars is how much of each historic announcement's text is given to the AI.
+const maxHistoricChars = 4000
+
+// historicTextCache caches condensed historic announcement text by URL for the life of the
+// process, since a company's recent announcements are shared by all its matches.
+var historicTextCache sync.Map
+
+// historicDocuments downloads and condenses the text of up to n announcements. The
+// document cache is used as for any other download; failures are logged and skipped.
+func historicDocuments(announcements []types.Announcement, n int) []ai.HistoricDocument {
+	var docs []ai.HistoricDocument
+	for _, a := range announcements {
+		if len(docs) == n {
+			break
+		}
+		text, err := historicText(a.PDFURL)
+		if err != nil {
+			log.Printf("Warning: Failed to load historic announcement %s (%s): %v", a.Ticker, a.Title, err)
+			continue
+		}
+		docs = append(docs, ai.HistoricDocument{
+			Title: a.Title,
+			Date:  a.DateTime.Format("2006-01-02"),
+			URL:   a.PDFURL,
+			Text:  text,
+		})
+	}
+	return docs
+}
+
+func historicText(url string) (string, error) {
+	if text, ok := historicTextCache.Load(url); ok {
+		return text.(string), nil
+	}
+
+	doc, err := loadDocument(url)
+	if err != nil {
+		return "", err
+	}
+	defer doc.close()
+
+	text, _, err := loadDocumentText(url, doc, false)
+	if err != nil {
+		return "", err
+	}
+	text = condenseText(text, maxHistoricChars)
+	historicTextCache.Store(url, text)
+	return text, nil
+}
+
+// condenseText collapses whitespace and cuts text to at most limit bytes at a word boundary.
+func condenseText(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= limit {
+		return text
+	}
+	text = text[:limit]
+	if i := strings.LastIndexByte(text, ' '); i > 0 {
+		text = text[:i]
+	}
+	return text + " ..."
+}
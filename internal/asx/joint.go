@@ -0,0 +1,61 @@
+package asx
+
+import (
+	"slices"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// GroupJoint merges matches on the same document released under several tickers, such as
+// joint venture or scheme announcements, into one match listing every ticker. Documents are
+// the same when they share a URL or their extracted text is identical. The primary ticker is
+// the first one matched by ticker, otherwise the first in matches; keywords are combined.
+func GroupJoint(matches []types.AnnotatedMatch) []types.AnnotatedMatch {
+	var grouped []types.AnnotatedMatch
+	index := make(map[string]int) // URL or document hash -> position in grouped
+
+	for _, am := range matches {
+		i, ok := index[am.Match.PDFURL]
+		if !ok && am.Match.DocumentHash != "" {
+			i, ok = index[am.Match.DocumentHash]
+		}
+		if !ok {
+			index[am.Match.PDFURL] = len(grouped)
+			if am.Match.DocumentHash != "" {
+				index[am.Match.DocumentHash] = len(grouped)
+			}
+			grouped = append(grouped, am)
+			continue
+		}
+		index[am.Match.PDFURL] = i
+		grouped[i] = mergeJoint(grouped[i], am)
+	}
+	return grouped
+}
+
+// mergeJoint folds other into group.
+func mergeJoint(group, other types.AnnotatedMatch) types.AnnotatedMatch {
+	tickers := append([]string{group.Match.Ticker, other.Match.Ticker}, group.Match.JointTickers...)
+
+	primary, secondary := group, other
+	if other.Match.TickerMatched && !group.Match.TickerMatched {
+		primary, secondary = other, group
+	}
+	tickers = slices.DeleteFunc(tickers, func(t string) bool { return t == primary.Match.Ticker })
+	slices.Sort(tickers)
+	primary.Match.JointTickers = slices.Compact(tickers)
+	primary.Match.KeywordsFound = mergeKeywords(primary.Match.KeywordsFound, secondary.Match.KeywordsFound)
+	if primary.Analysis == nil {
+		primary.Analysis, primary.Model = secondary.Analysis, secondary.Model
+	}
+	return primary
+}
+
+func mergeKeywords(keywords, more []string) []string {
+	for _, kw := range more {
+		if !slices.Contains(keywords, kw) {
+			keywords = append(keywords, kw)
+		}
+	}
+	return keywords
+}
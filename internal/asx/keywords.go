@@ -0,0 +1,124 @@
+package asx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// proximityPattern matches `"offtake" NEAR/20 "binding"`. Keywords arrive lowercased.
+var proximityPattern = regexp.MustCompile(`^"([^"]+)"\s+near/(\d+)\s+"([^"]+)"$`)
+
+// minCountPattern matches "dividend:3", a keyword that must occur at least 3 times. The
+// term may not end in a digit, so that ratios such as "ratio 1:10" stay literal keywords.
+var minCountPattern = regexp.MustCompile(`^(.*\D):(\d+)$`)
+
+// parseMinCount splits a "keyword:N" threshold. Plain keywords need one occurrence.
+func parseMinCount(keyword string) (term string, minCount int) {
+	m := minCountPattern.FindStringSubmatch(keyword)
+	if m == nil {
+		return keyword, 1
+	}
+	n, _ := strconv.Atoi(m[2])
+	return strings.TrimSpace(m[1]), max(n, 1)
+}
+
+// proximity is a keyword expression that matches when two terms occur within a number of
+// words of each other.
+type proximity struct {
+	first, second []string
+	within        int
+}
+
+func parseProximity(keyword string) (proximity, bool) {
+	m := proximityPattern.FindStringSubmatch(strings.TrimSpace(keyword))
+	if m == nil {
+		return proximity{}, false
+	}
+	within, _ := strconv.Atoi(m[2])
+	p := proximity{first: wordsOf(m[1]), second: wordsOf(m[3]), within: within}
+	return p, len(p.first) > 0 && len(p.second) > 0
+}
+
+// matches reports whether both terms occur in words at most within words apart.
+func (p proximity) matches(words []string) bool {
+	firsts := phrasePositions(words, p.first)
+	if len(firsts) == 0 {
+		return false
+	}
+	for _, b := range phrasePositions(words, p.second) {
+		for _, a := range firsts {
+			if distance := b - a; distance <= p.within && distance >= -p.within {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wordsOf splits text into lowercase words, dropping punctuation.
+func wordsOf(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// phrasePositions returns the word offsets at which phrase starts.
+func phrasePositions(words, phrase []string) []int {
+	var positions []int
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		match := true
+		for j, w := range phrase {
+			if words[i+j] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// keywordPages returns the 1-based page each keyword first appears on in text whose pages are
+// separated by form feeds, as pdftotext writes them. Other text yields nil.
+func keywordPages(text string, keywords []string) map[string]int {
+	if !strings.Contains(text, "\f") || len(keywords) == 0 {
+		return nil
+	}
+
+	pages := strings.Split(strings.ToLower(text), "\f")
+	found := make(map[string]int)
+	for _, kw := range keywords {
+		term := snippetTerm(kw)
+		for i, page := range pages {
+			if strings.Contains(page, term) {
+				found[kw] = i + 1
+				break
+			}
+		}
+	}
+	return found
+}
+
+// snippetTerm returns the plain text to locate a keyword by when building context snippets.
+func snippetTerm(keyword string) string {
+	if p, ok := parseProximity(keyword); ok {
+		return strings.Join(p.first, " ")
+	}
+	term, _ := parseMinCount(keyword)
+	return term
+}
+
+// conceptPrefix marks keywords found by semantic matching rather than in the text.
+const conceptPrefix = "concept: "
+
+// maxConceptSnippet is how much of the closest passage is shown for a concept match.
+const maxConceptSnippet = 300
+
+// conceptKeyword is the keyword reported for a semantic concept query match.
+func conceptKeyword(query string) string {
+	return conceptPrefix + query
+}
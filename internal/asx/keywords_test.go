@@ -0,0 +1,96 @@
+package asx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProximity(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    proximity
+		ok      bool
+	}{
+		{`"offtake" near/20 "binding"`, proximity{first: []string{"offtake"}, second: []string{"binding"}, within: 20}, true},
+		{`  "takeover bid" near/5 "unconditional"  `, proximity{first: []string{"takeover", "bid"}, second: []string{"unconditional"}, within: 5}, true},
+		{`"offtake" near/0 "binding"`, proximity{first: []string{"offtake"}, second: []string{"binding"}, within: 0}, true},
+		{`"offtake"   near/3   "binding"`, proximity{first: []string{"offtake"}, second: []string{"binding"}, within: 3}, true},
+		{`"..." near/3 "binding"`, proximity{}, false},
+		{`offtake near/20 binding`, proximity{}, false},
+		{`"offtake" near "binding"`, proximity{}, false},
+		{`"offtake" near/-1 "binding"`, proximity{}, false},
+		{`"offtake"`, proximity{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseProximity(tt.keyword)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("parseProximity(%q) = %+v, %t; want %+v, %t", tt.keyword, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestProximityMatches(t *testing.T) {
+	const text = "The company has signed a binding term sheet for an offtake agreement with a major smelter."
+	tests := []struct {
+		keyword string
+		want    bool
+	}{
+		{`"offtake" near/5 "binding"`, true},
+		{`"offtake" near/4 "binding"`, false},
+		{`"binding" near/5 "offtake"`, true},
+		{`"term sheet" near/1 "binding"`, true},
+		{`"offtake agreement" near/4 "major smelter"`, true}, // measured between the phrases' first words
+		{`"offtake agreement" near/3 "major smelter"`, false},
+		{`"offtake" near/20 "royalty"`, false},
+		{`"smelter" near/0 "smelter"`, true},
+	}
+	words := wordsOf(text)
+	for _, tt := range tests {
+		p, ok := parseProximity(tt.keyword)
+		if !ok {
+			t.Fatalf("parseProximity(%q) failed", tt.keyword)
+		}
+		if got := p.matches(words); got != tt.want {
+			t.Errorf("%s matches = %t, want %t", tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestParseMinCount(t *testing.T) {
+	tests := []struct {
+		keyword  string
+		term     string
+		minCount int
+	}{
+		{"dividend", "dividend", 1},
+		{"dividend:3", "dividend", 3},
+		{"special dividend :2", "special dividend", 2},
+		{"dividend:0", "dividend", 1},
+		{"dividend:", "dividend:", 1},
+		{"dividend:x", "dividend:x", 1},
+		{"ratio 1:10", "ratio 1:10", 1},
+		{"consolidation 1 :4", "consolidation 1", 4},
+		{"a:b:4", "a:b", 4},
+	}
+	for _, tt := range tests {
+		term, minCount := parseMinCount(tt.keyword)
+		if term != tt.term || minCount != tt.minCount {
+			t.Errorf("parseMinCount(%q) = %q, %d; want %q, %d", tt.keyword, term, minCount, tt.term, tt.minCount)
+		}
+	}
+}
+
+func TestSnippetTerm(t *testing.T) {
+	tests := []struct {
+		keyword, want string
+	}{
+		{"lithium", "lithium"},
+		{"dividend:3", "dividend"},
+		{`"takeover bid" near/5 "unconditional"`, "takeover bid"},
+	}
+	for _, tt := range tests {
+		if got := snippetTerm(tt.keyword); got != tt.want {
+			t.Errorf("snippetTerm(%q) = %q, want %q", tt.keyword, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package asx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LayoutMode selects when PDFs are extracted with their physical layout preserved instead of
+// in reading order. Layout extraction keeps table rows on one line, which suits tabular
+// announcements but can interleave the columns of ordinary multi-column prose.
+type LayoutMode int
+
+const (
+	LayoutOff    LayoutMode = iota // reading order (pdftotext -raw)
+	LayoutTables                   // layout for likely tabular announcements, see tabularTitle
+	LayoutAlways                   // layout for every PDF
+)
+
+// ParseLayoutMode parses "off", "tables" or "always".
+func ParseLayoutMode(s string) (LayoutMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "off", "raw":
+		return LayoutOff, nil
+	case "tables", "auto":
+		return LayoutTables, nil
+	case "always", "on":
+		return LayoutAlways, nil
+	}
+	return LayoutOff, fmt.Errorf("unknown layout mode %q (expected off, tables or always)", s)
+}
+
+// tabularTitle matches announcements that are mostly tables: quarterly cash flow reports and
+// drilling, assay and resource results.
+var tabularTitle = regexp.MustCompile(`(?i)appendix\s*(4c|5b)\b|quarterly (activities|cash ?flow)|drill(ing)? results|assays?\b|intercepts?\b|resource (estimate|update)`)
+
+// appliesTo reports whether an announcement's PDF should be extracted with layout preserved.
+func (m LayoutMode) appliesTo(title string) bool {
+	switch m {
+	case LayoutAlways:
+		return true
+	case LayoutTables:
+		return tabularTitle.MatchString(title)
+	}
+	return false
+}
+
+var (
+	// columnGap separates cells in layout text, where columns are padded with spaces.
+	columnGap = regexp.MustCompile(`[ \t]{2,}`)
+	// numericCell matches table values such as "1,234", "(1,234)", "-", "12.5%" or "$0.05".
+	numericCell = regexp.MustCompile(`^(?:[(-]?\$?\d[\d,]*(?:\.\d+)?\)?(?:%|m|g/t)?|-|nil)$`)
+)
+
+// reconstructTables rewrites layout text so each table row reads as "cell | cell | cell".
+// A line is treated as a row when it splits into several cells and at least one of them is a
+// number; other lines are rejoined with single spaces. Form feeds are kept for page splitting.
+func reconstructTables(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, "\f")
+		pages := line[:len(line)-len(trimmed)]
+
+		cells := columnGap.Split(strings.Trim(trimmed, " \t\r"), -1)
+		sep := " "
+		if len(cells) > 1 && hasNumericCell(cells) {
+			sep = " | "
+		}
+		lines[i] = pages + strings.Join(cells, sep)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hasNumericCell(cells []string) bool {
+	for _, c := range cells {
+		if numericCell.MatchString(c) {
+			return true
+		}
+	}
+	return false
+}
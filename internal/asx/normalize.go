@@ -0,0 +1,31 @@
+package asx
+
+import (
+	"regexp"
+	"strings"
+)
+
+// compatibilityReplacer maps the typographic characters PDFs commonly emit to the plain
+// forms users type as keywords.
+var compatibilityReplacer = strings.NewReplacer(
+	// Ligatures
+	"ﬀ", "ff", "ﬁ", "fi", "ﬂ", "fl", "ﬃ", "ffi", "ﬄ", "ffl", "ﬅ", "st", "ﬆ", "st",
+	// Non-breaking and other fixed-width spaces
+	"\u00a0", " ", "\u2002", " ", "\u2003", " ", "\u2007", " ", "\u2009", " ", "\u202f", " ", "\u3000", " ",
+	// Invisible characters and soft hyphens
+	"\u00ad", "", "\u200b", "", "\u200c", "", "\u200d", "", "\ufeff", "",
+	// Quotes and dashes
+	"‘", "'", "’", "'", "“", `"`, "”", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-", "−", "-",
+)
+
+// hyphenatedBreak matches a word split across lines, e.g. "divi-\ndend".
+var hyphenatedBreak = regexp.MustCompile(`(\pL)-[ \t]*\r?\n[ \t]*(\p{Ll})`)
+
+// normalizeText prepares extracted text for keyword matching: compatibility characters are
+// mapped to their plain forms and words hyphenated across line breaks are rejoined.
+// It is idempotent, so already normalised cached text can safely pass through again.
+func normalizeText(text string) string {
+	text = compatibilityReplacer.Replace(text)
+	return hyphenatedBreak.ReplaceAllString(text, "$1$2")
+}
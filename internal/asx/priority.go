@@ -0,0 +1,67 @@
+package asx
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// prioritise orders announcements so the most important are downloaded first on busy
+// mornings: halt follow-ups, then the configured tickers, then price-sensitive
+// announcements, then the rest. Each group keeps its feed order.
+func prioritise(announcements []types.Announcement, lifted map[string]types.Announcement, tickers []string) []types.Announcement {
+	rank := func(ann types.Announcement) [3]int {
+		_, lifts := lifted[ann.PDFURL]
+		return [3]int{boolRank(lifts), boolRank(slices.Contains(tickers, ann.Ticker)), boolRank(ann.IsPriceSensitive)}
+	}
+
+	ordered := slices.Clone(announcements)
+	slices.SortStableFunc(ordered, func(a, b types.Announcement) int {
+		ra, rb := rank(a), rank(b)
+		return cmp.Or(cmp.Compare(rb[0], ra[0]), cmp.Compare(rb[1], ra[1]), cmp.Compare(rb[2], ra[2]))
+	})
+	return ordered
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// urgent reports whether a match's AI analysis should jump the queue: halt follow-ups,
+// configured tickers and price-sensitive announcements.
+func (j aiJob) urgent() bool {
+	m := j.match
+	return m.FollowsHalt != nil || m.TickerMatched || m.IsPriceSensitive
+}
+
+// nextAIJob takes the next job for an AI worker, preferring urgent ones. It returns false
+// once both queues are closed and drained.
+func nextAIJob(urgent, bulk <-chan aiJob) (aiJob, bool) {
+	select {
+	case job, ok := <-urgent:
+		if ok {
+			return job, true
+		}
+	default:
+	}
+
+	for urgent != nil || bulk != nil {
+		select {
+		case job, ok := <-urgent:
+			if ok {
+				return job, true
+			}
+			urgent = nil
+		case job, ok := <-bulk:
+			if ok {
+				return job, true
+			}
+			bulk = nil
+		}
+	}
+	return aiJob{}, false
+}
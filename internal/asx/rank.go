@@ -0,0 +1,109 @@
+package asx
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/shanehull/annscraper/internal/geo"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// FilterByScore drops matches whose AI relevance score is below minScore.
+// Matches without an analysis are kept since they could not be scored.
+func FilterByScore(matches []types.AnnotatedMatch, minScore int) []types.AnnotatedMatch {
+	if minScore <= 0 {
+		return matches
+	}
+
+	var kept []types.AnnotatedMatch
+	for _, am := range matches {
+		if am.Analysis == nil || am.Analysis.RelevanceScore >= minScore {
+			kept = append(kept, am)
+		}
+	}
+	return kept
+}
+
+// FilterByThesis drops matches the AI judged not to fit the investment thesis.
+// Matches without a thesis judgement are kept.
+func FilterByThesis(matches []types.AnnotatedMatch) []types.AnnotatedMatch {
+	var kept []types.AnnotatedMatch
+	for _, am := range matches {
+		if am.Analysis == nil || am.Analysis.ThesisFit == nil || am.Analysis.ThesisFit.Fits {
+			kept = append(kept, am)
+		}
+	}
+	return kept
+}
+
+// FilterByGeo keeps matches whose location and commodity tags satisfy filter.
+func FilterByGeo(matches []types.AnnotatedMatch, filter geo.Filter) []types.AnnotatedMatch {
+	if !filter.Active() {
+		return matches
+	}
+
+	var kept []types.AnnotatedMatch
+	for _, am := range matches {
+		if filter.Allows(am.Match.Geo) {
+			kept = append(kept, am)
+		}
+	}
+	return kept
+}
+
+// Report orders accepted by SortMatches.
+const (
+	SortScore  = "score"
+	SortTime   = "time"
+	SortTicker = "ticker"
+)
+
+// Weights of the composite match score.
+const (
+	unscoredRelevance    = 40 // stands in for the AI relevance score of unanalysed matches
+	priceSensitiveWeight = 20
+	watchedWeight        = 15
+	keywordWeight        = 5 // per distinct keyword found, up to maxKeywordWeight
+	maxKeywordWeight     = 15
+)
+
+// MatchScore combines a match's AI relevance, price sensitivity, whether its ticker is watched
+// and how many keywords it found into one score for ranking reports.
+func MatchScore(am types.AnnotatedMatch) int {
+	score := unscoredRelevance
+	if am.Analysis != nil {
+		score = am.Analysis.RelevanceScore
+	}
+	if am.Match.IsPriceSensitive {
+		score += priceSensitiveWeight
+	}
+	if am.Match.TickerMatched {
+		score += watchedWeight
+	}
+	return score + min(len(am.Match.KeywordsFound)*keywordWeight, maxKeywordWeight)
+}
+
+// SortMatches orders matches by MatchScore (highest first), release time (newest first) or
+// ticker, and returns an error for any other order.
+func SortMatches(matches []types.AnnotatedMatch, order string) error {
+	var compare func(a, b types.AnnotatedMatch) int
+	switch order {
+	case SortScore:
+		compare = func(a, b types.AnnotatedMatch) int {
+			return cmp.Compare(MatchScore(b), MatchScore(a))
+		}
+	case SortTime:
+		compare = func(a, b types.AnnotatedMatch) int {
+			return b.Match.DateTime.Compare(a.Match.DateTime)
+		}
+	case SortTicker:
+		compare = func(a, b types.AnnotatedMatch) int {
+			return cmp.Or(cmp.Compare(a.Match.Tickers(), b.Match.Tickers()), b.Match.DateTime.Compare(a.Match.DateTime))
+		}
+	default:
+		return fmt.Errorf("unknown order %q (expected '%s', '%s' or '%s')", order, SortScore, SortTime, SortTicker)
+	}
+	slices.SortStableFunc(matches, compare)
+	return nil
+}
@@ -0,0 +1,88 @@
+package asx
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+func rankedMatch(ticker string, minute int, score *int, priceSensitive, watched bool, keywords ...string) types.AnnotatedMatch {
+	am := types.AnnotatedMatch{Match: types.Match{
+		Announcement: types.Announcement{
+			Ticker:           ticker,
+			DateTime:         time.Date(2026, 10, 16, 10, minute, 0, 0, time.UTC),
+			IsPriceSensitive: priceSensitive,
+		},
+		TickerMatched: watched,
+		KeywordsFound: keywords,
+	}}
+	if score != nil {
+		am.Analysis = &ai.AIAnalysis{RelevanceScore: *score}
+	}
+	return am
+}
+
+func TestMatchScore(t *testing.T) {
+	score := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		am   types.AnnotatedMatch
+		want int
+	}{
+		{"unanalysed", rankedMatch("AAA", 0, nil, false, false), unscoredRelevance},
+		{"analysed", rankedMatch("AAA", 0, score(70), false, false), 70},
+		{"analysed as irrelevant", rankedMatch("AAA", 0, score(0), false, false), 0},
+		{"price sensitive", rankedMatch("AAA", 0, score(70), true, false), 90},
+		{"watched", rankedMatch("AAA", 0, score(70), false, true), 85},
+		{"one keyword", rankedMatch("AAA", 0, score(70), false, false, "lithium"), 75},
+		{"keywords capped", rankedMatch("AAA", 0, score(70), false, false, "a", "b", "c", "d", "e"), 70 + maxKeywordWeight},
+		{"everything", rankedMatch("AAA", 0, score(100), true, true, "a", "b", "c"), 100 + 20 + 15 + 15},
+	}
+	for _, tt := range tests {
+		if got := MatchScore(tt.am); got != tt.want {
+			t.Errorf("%s: MatchScore() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortMatches(t *testing.T) {
+	score := func(n int) *int { return &n }
+	matches := []types.AnnotatedMatch{
+		rankedMatch("CCC", 5, score(50), false, false),                // 50
+		rankedMatch("AAA", 1, score(60), true, false),                 // 80
+		rankedMatch("BBB", 9, nil, false, false, "lithium"),           // 45
+		rankedMatch("AAA", 7, score(30), false, true, "gold", "lith"), // 55
+		rankedMatch("DDD", 3, score(50), false, false),                // 50, ties with CCC
+	}
+	tests := []struct {
+		order string
+		want  []string // ticker@minute
+	}{
+		{SortScore, []string{"AAA@1", "AAA@7", "CCC@5", "DDD@3", "BBB@9"}},
+		{SortTime, []string{"BBB@9", "AAA@7", "CCC@5", "DDD@3", "AAA@1"}},
+		{SortTicker, []string{"AAA@7", "AAA@1", "BBB@9", "CCC@5", "DDD@3"}},
+	}
+	for _, tt := range tests {
+		sorted := slices.Clone(matches)
+		if err := SortMatches(sorted, tt.order); err != nil {
+			t.Fatalf("SortMatches(%q) error = %v", tt.order, err)
+		}
+		var got []string
+		for _, am := range sorted {
+			got = append(got, am.Match.Ticker+"@"+am.Match.DateTime.Format("4"))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SortMatches(%q) = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+
+	if err := SortMatches(slices.Clone(matches), "relevance"); err == nil {
+		t.Error("SortMatches(\"relevance\") succeeded, want an error")
+	}
+	if err := SortMatches(nil, SortScore); err != nil {
+		t.Errorf("SortMatches(nil) error = %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package asx
+
+import "strings"
+
+const (
+	defaultSnippetWindow = 50
+	maxSentenceWindows   = 6 // sentence mode looks at most this many windows either side for a boundary
+)
+
+// SnippetOptions controls the context shown around a keyword match.
+type SnippetOptions struct {
+	Window    int  // characters either side of the keyword, 0 = 50
+	Sentences bool // expand to the surrounding sentence where one can be found
+}
+
+// sentenceBounds widens [start, end) to the enclosing sentence, looking at most limit bytes
+// either way. ok is false on each side where no boundary was found.
+func sentenceBounds(text string, start, end, limit int) (from, to int, startOK, endOK bool) {
+	from, to = start, end
+
+	for i := start - 1; i >= max(start-limit, 0); i-- {
+		if i == 0 {
+			from, startOK = 0, true
+			break
+		}
+		if isSentenceEnd(text, i-1) || strings.HasPrefix(text[i-1:], "\n\n") {
+			from, startOK = i, true
+			break
+		}
+	}
+
+	for j := end; j < min(end+limit, len(text)); j++ {
+		if isSentenceEnd(text, j) {
+			to, endOK = j+1, true
+			break
+		}
+	}
+	if end+limit >= len(text) && !endOK {
+		to, endOK = len(text), true
+	}
+
+	return from, to, startOK, endOK
+}
+
+// isSentenceEnd reports whether text[i] is '.', '!' or '?' followed by whitespace or the end.
+func isSentenceEnd(text string, i int) bool {
+	switch text[i] {
+	case '.', '!', '?':
+		return i+1 == len(text) || strings.ContainsRune(" \t\r\n", rune(text[i+1]))
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package asx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// Announcement sources for SetSources.
+const (
+	SourceMarkit = "markit" // the Markit Digital market feed used by asx.com.au
+	SourceASX    = "asx"    // the ASX company API, one request per ticker
+)
+
+// DefaultSources is the market feed with the ASX company API as a fallback.
+var DefaultSources = []string{SourceMarkit, SourceASX}
+
+// sources are tried in order until one returns announcements.
+var sources = DefaultSources
+
+// errNoTickers is returned by per-company sources when there are no tickers to fetch, so
+// they are skipped rather than reported as failed.
+var errNoTickers = errors.New("no tickers to fetch")
+
+// SetSources sets the announcement sources to try, in order, e.g. "markit,asx". Per-company
+// sources only cover FetchParams.Ticker or FetchParams.FallbackTickers.
+func SetSources(list []string) error {
+	if len(list) == 0 {
+		sources = DefaultSources
+		return nil
+	}
+	for i, s := range list {
+		s = strings.ToLower(strings.TrimSpace(s))
+		switch s {
+		case SourceMarkit, SourceASX:
+		default:
+			return fmt.Errorf("unknown announcement source %q (expected %s or %s)", s, SourceMarkit, SourceASX)
+		}
+		list[i] = s
+	}
+	sources = list
+	return nil
+}
+
+// fetchFromSource fetches announcements from one source.
+func fetchFromSource(source string, params FetchParams) ([]types.Announcement, FetchStats, error) {
+	switch source {
+	case SourceMarkit:
+		announcements, stats, err := fetchMarkitAnnouncements(params)
+		if err != nil {
+			return nil, stats, fmt.Errorf("markit feed: %w", err)
+		}
+		return announcements, stats, nil
+
+	case SourceASX:
+		tickers := params.FallbackTickers
+		if params.Ticker != "" {
+			tickers = []string{params.Ticker}
+		}
+		if len(tickers) == 0 {
+			return nil, FetchStats{}, errNoTickers
+		}
+		announcements, err := fetchASXAnnouncements(tickers, params)
+		if err != nil {
+			return nil, FetchStats{}, fmt.Errorf("ASX company API: %w", err)
+		}
+		return announcements, FetchStats{Received: len(announcements), Parsed: len(announcements)}, nil
+	}
+	return nil, FetchStats{}, fmt.Errorf("unknown announcement source %q", source)
+}
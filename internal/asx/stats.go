@@ -0,0 +1,55 @@
+package asx
+
+import "sync"
+
+// Reasons an announcement was skipped or failed, as counted in ProcessStats.Failures.
+const (
+	FailureUnsupported    = "unsupported document"
+	FailureBandwidth      = "bandwidth cap"
+	FailureSize           = "size cap"
+	FailureSourceDegraded = "source degraded"
+	FailureProcessing     = "processing error"
+)
+
+// ProcessStats counts what ProcessAnnouncements did, for the run summary.
+type ProcessStats struct {
+	mutex      sync.Mutex
+	Processed  int            // announcements considered, including those that failed
+	Downloaded int            // documents fetched from the network
+	Cached     int            // documents served from the document cache
+	Failures   map[string]int // announcements skipped or failed, by reason
+}
+
+func (s *ProcessStats) countProcessed() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Processed++
+}
+
+func (s *ProcessStats) countDocument(cached bool) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if cached {
+		s.Cached++
+	} else {
+		s.Downloaded++
+	}
+}
+
+func (s *ProcessStats) countFailure(reason string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.Failures == nil {
+		s.Failures = make(map[string]int)
+	}
+	s.Failures[reason]++
+}
@@ -0,0 +1,105 @@
+package asx
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// The ASX serves an "agree to terms" page instead of some announcement PDFs until the terms
+// are accepted. Acceptance is recorded in a cookie, so the client keeps a cookie jar and the
+// form is posted once per run rather than once per announcement.
+var (
+	termsFormPattern   = regexp.MustCompile(`(?is)<form[^>]*action="([^"]*announcementTerms[^"]*)"[^>]*>(.*?)</form>`)
+	hiddenInputPattern = regexp.MustCompile(`(?is)<input[^>]*type="hidden"[^>]*>`)
+	nameAttrPattern    = regexp.MustCompile(`(?i)\bname="([^"]*)"`)
+	valueAttrPattern   = regexp.MustCompile(`(?i)\bvalue="([^"]*)"`)
+)
+
+// newCookieJar returns the jar holding the accepted-terms session for the run.
+func newCookieJar() http.CookieJar {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		panic(err) // only fails for invalid options
+	}
+	return jar
+}
+
+// termsForm is the acceptance form found on a terms page.
+type termsForm struct {
+	action string
+	values url.Values
+}
+
+// parseTermsForm returns the acceptance form if page is the ASX terms page.
+func parseTermsForm(page []byte, pageURL string) (*termsForm, bool) {
+	m := termsFormPattern.FindSubmatch(page)
+	if m == nil {
+		return nil, false
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, false
+	}
+	action, err := base.Parse(html.UnescapeString(string(m[1])))
+	if err != nil {
+		return nil, false
+	}
+
+	values := url.Values{}
+	for _, input := range hiddenInputPattern.FindAll(m[2], -1) {
+		name := nameAttrPattern.FindSubmatch(input)
+		if name == nil {
+			continue
+		}
+		var value string
+		if v := valueAttrPattern.FindSubmatch(input); v != nil {
+			value = html.UnescapeString(string(v[1]))
+		}
+		values.Add(html.UnescapeString(string(name[1])), value)
+	}
+	return &termsForm{action: action.String(), values: values}, true
+}
+
+// termsSession tracks when the terms were last accepted so concurrent downloads that hit the
+// terms page together only post the form once.
+type termsSession struct {
+	mutex      sync.Mutex
+	acceptedAt time.Time
+}
+
+var terms = &termsSession{}
+
+// accept posts form unless the terms were accepted after since, when the request that saw the
+// terms page started.
+func (s *termsSession) accept(form *termsForm, since time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.acceptedAt.After(since) {
+		return nil
+	}
+
+	resp, err := client.PostForm(form.action, form.values)
+	if err != nil {
+		return fmt.Errorf("failed to accept ASX terms: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to accept ASX terms: received status code %d", resp.StatusCode)
+	}
+
+	log.Printf("Accepted ASX announcement terms for this session.")
+	s.acceptedAt = time.Now()
+	return nil
+}
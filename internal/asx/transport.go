@@ -0,0 +1,55 @@
+package asx
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// headerTransport sets the User-Agent of every request made by the package client.
+type headerTransport struct {
+	base      http.RoundTripper
+	userAgent string // "" = Go's default
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.userAgent != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// transport is the base of the package client's transport chain. Its proxy defaults to the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+var transport = &headerTransport{base: http.DefaultTransport.(*http.Transport).Clone()}
+
+// SetProxy routes all requests through an http://, https:// or socks5:// proxy, overriding the
+// proxy environment variables. An empty proxyURL keeps the environment's proxy.
+func SetProxy(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (expected http, https or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: missing host", proxyURL)
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = http.ProxyURL(u)
+	transport.base = base
+	return nil
+}
+
+// SetUserAgent sets the User-Agent sent with every request. "" = Go's default.
+func SetUserAgent(userAgent string) {
+	transport.userAgent = userAgent
+}
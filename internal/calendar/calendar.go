@@ -0,0 +1,93 @@
+// Package calendar knows which days the ASX holds a trading session: weekdays other than
+// the market holidays in holidays.txt, adjusted by an optional override file.
+package calendar
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+//go:embed holidays.txt
+var holidays string
+
+// maxLookback bounds the search for a previous session, well beyond any run of closures.
+const maxLookback = 14
+
+// Calendar is the ASX trading calendar.
+type Calendar struct {
+	closed map[string]bool // YYYY-MM-DD -> closed (true) or open despite the defaults (false)
+}
+
+// Load returns the built-in calendar with the override file at path applied, if path is
+// not empty. Each line of the file is a date, closing the market that day, or a date
+// followed by "open" to undo a built-in holiday; '#' starts a comment:
+//
+//	2028-01-03        # New Year's Day (observed)
+//	2027-12-28 open
+func Load(path string) (*Calendar, error) {
+	c := &Calendar{closed: make(map[string]bool)}
+	if err := c.read(strings.NewReader(holidays), "built-in holidays"); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := c.read(f, path); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Calendar) read(r io.Reader, name string) error {
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", fields[0]); err != nil {
+			return fmt.Errorf("%s:%d: invalid date %q (expected YYYY-MM-DD)", name, lineNo, fields[0])
+		}
+
+		switch {
+		case len(fields) == 1:
+			c.closed[fields[0]] = true
+		case len(fields) == 2 && strings.EqualFold(fields[1], "open"):
+			c.closed[fields[0]] = false
+		default:
+			return fmt.Errorf("%s:%d: expected a date, optionally followed by 'open'", name, lineNo)
+		}
+	}
+	return scanner.Err()
+}
+
+// IsTradingDay reports whether the market holds a session on t's date.
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	if closed, ok := c.closed[t.Format("2006-01-02")]; ok {
+		return !closed
+	}
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// PreviousTradingDay returns the latest trading day before t's date, at the same clock time.
+func (c *Calendar) PreviousTradingDay(t time.Time) time.Time {
+	for range maxLookback {
+		t = t.AddDate(0, 0, -1)
+		if c.IsTradingDay(t) {
+			return t
+		}
+	}
+	return t
+}
@@ -0,0 +1,82 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestIsTradingDay(t *testing.T) {
+	override := filepath.Join(t.TempDir(), "holidays.txt")
+	if err := os.WriteFile(override, []byte("2026-10-16  # closed for an outage\n2026-12-28 open\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	builtIn, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overridden, err := Load(override)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		day                   string
+		builtIn, withOverride bool
+	}{
+		{"2026-10-15", true, true},   // Thursday
+		{"2026-10-17", false, false}, // Saturday
+		{"2026-10-18", false, false}, // Sunday
+		{"2026-10-16", true, false},  // closed by the override
+		{"2026-12-25", false, false}, // Christmas Day
+		{"2026-12-28", false, true},  // Boxing Day (observed), reopened by the override
+		{"2026-01-26", false, false}, // Australia Day
+		{"2027-03-26", false, false}, // Good Friday
+	}
+	for _, tt := range tests {
+		if got := builtIn.IsTradingDay(date(tt.day)); got != tt.builtIn {
+			t.Errorf("IsTradingDay(%s) = %t, want %t", tt.day, got, tt.builtIn)
+		}
+		if got := overridden.IsTradingDay(date(tt.day)); got != tt.withOverride {
+			t.Errorf("with override, IsTradingDay(%s) = %t, want %t", tt.day, got, tt.withOverride)
+		}
+	}
+}
+
+func TestPreviousTradingDay(t *testing.T) {
+	c, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		day, want string
+	}{
+		{"2026-10-16", "2026-10-15"},
+		{"2026-10-19", "2026-10-16"}, // Monday
+		{"2026-10-18", "2026-10-16"}, // Sunday
+		{"2026-12-29", "2026-12-24"}, // after Christmas and Boxing Day
+	}
+	for _, tt := range tests {
+		if got := c.PreviousTradingDay(date(tt.day)).Format("2006-01-02"); got != tt.want {
+			t.Errorf("PreviousTradingDay(%s) = %s, want %s", tt.day, got, tt.want)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, content := range []string{"2026-02-30\n", "16/10/2026\n", "2026-10-16 closed\n", "2026-10-16 open now\n"} {
+		path := filepath.Join(t.TempDir(), "holidays.txt")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%q) succeeded, want an error", content)
+		}
+	}
+}
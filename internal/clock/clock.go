@@ -0,0 +1,48 @@
+/*
+Package clock abstracts the current time so date-dependent logic (report dates, previous
+business day, history rollover) can run against a chosen date deterministically.
+*/
+package clock
+
+import (
+	"fmt"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the real wall clock.
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed always returns the same instant.
+type Fixed struct {
+	T time.Time
+}
+
+func (c Fixed) Now() time.Time {
+	return c.T
+}
+
+// AsOf returns a fixed clock set to midday on the given YYYY-MM-DD date in loc. Midday keeps
+// the date stable across daylight saving transitions and UTC conversions.
+func AsOf(date string, loc *time.Location) (Fixed, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return Fixed{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD): %w", date, err)
+	}
+	return Fixed{T: day.Add(12 * time.Hour)}, nil
+}
+
+// OrSystem returns c, or the system clock if c is nil.
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System{}
+	}
+	return c
+}
@@ -0,0 +1,295 @@
+/*
+Package errreport sends failures to Sentry or a generic JSON webhook so operators of
+always-on deployments can see breakage, such as ASX page changes, without watching logs.
+
+Sentry events are posted to the project's envelope endpoint derived from the DSN, so no
+SDK is needed.
+*/
+package errreport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	sendTimeout      = 10 * time.Second
+	flushTimeout     = 15 * time.Second
+	maxEventsPerHour = 100 // further events are dropped so an outage doesn't flood the project
+)
+
+// Config selects where errors are reported. Both destinations may be set.
+type Config struct {
+	SentryDSN  string // https://PUBLIC_KEY@HOST/PROJECT_ID
+	WebhookURL string // receives each Event as JSON
+}
+
+// Event is a reported failure.
+type Event struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Kind      string            `json:"kind"` // what failed, e.g. "scan" or "announcement"
+	Message   string            `json:"message"`
+	ErrorType string            `json:"error_type"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Stack     string            `json:"stack,omitempty"`
+}
+
+// Reporter sends events in the background. A nil Reporter discards them.
+type Reporter struct {
+	sentry     *sentryDSN
+	webhookURL string
+	client     *http.Client
+	hostname   string
+
+	wg          sync.WaitGroup
+	mutex       sync.Mutex
+	windowStart time.Time
+	sent        int // events sent since windowStart
+}
+
+// New returns a Reporter for cfg, or nil when no destination is configured.
+func New(cfg Config) (*Reporter, error) {
+	if cfg.SentryDSN == "" && cfg.WebhookURL == "" {
+		return nil, nil
+	}
+
+	r := &Reporter{
+		webhookURL: cfg.WebhookURL,
+		client:     &http.Client{Timeout: sendTimeout},
+	}
+	r.hostname, _ = os.Hostname()
+	if cfg.SentryDSN != "" {
+		dsn, err := parseDSN(cfg.SentryDSN)
+		if err != nil {
+			return nil, err
+		}
+		r.sentry = dsn
+	}
+	return r, nil
+}
+
+// Capture reports err with optional tags.
+func (r *Reporter) Capture(kind string, err error, tags map[string]string) {
+	if r == nil || err == nil {
+		return
+	}
+	r.send(Event{
+		Kind:      kind,
+		Message:   err.Error(),
+		ErrorType: rootType(err),
+		Tags:      tags,
+	})
+}
+
+// rootType returns the type of the innermost wrapped error, which says more about the
+// failure than the *fmt.wrapError around it.
+func rootType(err error) string {
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
+
+// ReportAnnouncementError reports a failure to process an announcement, tagged with its
+// ticker and document URL.
+func (r *Reporter) ReportAnnouncementError(ann types.Announcement, err error) {
+	r.Capture("announcement", err, map[string]string{
+		"ticker": ann.Ticker,
+		"title":  ann.Title,
+		"url":    ann.PDFURL,
+	})
+}
+
+// Recover reports a panic and re-panics. It must be deferred directly.
+func (r *Reporter) Recover() {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if r != nil {
+		r.send(Event{
+			Kind:      "panic",
+			Message:   fmt.Sprint(p),
+			ErrorType: "panic",
+			Stack:     string(debug.Stack()),
+		})
+		r.Flush()
+	}
+	panic(p)
+}
+
+// Flush waits for pending events to be sent.
+func (r *Reporter) Flush() {
+	if r == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(flushTimeout):
+		log.Printf("Warning: timed out sending error reports")
+	}
+}
+
+func (r *Reporter) send(e Event) {
+	now := time.Now()
+	r.mutex.Lock()
+	if now.Sub(r.windowStart) > time.Hour {
+		r.windowStart, r.sent = now, 0
+	}
+	r.sent++
+	over := r.sent > maxEventsPerHour
+	r.mutex.Unlock()
+	if over {
+		return
+	}
+
+	e.ID = newEventID()
+	e.Time = now.UTC()
+
+	r.wg.Go(func() {
+		if r.sentry != nil {
+			if err := r.sendSentry(e); err != nil {
+				log.Printf("Warning: failed to report error to Sentry: %v", err)
+			}
+		}
+		if r.webhookURL != "" {
+			if err := r.sendWebhook(e); err != nil {
+				log.Printf("Warning: failed to report error to webhook: %v", err)
+			}
+		}
+	})
+}
+
+func (r *Reporter) sendWebhook(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return r.post(r.webhookURL, "application/json", nil, body)
+}
+
+// sentryDSN is a parsed Sentry DSN.
+type sentryDSN struct {
+	raw       string
+	publicKey string
+	envelope  string // the project's envelope endpoint
+}
+
+func parseDSN(raw string) (*sentryDSN, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN %q (expected https://KEY@HOST/PROJECT)", raw)
+	}
+	prefix, project := "", strings.Trim(u.Path, "/")
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	if project == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN %q: missing project ID", raw)
+	}
+	return &sentryDSN{
+		raw:       raw,
+		publicKey: u.User.Username(),
+		envelope:  fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+	}, nil
+}
+
+func (r *Reporter) sendSentry(e Event) error {
+	tags := map[string]string{"kind": e.Kind}
+	maps.Copy(tags, e.Tags)
+
+	event := map[string]any{
+		"event_id":    e.ID,
+		"timestamp":   e.Time.Format(time.RFC3339),
+		"platform":    "go",
+		"level":       "error",
+		"logger":      "annscraper",
+		"server_name": r.hostname,
+		"tags":        tags,
+		"exception": map[string]any{
+			"values": []map[string]any{{"type": e.ErrorType, "value": e.Message}},
+		},
+		// Group by what failed rather than the message, which usually contains a URL.
+		"fingerprint": []string{e.Kind, e.ErrorType},
+	}
+	if e.Stack != "" {
+		event["extra"] = map[string]string{"stack": e.Stack}
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(map[string]string{"event_id": e.ID, "dsn": r.sentry.raw})
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+
+	var envelope bytes.Buffer
+	envelope.Write(header)
+	envelope.WriteByte('\n')
+	envelope.Write(item)
+	envelope.WriteByte('\n')
+	envelope.Write(payload)
+	envelope.WriteByte('\n')
+
+	auth := map[string]string{
+		"X-Sentry-Auth": "Sentry sentry_version=7, sentry_client=annscraper/1.0, sentry_key=" + r.sentry.publicKey,
+	}
+	return r.post(r.sentry.envelope, "application/x-sentry-envelope", auth, envelope.Bytes())
+}
+
+func (r *Reporter) post(target, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// newEventID returns a random 32 character hex ID as Sentry expects.
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,148 @@
+package forms
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DirectorInterest is the change reported in an Appendix 3Y (Change of Director's Interest Notice).
+type DirectorInterest struct {
+	Director      string
+	DateOfChange  string
+	Interest      string // "Direct", "Indirect" or both
+	Class         string
+	Acquired      int64
+	Disposed      int64
+	Consideration float64 // total value in AUD, 0 if not stated or non-cash
+	ValueText     string  // the consideration as written on the form
+	HeldAfter     string
+	Nature        string // e.g. "On-market trade"
+}
+
+var (
+	appendix3YTitle = regexp.MustCompile(`(?i)appendix\s*3y|change of director'?s'? interest`)
+
+	// perSecurityValue matches a consideration stated only per security ("$0.05 per share").
+	perSecurityValue = regexp.MustCompile(`(?i)^\$\s?[\d.,]+\s*(?:per|each|/)`)
+	totalValue       = regexp.MustCompile(`(?i)total|aggregate`)
+
+	appendix3YFields = func() []*field {
+		f := func(name, label, skip string) *field {
+			fd := &field{name: name, label: regexp.MustCompile(`(?i)` + label)}
+			if skip != "" {
+				fd.skip = regexp.MustCompile(`(?i)` + skip)
+			}
+			return fd
+		}
+		return []*field{
+			f("director", `name of director`, ""),
+			f("lastNotice", `date of last notice`, ""),
+			f("interest", `direct or indirect interest`, ""),
+			f("indirect", `nature of indirect interest`, `^.{0,300}?giving rise to the relevant interest\.?`),
+			f("date", `date of change`, ""),
+			f("prior", `no\.? of securities held prior to change`, ""),
+			f("class", `\bclass\b`, ""),
+			f("acquired", `number acquired`, ""),
+			f("disposed", `number disposed`, ""),
+			f("value", `value\s*/\s*consideration`, `^\s*note: if consideration is non-cash, provide details and (?:an )?estimated valuation`),
+			f("after", `no\.? of securities held after change`, ""),
+			f("nature", `nature of change`, `^\s*example:.{0,300}?(?:buy-back|buy back)`),
+			f("part2", `part 2\b`, ""),
+		}
+	}()
+)
+
+// IsAppendix3Y reports whether an announcement looks like an Appendix 3Y.
+func IsAppendix3Y(title, text string) bool {
+	if appendix3YTitle.MatchString(title) {
+		return true
+	}
+	head := text
+	if len(head) > 2000 {
+		head = head[:2000]
+	}
+	return strings.Contains(strings.ToLower(head), "appendix 3y")
+}
+
+// ParseAppendix3Y extracts the Part 1 change from an Appendix 3Y's text.
+func ParseAppendix3Y(text string) (*DirectorInterest, error) {
+	values := extractFields(normalise(text), appendix3YFields)
+
+	d := &DirectorInterest{
+		Director:     values["director"],
+		DateOfChange: values["date"],
+		Interest:     values["interest"],
+		Class:        values["class"],
+		Acquired:     parseCount(values["acquired"]),
+		Disposed:     parseCount(values["disposed"]),
+		ValueText:    values["value"],
+		HeldAfter:    values["after"],
+		Nature:       values["nature"],
+	}
+	d.Consideration, _ = parseDollars(d.ValueText)
+	if perSecurityValue.MatchString(d.ValueText) && !totalValue.MatchString(d.ValueText) {
+		d.Consideration *= float64(d.Acquired + d.Disposed)
+	}
+
+	if d.Director == "" || (d.Acquired == 0 && d.Disposed == 0 && d.Nature == "") {
+		return nil, errors.New("appendix 3Y fields not found")
+	}
+	return d, nil
+}
+
+// Direction describes the change: "Buy", "Sell", "Buy & sell" or "Change" (e.g. options lapsing).
+func (d *DirectorInterest) Direction() string {
+	switch {
+	case d.Acquired > 0 && d.Disposed > 0:
+		return "Buy & sell"
+	case d.Acquired > 0:
+		return "Buy"
+	case d.Disposed > 0:
+		return "Sell"
+	default:
+		return "Change"
+	}
+}
+
+// PricePerSecurity returns the implied price, or 0 if it can't be derived.
+func (d *DirectorInterest) PricePerSecurity() float64 {
+	n := d.Acquired + d.Disposed
+	if d.Consideration == 0 || n == 0 || (d.Acquired > 0 && d.Disposed > 0) {
+		return 0
+	}
+	return d.Consideration / float64(n)
+}
+
+// Summary renders a one-line description, e.g.
+// "Jane Citizen bought 500,000 securities for $250,000 ($0.50 each) – On-market trade".
+func (d *DirectorInterest) Summary() string {
+	var sb strings.Builder
+	sb.WriteString(d.Director)
+
+	switch d.Direction() {
+	case "Buy":
+		fmt.Fprintf(&sb, " bought %s securities", formatCount(d.Acquired))
+	case "Sell":
+		fmt.Fprintf(&sb, " sold %s securities", formatCount(d.Disposed))
+	case "Buy & sell":
+		fmt.Fprintf(&sb, " acquired %s and disposed of %s securities", formatCount(d.Acquired), formatCount(d.Disposed))
+	default:
+		sb.WriteString(" changed their interest")
+	}
+
+	if d.Consideration > 0 {
+		fmt.Fprintf(&sb, " for %s", formatDollars(d.Consideration))
+		if p := d.PricePerSecurity(); p > 0 {
+			fmt.Fprintf(&sb, " (%s each)", formatDollars(p))
+		}
+	}
+	if d.Nature != "" {
+		fmt.Fprintf(&sb, " – %s", d.Nature)
+	}
+	if d.DateOfChange != "" {
+		fmt.Fprintf(&sb, " on %s", d.DateOfChange)
+	}
+	return sb.String()
+}
@@ -0,0 +1,133 @@
+package forms
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CashFlow is the funding position reported in an Appendix 4C (quarterly cash flow report) or
+// the equivalent Appendix 5B lodged by mining exploration entities.
+type CashFlow struct {
+	Form            string  // "4C" or "5B"
+	CashAtEnd       float64 // AUD, cash and cash equivalents at quarter end
+	NetOperating    float64 // AUD, net cash from operating activities for the quarter (negative = burn)
+	QuartersFunding float64 // estimated quarters of funding available, 0 when Positive
+	Positive        bool    // operating cash flow is positive, so funding doesn't run down
+}
+
+const cashFlowUnits = 1000 // the forms report amounts in $A'000
+
+// amount matches "1,234", "(1,234)", "-1,234", "1,234.5" or "-" (nil).
+const amount = `(\(?-?[\d,]+(?:\.\d+)?\)?|-|–|nil)`
+
+var (
+	cashFlowForm = regexp.MustCompile(`(?i)appendix\s*(4c|5b)\b`)
+
+	netOperatingPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)net cash from\s*/\s*\(used in\)\s*operating activities\s*\(item 1\.9\)\s*` + amount),
+		regexp.MustCompile(`(?i)1\.9\s*net cash from\s*/\s*\(used in\)\s*operating activities\s*` + amount),
+	}
+	cashAtEndPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)cash and cash equivalents at quarter end\s*\(item 4\.6\)\s*` + amount),
+		regexp.MustCompile(`(?i)4\.6\s*cash and cash equivalents at end of (?:period|quarter)\s*` + amount),
+	}
+	quartersPattern = regexp.MustCompile(`(?i)estimated quarters of funding available\s*\(item [\d.]+ divided by item [\d.]+\)\s*(n/?a|[\d,]+(?:\.\d+)?)`)
+)
+
+// IsCashFlowReport reports whether an announcement is a quarterly Appendix 4C or 5B.
+func IsCashFlowReport(title, text string) bool {
+	if cashFlowForm.MatchString(title) {
+		return true
+	}
+	head := text
+	if len(head) > 3000 {
+		head = head[:3000]
+	}
+	return cashFlowForm.MatchString(head)
+}
+
+// ParseCashFlow extracts the funding position from an Appendix 4C or 5B.
+func ParseCashFlow(text string) (*CashFlow, error) {
+	text = normalise(text)
+
+	c := &CashFlow{Form: "4C"}
+	if m := cashFlowForm.FindStringSubmatch(text); m != nil {
+		c.Form = strings.ToUpper(m[1])
+	}
+
+	netOperating, okOperating := findAmount(text, netOperatingPatterns)
+	cashAtEnd, okCash := findAmount(text, cashAtEndPatterns)
+	if !okOperating || !okCash {
+		return nil, errors.New("cash flow fields not found")
+	}
+	c.NetOperating = netOperating * cashFlowUnits
+	c.CashAtEnd = cashAtEnd * cashFlowUnits
+
+	// The form's own estimate includes unused finance facilities, so prefer it when stated.
+	if m := quartersPattern.FindStringSubmatch(text); m != nil && !strings.HasPrefix(strings.ToLower(m[1]), "n") {
+		c.QuartersFunding, _ = strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	} else if c.NetOperating < 0 {
+		c.QuartersFunding = c.CashAtEnd / -c.NetOperating
+	} else {
+		c.Positive = true
+	}
+	return c, nil
+}
+
+// findAmount returns the first amount matched by any of patterns.
+func findAmount(text string, patterns []*regexp.Regexp) (float64, bool) {
+	for _, p := range patterns {
+		if m := p.FindStringSubmatch(text); m != nil {
+			return parseAmount(m[1]), true
+		}
+	}
+	return 0, false
+}
+
+// parseAmount parses accounting notation: "(1,234)" is negative and "-" is zero.
+func parseAmount(s string) float64 {
+	negative := strings.HasPrefix(s, "(") || strings.HasPrefix(s, "-")
+	v, err := strconv.ParseFloat(strings.Trim(strings.ReplaceAll(s, ",", ""), "()-"), 64)
+	if err != nil {
+		return 0
+	}
+	if negative {
+		return -v
+	}
+	return v
+}
+
+// BelowFunding reports whether fewer than minQuarters of funding remain. 0 disables the check.
+func (c *CashFlow) BelowFunding(minQuarters float64) bool {
+	return minQuarters > 0 && !c.Positive && c.QuartersFunding < minQuarters
+}
+
+// Summary renders the funding position, e.g.
+// "Appendix 4C: cash $5.7m, operating cash flow -$1.2m, 4.6 quarters of funding".
+func (c *CashFlow) Summary() string {
+	quarters := "cash flow positive"
+	if !c.Positive {
+		quarters = fmt.Sprintf("%.1f quarters of funding", c.QuartersFunding)
+	}
+	return fmt.Sprintf("Appendix %s: cash %s, operating cash flow %s, %s",
+		c.Form, formatMillions(c.CashAtEnd), formatMillions(c.NetOperating), quarters)
+}
+
+// formatMillions renders AUD amounts compactly: "$5.7m", "-$850k".
+func formatMillions(v float64) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	switch {
+	case v >= 1e6:
+		return fmt.Sprintf("%s$%.1fm", sign, v/1e6)
+	case v >= 1e3:
+		return fmt.Sprintf("%s$%.0fk", sign, v/1e3)
+	default:
+		return fmt.Sprintf("%s$%.0f", sign, v)
+	}
+}
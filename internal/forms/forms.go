@@ -0,0 +1,126 @@
+/*
+Package forms parses the fixed-layout ASX forms (Appendix 3Y, ...) out of extracted PDF text
+so their key figures can be shown as structured fields instead of a raw snippet.
+
+The forms are label/value tables. pdftotext emits labels and values in reading order but with
+unpredictable line breaks, so fields are located by label in whitespace-normalised text and a
+value runs until the next known label.
+*/
+package forms
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// whitespace also matches the "|" cell separators of tables reconstructed from layout text.
+var whitespace = regexp.MustCompile(`[\s|]+`)
+
+// normalise collapses all whitespace so labels match regardless of line breaks.
+func normalise(text string) string {
+	return whitespace.ReplaceAllString(text, " ")
+}
+
+// field is a labelled value in a form. Its value ends at the earliest following label.
+type field struct {
+	name    string
+	label   *regexp.Regexp
+	skip    *regexp.Regexp // optional boilerplate right after the label, e.g. "Note: ..."
+	content string
+}
+
+// extractFields finds each field's value in normalised text.
+func extractFields(text string, fields []*field) map[string]string {
+	type hit struct {
+		f          *field
+		start, end int
+	}
+
+	var hits []hit
+	for _, f := range fields {
+		loc := f.label.FindStringIndex(text)
+		if loc == nil {
+			continue
+		}
+		end := loc[1]
+		if f.skip != nil {
+			if s := f.skip.FindStringIndex(text[end:]); s != nil && s[0] <= 2 {
+				end += s[1]
+			}
+		}
+		hits = append(hits, hit{f: f, start: loc[0], end: end})
+	}
+
+	values := make(map[string]string, len(hits))
+	for _, h := range hits {
+		stop := len(text)
+		for _, other := range hits {
+			if other.start >= h.end && other.start < stop {
+				stop = other.start
+			}
+		}
+		values[h.f.name] = strings.Trim(strings.TrimSpace(text[h.end:stop]), ":- ")
+	}
+	return values
+}
+
+var numberPattern = regexp.MustCompile(`\d[\d,]*(?:\.\d+)?`)
+
+// parseCount returns the first whole number in s ("1,500,000 ordinary shares" = 1500000).
+// "Nil", "N/A" and empty values are 0.
+func parseCount(s string) int64 {
+	n := numberPattern.FindString(s)
+	if n == "" {
+		return 0
+	}
+	n, _, _ = strings.Cut(strings.ReplaceAll(n, ",", ""), ".")
+	v, _ := strconv.ParseInt(n, 10, 64)
+	return v
+}
+
+var dollarPattern = regexp.MustCompile(`\$\s?(\d[\d,]*(?:\.\d+)?)\s*(million|m|k|thousand|billion|bn)?\b`)
+
+// parseDollars returns the first dollar amount in s, honouring million/thousand suffixes.
+func parseDollars(s string) (float64, bool) {
+	m := dollarPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	switch strings.ToLower(m[2]) {
+	case "k", "thousand":
+		v *= 1e3
+	case "m", "million":
+		v *= 1e6
+	case "bn", "billion":
+		v *= 1e9
+	}
+	return v, true
+}
+
+// formatCount renders n with thousands separators.
+func formatCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// formatDollars renders v as "$1,234" or "$0.052" for sub-dollar amounts.
+func formatDollars(v float64) string {
+	if v < 0 {
+		return "-" + formatDollars(-v)
+	}
+	if v < 1 {
+		return "$" + strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return "$" + formatCount(int64(v+0.5))
+}
@@ -0,0 +1,264 @@
+/*
+Package geo tags announcements with the Australian states, mining regions and commodities they
+mention, using a small built-in gazetteer, so resource matches can be filtered by geography.
+*/
+package geo
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Tags are the places and commodities an announcement refers to.
+type Tags struct {
+	States      []string `json:",omitempty"` // state/territory codes, e.g. "WA"
+	Regions     []string `json:",omitempty"` // named regions or projects, e.g. "Pilbara"
+	Commodities []string `json:",omitempty"` // normalised commodity names, e.g. "gold"
+}
+
+// Empty reports whether no tags were found.
+func (t Tags) Empty() bool {
+	return len(t.States) == 0 && len(t.Regions) == 0 && len(t.Commodities) == 0
+}
+
+// String formats the tags for display, e.g. "WA (Pilbara) · lithium".
+func (t Tags) String() string {
+	var parts []string
+	if len(t.States) > 0 {
+		places := strings.Join(t.States, ", ")
+		if len(t.Regions) > 0 {
+			places += " (" + strings.Join(t.Regions, ", ") + ")"
+		}
+		parts = append(parts, places)
+	} else if len(t.Regions) > 0 {
+		parts = append(parts, strings.Join(t.Regions, ", "))
+	}
+	if len(t.Commodities) > 0 {
+		parts = append(parts, strings.Join(t.Commodities, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// states maps state and territory names to their codes.
+var states = map[string]string{
+	"western australia":            "WA",
+	"new south wales":              "NSW",
+	"queensland":                   "QLD",
+	"victoria":                     "VIC",
+	"south australia":              "SA",
+	"tasmania":                     "TAS",
+	"northern territory":           "NT",
+	"australian capital territory": "ACT",
+}
+
+// regions maps well-known mining regions and districts to their state.
+var regions = map[string]string{
+	"pilbara":            "WA",
+	"goldfields":         "WA",
+	"eastern goldfields": "WA",
+	"yilgarn":            "WA",
+	"kimberley":          "WA",
+	"murchison":          "WA",
+	"kalgoorlie":         "WA",
+	"leonora":            "WA",
+	"laverton":           "WA",
+	"paterson":           "WA",
+	"fraser range":       "WA",
+	"greenbushes":        "WA",
+	"mount isa":          "QLD",
+	"bowen basin":        "QLD",
+	"surat basin":        "QLD",
+	"cloncurry":          "QLD",
+	"lachlan fold belt":  "NSW",
+	"cobar":              "NSW",
+	"broken hill":        "NSW",
+	"hunter valley":      "NSW",
+	"bendigo":            "VIC",
+	"ballarat":           "VIC",
+	"gawler craton":      "SA",
+	"olympic dam":        "SA",
+	"cooper basin":       "SA",
+	"tennant creek":      "NT",
+	"arnhem land":        "NT",
+	"mcarthur basin":     "NT",
+	"beetaloo":           "NT",
+}
+
+// commodities maps commodity spellings to a normalised name.
+var commodities = map[string]string{
+	"gold":          "gold",
+	"silver":        "silver",
+	"copper":        "copper",
+	"nickel":        "nickel",
+	"cobalt":        "cobalt",
+	"zinc":          "zinc",
+	"lithium":       "lithium",
+	"spodumene":     "lithium",
+	"iron ore":      "iron ore",
+	"magnetite":     "iron ore",
+	"hematite":      "iron ore",
+	"uranium":       "uranium",
+	"rare earth":    "rare earths",
+	"rare earths":   "rare earths",
+	"graphite":      "graphite",
+	"vanadium":      "vanadium",
+	"tin":           "tin",
+	"tungsten":      "tungsten",
+	"manganese":     "manganese",
+	"bauxite":       "bauxite",
+	"mineral sands": "mineral sands",
+	"coal":          "coal",
+	"natural gas":   "gas",
+	"lng":           "gas",
+	"oil":           "oil",
+	"helium":        "helium",
+	"potash":        "potash",
+	"phosphate":     "phosphate",
+}
+
+// stateCodes lets filters and AI-reported locations use either names or codes.
+var stateCodes = func() map[string]string {
+	codes := make(map[string]string, len(states))
+	for name, code := range states {
+		codes[name] = code
+		codes[strings.ToLower(code)] = code
+	}
+	return codes
+}()
+
+// Tag scans text for gazetteer entries. Matching is case-insensitive and on word boundaries.
+func Tag(text string) Tags {
+	lower := strings.ToLower(text)
+
+	var t Tags
+	for name, code := range states {
+		if containsWord(lower, name) {
+			t.States = appendUnique(t.States, code)
+		}
+	}
+	for name, state := range regions {
+		if containsWord(lower, name) {
+			t.Regions = appendUnique(t.Regions, titleCase(name))
+			t.States = appendUnique(t.States, state)
+		}
+	}
+	for name, commodity := range commodities {
+		if containsWord(lower, name) {
+			t.Commodities = appendUnique(t.Commodities, commodity)
+		}
+	}
+
+	t.sort()
+	return t
+}
+
+// Merge adds other's tags to t.
+func (t Tags) Merge(other Tags) Tags {
+	for _, s := range other.States {
+		t.States = appendUnique(t.States, s)
+	}
+	for _, r := range other.Regions {
+		t.Regions = appendUnique(t.Regions, r)
+	}
+	for _, c := range other.Commodities {
+		t.Commodities = appendUnique(t.Commodities, c)
+	}
+	t.sort()
+	return t
+}
+
+// FromProject converts a project reported by the AI into tags.
+func FromProject(location, state, commodity string) Tags {
+	t := Tag(location)
+	if code := NormaliseState(state); code != "" {
+		t.States = appendUnique(t.States, code)
+	}
+	if strings.TrimSpace(location) != "" && len(t.Regions) == 0 {
+		t.Regions = appendUnique(t.Regions, strings.TrimSpace(location))
+	}
+	if strings.TrimSpace(commodity) != "" {
+		t.Commodities = appendUnique(t.Commodities, NormaliseCommodity(commodity))
+	}
+	t.sort()
+	return t
+}
+
+// NormaliseState turns "Western Australia" or "wa" into "WA". Unknown values return "".
+func NormaliseState(s string) string {
+	return stateCodes[strings.ToLower(strings.TrimSpace(s))]
+}
+
+// NormaliseCommodity turns a commodity spelling into its normalised name, or lower-cases unknown values.
+func NormaliseCommodity(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := commodities[s]; ok {
+		return c
+	}
+	return s
+}
+
+// Filter selects matches by state and commodity. Each non-empty list must share at least one
+// entry with the tags, so {States: WA, Commodities: gold} means "Western Australian gold".
+type Filter struct {
+	States      []string
+	Commodities []string
+}
+
+// Active reports whether the filter restricts anything.
+func (f Filter) Active() bool {
+	return len(f.States) > 0 || len(f.Commodities) > 0
+}
+
+// Allows reports whether tags satisfy the filter.
+func (f Filter) Allows(t Tags) bool {
+	if len(f.States) > 0 && !intersects(f.States, t.States) {
+		return false
+	}
+	if len(f.Commodities) > 0 && !intersects(f.Commodities, t.Commodities) {
+		return false
+	}
+	return true
+}
+
+func intersects(a, b []string) bool {
+	return slices.ContainsFunc(a, func(s string) bool { return slices.Contains(b, s) })
+}
+
+var wordPatterns = map[string]*regexp.Regexp{}
+
+func init() {
+	for _, m := range []map[string]string{states, regions, commodities} {
+		for name := range m {
+			wordPatterns[name] = regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+		}
+	}
+}
+
+func containsWord(text, word string) bool {
+	if !strings.Contains(text, word) {
+		return false
+	}
+	return wordPatterns[word].MatchString(text)
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" || slices.Contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func (t *Tags) sort() {
+	slices.Sort(t.States)
+	slices.Sort(t.Regions)
+	slices.Sort(t.Commodities)
+}
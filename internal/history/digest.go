@@ -0,0 +1,85 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	digestFileName = "asx_digest_queue.json"
+	digestVersion  = 1
+)
+
+// digestFile holds matches waiting for the next scheduled email digest.
+type digestFile struct {
+	Version  int                    `json:"version"`
+	LastSent time.Time              `json:"last_sent"`
+	Matches  []types.AnnotatedMatch `json:"matches"`
+}
+
+func (m *Manager) digestFilePath() string {
+	return filepath.Join(filepath.Dir(m.historyFilePath), digestFileName)
+}
+
+func (m *Manager) loadDigest() (digestFile, error) {
+	var d digestFile
+	data, err := os.ReadFile(m.digestFilePath())
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return d, fmt.Errorf("failed to read digest queue: %w", err)
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return d, fmt.Errorf("failed to parse digest queue: %w", err)
+	}
+	return d, nil
+}
+
+func (m *Manager) saveDigest(d digestFile) error {
+	d.Version = digestVersion
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.digestFilePath(), data, 0o644)
+}
+
+// QueueDigest adds matches to the next email digest. The queue survives restarts.
+func (m *Manager) QueueDigest(matches []types.AnnotatedMatch) error {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	d, err := m.loadDigest()
+	if err != nil {
+		return err
+	}
+	d.Matches = append(d.Matches, matches...)
+	return m.saveDigest(d)
+}
+
+// PendingDigest returns the queued matches and when the last digest was sent.
+func (m *Manager) PendingDigest() ([]types.AnnotatedMatch, time.Time, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	d, err := m.loadDigest()
+	return d.Matches, d.LastSent, err
+}
+
+// DigestSent empties the queue and records when the digest went out.
+func (m *Manager) DigestSent(at time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.saveDigest(digestFile{LastSent: at})
+}
@@ -0,0 +1,172 @@
+package history
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// AllKeywords recorded for an announcement suppresses every keyword and ticker match on it.
+const AllKeywords = "*"
+
+// Entry is an announcement reported today and what it was reported for.
+type Entry struct {
+	Ticker   string   `json:"ticker"`
+	Title    string   `json:"title"`
+	Keywords []string `json:"keywords"` // types.TickerMatchPlaceholder for a ticker match, AllKeywords for all
+}
+
+// Entries returns today's reported announcements, sorted by ticker and title.
+func (m *Manager) Entries() []Entry {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	reported, err := m.store.Load(m.getCurrentReportDate())
+	if err != nil {
+		log.Printf("Error loading history: %v", err)
+	}
+	entries := make([]Entry, 0, len(reported))
+	for key, keywords := range reported {
+		ticker, title, _ := strings.Cut(key, "|")
+		entries = append(entries, Entry{Ticker: ticker, Title: title, Keywords: slices.Sorted(maps.Keys(keywords))})
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(cmp.Compare(a.Ticker, b.Ticker), cmp.Compare(a.Title, b.Title))
+	})
+	return entries
+}
+
+// Import records entries as already reported today. Entries without keywords suppress all
+// further matches on the announcement. It returns the number of keywords added.
+func (m *Manager) Import(entries []Entry) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	keywords := make(map[string][]string)
+	for _, e := range entries {
+		key := strings.ToUpper(strings.TrimSpace(e.Ticker)) + "|" + e.Title
+		if len(e.Keywords) == 0 {
+			keywords[key] = append(keywords[key], AllKeywords)
+		} else {
+			keywords[key] = append(keywords[key], e.Keywords...)
+		}
+	}
+	claimed, err := m.store.Claim(m.getCurrentReportDate(), keywords)
+	if err != nil {
+		return 0, err
+	}
+	m.saveHistory()
+	m.loadReported()
+
+	added := 0
+	for _, kws := range claimed {
+		added += len(kws)
+	}
+	return added, nil
+}
+
+// Clear forgets today's reported matches and cached analyses, so matching announcements are
+// alerted on again by the next scan.
+func (m *Manager) Clear() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.store.Clear(m.getCurrentReportDate()); err != nil {
+		return err
+	}
+	m.history.AnalysisCache = make(map[string]*ai.AIAnalysis)
+	m.saveHistory()
+	m.loadReported()
+	return nil
+}
+
+// WriteEntries writes entries as JSON or CSV (ticker, title and ';' separated keywords).
+func WriteEntries(w io.Writer, entries []Entry, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"ticker", "title", "keywords"})
+		for _, e := range entries {
+			_ = cw.Write([]string{e.Ticker, e.Title, strings.Join(e.Keywords, ";")})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown format %q (expected 'json' or 'csv')", format)
+}
+
+// ReadEntries reads entries written by WriteEntries. CSV needs ticker and title columns;
+// keywords is optional.
+func ReadEntries(r io.Reader, format string) ([]Entry, error) {
+	switch format {
+	case "json":
+		var entries []Entry
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return entries, nil
+	case "csv":
+		return readCSVEntries(r)
+	}
+	return nil, fmt.Errorf("unknown format %q (expected 'json' or 'csv')", format)
+}
+
+func readCSVEntries(r io.Reader) ([]Entry, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	tickerCol, hasTicker := columns["ticker"]
+	titleCol, hasTitle := columns["title"]
+	if !hasTicker || !hasTitle {
+		return nil, fmt.Errorf("CSV header must include ticker and title columns")
+	}
+	keywordsCol, hasKeywords := columns["keywords"]
+
+	var entries []Entry
+	for _, record := range records[1:] {
+		e := Entry{Ticker: record[tickerCol], Title: record[titleCol]}
+		if hasKeywords && record[keywordsCol] != "" {
+			e.Keywords = strings.Split(record[keywordsCol], ";")
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Describe renders an entry's keywords for display.
+func (e Entry) Describe() string {
+	var parts []string
+	for _, kw := range e.Keywords {
+		switch kw {
+		case types.TickerMatchPlaceholder:
+			parts = append(parts, "(ticker match)")
+		case AllKeywords:
+			parts = append(parts, "(all)")
+		default:
+			parts = append(parts, kw)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,177 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	failedFileName    = "asx_failed_notifications.json"
+	failedVersion     = 1
+	maxFailedAttempts = 20
+	maxFailedAge      = 48 * time.Hour // an alert this old is no longer worth delivering
+)
+
+// FailedNotification is a match a channel failed to deliver, waiting to be retried.
+type FailedNotification struct {
+	Channel     string               `json:"channel"`
+	Match       types.AnnotatedMatch `json:"match"`
+	Error       string               `json:"error"`
+	Attempts    int                  `json:"attempts"`
+	FirstFailed time.Time            `json:"first_failed"`
+}
+
+func (f FailedNotification) key() string {
+	return f.Channel + "|" + f.Match.Match.Ticker + "|" + f.Match.Match.PDFURL
+}
+
+// failedFile holds notifications waiting to be retried.
+type failedFile struct {
+	Version       int                  `json:"version"`
+	Notifications []FailedNotification `json:"notifications"`
+}
+
+func (m *Manager) failedFilePath() string {
+	return filepath.Join(filepath.Dir(m.historyFilePath), failedFileName)
+}
+
+func (m *Manager) loadFailed() (failedFile, error) {
+	var f failedFile
+	data, err := os.ReadFile(m.failedFilePath())
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return f, fmt.Errorf("failed to read failed notification queue: %w", err)
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("failed to parse failed notification queue: %w", err)
+	}
+	return f, nil
+}
+
+func (m *Manager) saveFailed(f failedFile) error {
+	f.Version = failedVersion
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.failedFilePath(), data, 0o644)
+}
+
+// QueueFailed stores matches that channel failed to deliver for a later retry. Matches
+// that have failed too often or for too long are dropped with a warning.
+func (m *Manager) QueueFailed(channel string, matches []types.AnnotatedMatch, cause error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	f, err := m.loadFailed()
+	if err != nil {
+		log.Printf("Error queueing failed %s notifications: %v", channel, err)
+		return
+	}
+
+	now := m.clock.Now()
+	queued := make(map[string]int, len(f.Notifications))
+	for i, n := range f.Notifications {
+		queued[n.key()] = i
+	}
+	added := 0
+	for _, am := range matches {
+		n := FailedNotification{Channel: channel, Match: am, Error: cause.Error(), Attempts: 1, FirstFailed: now}
+		if prev, ok := m.retrying[n.key()]; ok {
+			n.Attempts, n.FirstFailed = prev.Attempts+1, prev.FirstFailed
+			delete(m.retrying, n.key())
+		}
+		if n.Attempts > maxFailedAttempts || now.Sub(n.FirstFailed) > maxFailedAge {
+			log.Printf("Warning: giving up on the %s notification for %s (%s) after %d attempts: %v", channel, am.Match.Ticker, am.Match.Title, n.Attempts, cause)
+			continue
+		}
+		added++
+		if i, ok := queued[n.key()]; ok {
+			f.Notifications[i] = n
+			continue
+		}
+		queued[n.key()] = len(f.Notifications)
+		f.Notifications = append(f.Notifications, n)
+	}
+	if added == 0 {
+		return
+	}
+
+	if err := m.saveFailed(f); err != nil {
+		log.Printf("Error queueing failed %s notifications: %v", channel, err)
+		return
+	}
+	log.Printf("Queued %d failed %s notification(s) for retry.", added, channel)
+}
+
+// PendingFailed returns the notifications waiting to be retried. They stay queued until
+// FinishRetry confirms delivery, so a retry interrupted by a restart isn't lost. Any that fail
+// again and are re-queued keep their attempt count. Notifications too old to be worth
+// delivering are dropped with a warning.
+func (m *Manager) PendingFailed() ([]FailedNotification, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	f, err := m.loadFailed()
+	if err != nil || len(f.Notifications) == 0 {
+		return nil, err
+	}
+
+	now := m.clock.Now()
+	pending := slices.DeleteFunc(slices.Clone(f.Notifications), func(n FailedNotification) bool {
+		if now.Sub(n.FirstFailed) <= maxFailedAge {
+			return false
+		}
+		log.Printf("Warning: giving up on the %s notification for %s (%s) after %s undelivered", n.Channel, n.Match.Match.Ticker, n.Match.Match.Title, maxFailedAge)
+		return true
+	})
+	if len(pending) < len(f.Notifications) {
+		f.Notifications = pending
+		if err := m.saveFailed(f); err != nil {
+			return nil, err
+		}
+	}
+
+	m.retrying = make(map[string]FailedNotification, len(pending))
+	for _, n := range pending {
+		m.retrying[n.key()] = n
+	}
+	return pending, nil
+}
+
+// FinishRetry ends the retry of channel's notifications returned by PendingFailed. When
+// delivered, those that weren't queued again meanwhile are removed from the queue; otherwise,
+// e.g. when the channel is disabled, they are left for a later retry.
+func (m *Manager) FinishRetry(channel string, delivered bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	done := make(map[string]bool)
+	for key, n := range m.retrying {
+		if n.Channel == channel {
+			done[key] = true
+			delete(m.retrying, key)
+		}
+	}
+	if !delivered || len(done) == 0 {
+		return nil
+	}
+
+	f, err := m.loadFailed()
+	if err != nil {
+		return err
+	}
+	f.Notifications = slices.DeleteFunc(f.Notifications, func(n FailedNotification) bool {
+		return done[n.key()]
+	})
+	return m.saveFailed(f)
+}
@@ -0,0 +1,102 @@
+package history
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/clock"
+	"github.com/shanehull/annscraper/internal/paths"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+func testMatch(ticker string) types.AnnotatedMatch {
+	return types.AnnotatedMatch{Match: types.Match{Announcement: types.Announcement{Ticker: ticker, PDFURL: "https://example.com/" + ticker}}}
+}
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir()) // keep legacy migration away from a real /tmp/annscraper
+	paths.SetDataDir(t.TempDir())
+	t.Cleanup(func() { paths.SetDataDir("") })
+	m, err := NewManager("Australia/Sydney", clock.System{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func pendingTickers(t *testing.T, m *Manager) []string {
+	t.Helper()
+	f, err := m.loadFailed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tickers []string
+	for _, n := range f.Notifications {
+		tickers = append(tickers, n.Match.Match.Ticker)
+	}
+	return tickers
+}
+
+func TestFailedQueueSurvivesRetry(t *testing.T) {
+	m := newTestManager(t)
+	m.QueueFailed("email", []types.AnnotatedMatch{testMatch("BHP"), testMatch("CBA")}, errors.New("smtp down"))
+
+	pending, err := m.PendingFailed()
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("PendingFailed() = %d notifications, %v; want 2", len(pending), err)
+	}
+	// Until the retry finishes, a restart must still find both queued.
+	if got := pendingTickers(t, m); len(got) != 2 {
+		t.Fatalf("queue during retry = %v, want both", got)
+	}
+
+	// CBA fails again, BHP is delivered.
+	m.QueueFailed("email", []types.AnnotatedMatch{testMatch("CBA")}, errors.New("smtp down"))
+	if err := m.FinishRetry("email", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := pendingTickers(t, m); len(got) != 1 || got[0] != "CBA" {
+		t.Fatalf("queue after retry = %v, want [CBA]", got)
+	}
+	f, _ := m.loadFailed()
+	if f.Notifications[0].Attempts != 2 {
+		t.Errorf("CBA attempts = %d, want 2", f.Notifications[0].Attempts)
+	}
+	if len(m.retrying) != 0 {
+		t.Errorf("retrying = %v, want empty", m.retrying)
+	}
+}
+
+func TestFailedQueueKeptForDisabledChannel(t *testing.T) {
+	m := newTestManager(t)
+	m.QueueFailed("sms", []types.AnnotatedMatch{testMatch("BHP")}, errors.New("gateway down"))
+
+	if _, err := m.PendingFailed(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.FinishRetry("sms", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := pendingTickers(t, m); len(got) != 1 {
+		t.Fatalf("queue = %v, want [BHP] kept", got)
+	}
+	if len(m.retrying) != 0 {
+		t.Errorf("retrying = %v, want empty", m.retrying)
+	}
+}
+
+func TestFailedQueueDropsExpired(t *testing.T) {
+	m := newTestManager(t)
+	m.QueueFailed("email", []types.AnnotatedMatch{testMatch("BHP")}, errors.New("smtp down"))
+	m.clock = clock.Fixed{T: time.Now().Add(maxFailedAge + time.Hour)}
+
+	pending, err := m.PendingFailed()
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("PendingFailed() = %d notifications, %v; want none", len(pending), err)
+	}
+	if got := pendingTickers(t, m); len(got) != 0 {
+		t.Errorf("queue = %v, want empty", got)
+	}
+}
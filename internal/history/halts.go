@@ -0,0 +1,58 @@
+package history
+
+import (
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// haltRetention is how long an unanswered halt is remembered. Halts normally end within two
+// trading days, but voluntary suspensions can run for weeks.
+const haltRetention = 30 * 24 * time.Hour
+
+// Halt is the latest trading halt for a ticker and the announcement that followed it.
+type Halt struct {
+	Announcement types.Announcement
+	LiftedBy     string `json:",omitempty"` // URL of the first announcement lodged after the halt
+}
+
+// RecordHalt remembers ann as the ticker's open trading halt. Re-recording the same halt keeps
+// its follow-up so repeated scans of a day pair it with the same announcement.
+func (m *Manager) RecordHalt(ann types.Announcement) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if existing := m.history.Halts[ann.Ticker]; existing != nil {
+		if existing.Announcement.PDFURL == ann.PDFURL || existing.Announcement.DateTime.After(ann.DateTime) {
+			return
+		}
+	}
+	m.history.Halts[ann.Ticker] = &Halt{Announcement: ann}
+}
+
+// LiftHalt returns the ticker's trading halt if ann is the first announcement lodged after it.
+func (m *Manager) LiftHalt(ann types.Announcement) (types.Announcement, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	halt := m.history.Halts[ann.Ticker]
+	if halt == nil || !ann.DateTime.After(halt.Announcement.DateTime) {
+		return types.Announcement{}, false
+	}
+	if halt.LiftedBy != "" && halt.LiftedBy != ann.PDFURL {
+		return types.Announcement{}, false
+	}
+	halt.LiftedBy = ann.PDFURL
+	return halt.Announcement, true
+}
+
+// pruneHalts drops halts older than haltRetention.
+func pruneHalts(halts map[string]*Halt, now time.Time) map[string]*Halt {
+	kept := make(map[string]*Halt, len(halts))
+	for ticker, halt := range halts {
+		if halt != nil && now.Sub(halt.Announcement.DateTime) < haltRetention {
+			kept[ticker] = halt
+		}
+	}
+	return kept
+}
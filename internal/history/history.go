@@ -5,24 +5,29 @@ package history
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/clock"
+	"github.com/shanehull/annscraper/internal/paths"
 	"github.com/shanehull/annscraper/internal/types"
 )
 
-const (
-	historyFileName = "asx_report_history.json"
-	historyDirName  = "annscraper"
-)
+const historyFileName = "asx_report_history.json"
 
 type History struct {
+	Version         int `json:"version"`
 	ReportDate      string
 	ReportedMatches map[string]map[string]bool
+	AnalysisCache   map[string]*ai.AIAnalysis `json:",omitempty"`
+	Halts           map[string]*Halt          `json:",omitempty"` // by ticker, kept across report dates
 }
 
 type Manager struct {
@@ -30,13 +35,21 @@ type Manager struct {
 	mutex           sync.Mutex
 	historyFilePath string
 	reportLocation  *time.Location
+	clock           clock.Clock
+	store           Store                      // reported matches, the history file unless SetStore is called
+	reported        map[string]map[string]bool // the store's matches for the report date, loaded each scan
+
+	retrying map[string]FailedNotification // being retried, by key, to carry attempts over
 }
 
-func NewManager(tzName string) (*Manager, error) {
-	historyDir := filepath.Join(os.TempDir(), historyDirName)
+// NewManager creates a history manager whose report date follows clk in the given time zone.
+// A nil clock uses the system time.
+func NewManager(tzName string, clk clock.Clock) (*Manager, error) {
+	historyDir := paths.DataDir()
 	if err := os.MkdirAll(historyDir, 0o755); err != nil {
-		return nil, fmt.Errorf("failed to create temporary history directory %s: %w", historyDir, err)
+		return nil, fmt.Errorf("failed to create history directory %s: %w", historyDir, err)
 	}
+	migrateLegacyFiles(historyDir)
 	filePath := filepath.Join(historyDir, historyFileName)
 
 	loc, err := time.LoadLocation(tzName)
@@ -47,48 +60,117 @@ func NewManager(tzName string) (*Manager, error) {
 	m := &Manager{
 		historyFilePath: filePath,
 		reportLocation:  loc,
+		clock:           clock.OrSystem(clk),
 	}
+	m.store = &fileStore{history: &m.history}
 
-	m.loadHistory()
+	if err := m.loadHistory(); err != nil {
+		return nil, err
+	}
 	return m, nil
 }
 
-func (m *Manager) loadHistory() {
+// SetStore keeps reported matches in store instead of the history file, so instances
+// sharing it share dedup state. A nil store is ignored.
+func (m *Manager) SetStore(store Store) {
+	if store == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.store = store
+	m.loadReported()
+}
+
+// loadReported reads the report date's matches from the store in one query, so checking
+// each candidate match during a scan needs none. Must be called with the mutex held.
+func (m *Manager) loadReported() {
+	reported, err := m.store.Load(m.getCurrentReportDate())
+	if err != nil {
+		// Matches are still claimed before notifying, so this only costs repeated work.
+		log.Printf("Warning: failed to load reported matches: %v", err)
+		reported = make(map[string]map[string]bool)
+	}
+	m.reported = reported
+}
+
+// migrateLegacyFiles moves history kept in the temp directory by earlier versions into dir.
+func migrateLegacyFiles(dir string) {
+	for _, name := range []string{historyFileName, digestFileName, notificationsFileName, shareKeyFileName, weeklyWrapFileName} {
+		moved, err := paths.MigrateLegacy(dir, name)
+		if err != nil {
+			log.Printf("Warning: failed to move %s from %s to %s: %v", name, paths.LegacyDir(), dir, err)
+		} else if moved {
+			log.Printf("Moved %s from %s to %s.", name, paths.LegacyDir(), dir)
+		}
+	}
+}
+
+// loadHistory reads the history file, starting a fresh report if it is missing, unreadable or
+// for an earlier date. A file written by a newer version is an error, so that it isn't
+// replaced by an empty report and the day's alerts sent again.
+func (m *Manager) loadHistory() error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	defer m.loadReported()
 
 	today := m.getCurrentReportDate()
 	m.history = History{
 		ReportDate:      today,
 		ReportedMatches: make(map[string]map[string]bool),
+		AnalysisCache:   make(map[string]*ai.AIAnalysis),
+		Halts:           make(map[string]*Halt),
 	}
 
 	data, err := os.ReadFile(m.historyFilePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			log.Printf("History file %s not found. Starting fresh report.", m.historyFilePath)
-			return
+			return nil
 		}
 		log.Printf("Error reading history file (%s): %v. Starting fresh report.", m.historyFilePath, err)
-		return
+		return nil
 	}
 
 	var loadedHistory History
-	if err := json.Unmarshal(data, &loadedHistory); err != nil {
-		log.Printf("Error unmarshalling history JSON: %v. Starting fresh report.", err)
-		return
+	migrated, err := decodeVersioned(data, historyVersion, historyMigrations, "", &loadedHistory)
+	if errors.Is(err, errNewerVersion) {
+		return fmt.Errorf("history file %s: %w", m.historyFilePath, err)
+	}
+	if err != nil {
+		backup := backupFile(m.historyFilePath, "unreadable")
+		log.Printf("Error loading history file: %v. Backed up to %s. Starting fresh report.", err, backup)
+		return nil
+	}
+	if migrated {
+		log.Printf("Migrated history file to format version %d.", historyVersion)
 	}
 
+	m.history.Halts = pruneHalts(loadedHistory.Halts, m.clock.Now())
+
 	if loadedHistory.ReportDate == today {
+		if loadedHistory.AnalysisCache == nil {
+			loadedHistory.AnalysisCache = make(map[string]*ai.AIAnalysis)
+		}
+		loadedHistory.Halts = m.history.Halts
 		m.history = loadedHistory
-		log.Printf("Loaded %d reported matches for today (%s).", len(m.history.ReportedMatches), today)
+		log.Printf("Loaded %d reported matches and %d cached analyses for today (%s).", len(m.history.ReportedMatches), len(m.history.AnalysisCache), today)
 	} else {
 		log.Printf("History is from %s. Starting new report history for today (%s).", loadedHistory.ReportDate, today)
 	}
+	return nil
+}
+
+// Reload re-reads the history file, starting a fresh report if the report date has rolled over.
+// Long-running processes call it before each scan. It fails if another process has since
+// written the file in a newer format.
+func (m *Manager) Reload() error {
+	return m.loadHistory()
 }
 
 func (m *Manager) saveHistory() {
 	m.history.ReportDate = m.getCurrentReportDate()
+	m.history.Version = historyVersion
 
 	data, err := json.MarshalIndent(m.history, "", "  ")
 	if err != nil {
@@ -101,58 +183,102 @@ func (m *Manager) saveHistory() {
 	}
 }
 
+// FilterNewMatches returns the keywords (or the ticker match placeholder) not yet reported
+// for ann according to the matches loaded at the start of the scan. ClaimMatches makes the
+// final check before notifying.
 func (m *Manager) FilterNewMatches(ann types.Announcement, foundKeywords []string, isTickerMatch bool) []string {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	key := ann.Ticker + "|" + ann.Title
-	reportedKws, exists := m.history.ReportedMatches[key]
-
+	candidates := foundKeywords
 	if isTickerMatch && len(foundKeywords) == 0 {
-		if exists && reportedKws[types.TickerMatchPlaceholder] {
-			return nil
-		}
-
-		return []string{types.TickerMatchPlaceholder}
+		candidates = []string{types.TickerMatchPlaceholder}
 	}
-
-	if len(foundKeywords) == 0 {
+	if len(candidates) == 0 {
 		return nil
 	}
+	return filterNew(m.reported[ann.Ticker+"|"+ann.Title], candidates)
+}
 
-	if !exists {
-		return foundKeywords
+// ClaimMatches records matches as reported and returns those that this process claimed
+// first, dropping any another instance sharing the store has reported since the scan began.
+func (m *Manager) ClaimMatches(matches []types.AnnotatedMatch) []types.AnnotatedMatch {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	defer m.saveHistory()
+
+	keywords := make(map[string][]string)
+	for _, am := range matches {
+		key := am.Match.Ticker + "|" + am.Match.Title
+		keywords[key] = append(keywords[key], matchKeywords(am.Match)...)
+	}
+	if len(keywords) == 0 {
+		return matches
 	}
 
-	var newKeywords []string
-	for _, kw := range foundKeywords {
-		if !reportedKws[kw] {
-			newKeywords = append(newKeywords, kw)
+	claimed, err := m.store.Claim(m.getCurrentReportDate(), keywords)
+	if err != nil {
+		// Better a repeated alert than a missed one.
+		log.Printf("Warning: failed to record %d match(es) in history: %v", len(matches), err)
+		return matches
+	}
+	for key, kws := range keywords {
+		if m.reported[key] == nil {
+			m.reported[key] = make(map[string]bool)
+		}
+		for _, kw := range kws {
+			m.reported[key][kw] = true
+		}
+	}
+
+	var won []types.AnnotatedMatch
+	for _, am := range matches {
+		fresh := claimed[am.Match.Ticker+"|"+am.Match.Title]
+		if slices.ContainsFunc(matchKeywords(am.Match), func(kw string) bool { return slices.Contains(fresh, kw) }) {
+			won = append(won, am)
 		}
 	}
-	return newKeywords
+	if dropped := len(matches) - len(won); dropped > 0 {
+		log.Printf("Skipping %d match(es) already reported by another instance.", dropped)
+	}
+	return won
 }
 
-func (m *Manager) RecordMatches(matches []types.Match) {
+// matchKeywords returns what a match is reported for: its keywords, or the ticker match
+// placeholder.
+func matchKeywords(match types.Match) []string {
+	if len(match.KeywordsFound) == 0 && match.TickerMatched {
+		return []string{types.TickerMatchPlaceholder}
+	}
+	return match.KeywordsFound
+}
+
+// Save writes the history file, persisting cached analyses and the report date.
+func (m *Manager) Save() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	m.saveHistory()
+}
 
-	for _, match := range matches {
-		key := match.Ticker + "|" + match.Title
-
-		if m.history.ReportedMatches[key] == nil {
-			m.history.ReportedMatches[key] = make(map[string]bool)
-		}
+// CachedAnalysis returns the AI analysis previously stored for a document hash today.
+func (m *Manager) CachedAnalysis(key string) (*ai.AIAnalysis, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
-		if len(match.KeywordsFound) == 0 && match.TickerMatched {
-			m.history.ReportedMatches[key][types.TickerMatchPlaceholder] = true
-		}
+	analysis, ok := m.history.AnalysisCache[key]
+	return analysis, ok
+}
 
-		for _, kw := range match.KeywordsFound {
-			m.history.ReportedMatches[key][kw] = true
-		}
+// CacheAnalysis stores an AI analysis for a document hash. It is persisted on the next save.
+func (m *Manager) CacheAnalysis(key string, analysis *ai.AIAnalysis) {
+	if analysis == nil {
+		return
 	}
-	m.saveHistory()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.history.AnalysisCache[key] = analysis
 }
 
 func (m *Manager) HistoryFilePath() string {
@@ -160,5 +286,5 @@ func (m *Manager) HistoryFilePath() string {
 }
 
 func (m *Manager) getCurrentReportDate() string {
-	return time.Now().In(m.reportLocation).Format("2006-01-02")
+	return m.clock.Now().In(m.reportLocation).Format("2006-01-02")
 }
@@ -0,0 +1,97 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// On-disk format versions. Bump a version and add a migration step whenever the stored
+// structure changes, so existing files are upgraded on load instead of being discarded.
+const (
+	historyVersion       = 1
+	notificationsVersion = 1
+)
+
+// migration upgrades a document from version n to n+1.
+type migration func(doc map[string]json.RawMessage) error
+
+// historyMigrations[n] upgrades a history file from version n.
+var historyMigrations = map[int]migration{
+	// v0 had no version field; the structure is unchanged.
+	0: func(doc map[string]json.RawMessage) error { return nil },
+}
+
+// notificationsMigrations[n] upgrades a notifications file from version n.
+var notificationsMigrations = map[int]migration{
+	// v0 was a bare JSON array, wrapped into {"notifications": [...]} by decodeVersioned.
+	0: func(doc map[string]json.RawMessage) error { return nil },
+}
+
+// errNewerVersion is returned for files written by a newer version, which must not be
+// overwritten by this one.
+var errNewerVersion = errors.New("written by a newer version of annscraper")
+
+// versioned is the envelope shared by all versioned files.
+type versioned struct {
+	Version int `json:"version"`
+}
+
+// decodeVersioned migrates data to target and unmarshals it into v. A top-level JSON array
+// is treated as a version 0 document stored under arrayKey. Files written by a newer
+// version are rejected with errNewerVersion, and callers must leave them untouched.
+func decodeVersioned(data []byte, target int, steps map[int]migration, arrayKey string, v any) (migrated bool, err error) {
+	var doc map[string]json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		doc = map[string]json.RawMessage{arrayKey: trimmed}
+	} else if err := json.Unmarshal(data, &doc); err != nil {
+		return false, err
+	}
+
+	var version int
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return false, fmt.Errorf("invalid version field: %w", err)
+		}
+	}
+
+	if version > target {
+		return false, fmt.Errorf("%w (format version %d, this build supports up to %d); upgrade annscraper", errNewerVersion, version, target)
+	}
+
+	for ; version < target; version++ {
+		step, ok := steps[version]
+		if !ok {
+			return false, fmt.Errorf("no migration from format version %d", version)
+		}
+		if err := step(doc); err != nil {
+			return false, fmt.Errorf("migrating from format version %d: %w", version, err)
+		}
+		migrated = true
+	}
+	doc["version"] = json.RawMessage(fmt.Sprint(target))
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return false, err
+	}
+	return migrated, json.Unmarshal(upgraded, v)
+}
+
+// backupFile copies path aside before it is migrated or replaced and returns the backup path.
+func backupFile(path, reason string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	backup := fmt.Sprintf("%s.%s-%s", path, reason, time.Now().Format("20060102T150405"))
+	if err := os.WriteFile(backup, data, 0o644); err != nil {
+		log.Printf("Warning: failed to back up %s: %v", path, err)
+		return ""
+	}
+	return backup
+}
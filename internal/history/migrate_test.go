@@ -0,0 +1,57 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shanehull/annscraper/internal/clock"
+	"github.com/shanehull/annscraper/internal/paths"
+)
+
+func TestDecodeVersioned(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantMigrated bool
+		wantErr      error
+	}{
+		{name: "current", data: `{"version": 1, "notifications": []}`},
+		{name: "unversioned object", data: `{"notifications": []}`, wantMigrated: true},
+		{name: "bare array", data: `[]`, wantMigrated: true},
+		{name: "newer", data: `{"version": 2, "notifications": []}`, wantErr: errNewerVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var file notificationsFile
+			migrated, err := decodeVersioned([]byte(tt.data), notificationsVersion, notificationsMigrations, "notifications", &file)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("decodeVersioned() error = %v, want %v", err, tt.wantErr)
+			}
+			if migrated != tt.wantMigrated {
+				t.Errorf("decodeVersioned() migrated = %t, want %t", migrated, tt.wantMigrated)
+			}
+		})
+	}
+}
+
+func TestNewManagerRejectsNewerHistory(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	dir := t.TempDir()
+	paths.SetDataDir(dir)
+	t.Cleanup(func() { paths.SetDataDir("") })
+
+	path := filepath.Join(dir, historyFileName)
+	newer := []byte(`{"version": 99, "ReportDate": "2026-10-16", "ReportedMatches": {}}`)
+	if err := os.WriteFile(path, newer, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewManager("Australia/Sydney", clock.System{}); !errors.Is(err, errNewerVersion) {
+		t.Fatalf("NewManager() error = %v, want errNewerVersion", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(newer) {
+		t.Errorf("history file was modified: %s", data)
+	}
+}
@@ -0,0 +1,231 @@
+package history
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	notificationsFileName  = "asx_notifications.json"
+	notificationsRetention = 30 * 24 * time.Hour
+
+	shareKeyFileName = "share.key"
+	shareKeySize     = 32
+
+	weeklyWrapFileName = "weekly_wrap_sent"
+)
+
+// StoredNotification is a delivered alert kept so it can be listed and re-sent later.
+// Deleted notifications are hidden from listings but kept until they expire.
+type StoredNotification struct {
+	ID        string               `json:"id"`
+	SentAt    time.Time            `json:"sent_at"`
+	Match     types.AnnotatedMatch `json:"match"`
+	DeletedAt *time.Time           `json:"deleted_at,omitempty"`
+}
+
+func (n StoredNotification) Deleted() bool {
+	return n.DeletedAt != nil
+}
+
+// notificationsFile is the on-disk format of the notifications store.
+type notificationsFile struct {
+	Version       int                  `json:"version"`
+	Notifications []StoredNotification `json:"notifications"`
+}
+
+// NotificationID derives a short stable identifier for a match. The release time is taken in
+// UTC so the ID doesn't depend on the zone the time was parsed in.
+func NotificationID(m types.Match) string {
+	sum := sha256.Sum256([]byte(m.Ticker + "|" + m.Title + "|" + m.DateTime.UTC().Format(time.RFC3339)))
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+func (m *Manager) notificationsFilePath() string {
+	return filepath.Join(filepath.Dir(m.historyFilePath), notificationsFileName)
+}
+
+func (m *Manager) loadNotifications() ([]StoredNotification, error) {
+	data, err := os.ReadFile(m.notificationsFilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notifications file %s: %w", m.notificationsFilePath(), err)
+	}
+
+	var file notificationsFile
+	migrated, err := decodeVersioned(data, notificationsVersion, notificationsMigrations, "notifications", &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notifications file %s: %w", m.notificationsFilePath(), err)
+	}
+	if migrated {
+		backup := backupFile(m.notificationsFilePath(), "pre-migration")
+		if err := m.saveNotifications(file.Notifications); err != nil {
+			return nil, err
+		}
+		log.Printf("Migrated notifications to format version %d (previous file kept as %s).", notificationsVersion, backup)
+	}
+	return file.Notifications, nil
+}
+
+func (m *Manager) saveNotifications(notifications []StoredNotification) error {
+	data, err := json.MarshalIndent(notificationsFile{Version: notificationsVersion, Notifications: notifications}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal notifications: %w", err)
+	}
+
+	if err := os.WriteFile(m.notificationsFilePath(), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write notifications file %s: %w", m.notificationsFilePath(), err)
+	}
+	return nil
+}
+
+// RecordNotifications stores delivered matches, replacing earlier copies with the same ID and
+// dropping notifications older than the retention period.
+func (m *Manager) RecordNotifications(matches []types.AnnotatedMatch) {
+	if len(matches) == 0 {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	notifications, err := m.loadNotifications()
+	if err != nil {
+		log.Printf("Error loading notifications: %v. Starting fresh.", err)
+	}
+
+	now := m.clock.Now()
+	notifications = slices.DeleteFunc(notifications, func(n StoredNotification) bool {
+		return now.Sub(n.SentAt) > notificationsRetention
+	})
+
+	for _, am := range matches {
+		id := NotificationID(am.Match)
+		notifications = slices.DeleteFunc(notifications, func(n StoredNotification) bool { return n.ID == id })
+		notifications = append(notifications, StoredNotification{ID: id, SentAt: now, Match: am})
+	}
+
+	if err := m.saveNotifications(notifications); err != nil {
+		log.Printf("Error saving notifications: %v", err)
+	}
+}
+
+// Notifications returns stored notifications, newest first. Deleted ones are included only if requested.
+func (m *Manager) Notifications(includeDeleted bool) ([]StoredNotification, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	notifications, err := m.loadNotifications()
+	if err != nil {
+		return nil, err
+	}
+
+	if !includeDeleted {
+		notifications = slices.DeleteFunc(notifications, StoredNotification.Deleted)
+	}
+	slices.Reverse(notifications)
+	return notifications, nil
+}
+
+// NotificationIDs returns the IDs of the stored notifications' matches, including deleted
+// ones. IDs are derived afresh, so they compare with NotificationID even for notifications
+// stored by earlier versions.
+func (m *Manager) NotificationIDs() (map[string]bool, error) {
+	notifications, err := m.Notifications(true)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(notifications))
+	for _, n := range notifications {
+		ids[NotificationID(n.Match.Match)] = true
+	}
+	return ids, nil
+}
+
+// Notification looks up a stored notification by ID.
+func (m *Manager) Notification(id string) (*StoredNotification, error) {
+	notifications, err := m.Notifications(true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range notifications {
+		if n.ID == id {
+			return &n, nil
+		}
+	}
+	return nil, fmt.Errorf("notification %s not found", id)
+}
+
+// SetNotificationDeleted soft-deletes or restores a stored notification.
+func (m *Manager) SetNotificationDeleted(id string, deleted bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	notifications, err := m.loadNotifications()
+	if err != nil {
+		return err
+	}
+
+	for i := range notifications {
+		if notifications[i].ID != id {
+			continue
+		}
+		if deleted {
+			now := m.clock.Now()
+			notifications[i].DeletedAt = &now
+		} else {
+			notifications[i].DeletedAt = nil
+		}
+		return m.saveNotifications(notifications)
+	}
+	return fmt.Errorf("notification %s not found", id)
+}
+
+// ShareKey returns the secret used to sign share links, creating it on first use.
+func (m *Manager) ShareKey() ([]byte, error) {
+	path := filepath.Join(filepath.Dir(m.historyFilePath), shareKeyFileName)
+
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) >= shareKeySize {
+		return key, nil
+	}
+
+	key = make([]byte, shareKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate share key: %w", err)
+	}
+	if err := os.WriteFile(path, key, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to save share key: %w", err)
+	}
+	return key, nil
+}
+
+// LastWeeklyWrap returns when the weekly wrap was last sent, or the zero time if never.
+func (m *Manager) LastWeeklyWrap() time.Time {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(m.historyFilePath), weeklyWrapFileName))
+	if err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	return t
+}
+
+// SetLastWeeklyWrap records when the weekly wrap was sent.
+func (m *Manager) SetLastWeeklyWrap(t time.Time) error {
+	return os.WriteFile(filepath.Join(filepath.Dir(m.historyFilePath), weeklyWrapFileName), []byte(t.Format(time.RFC3339)), 0o644)
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/paths"
+)
+
+// Store holds which keywords have been reported for each announcement on a report date, so
+// several instances sharing a store never alert on the same match twice. Keys are
+// "TICKER|Title".
+type Store interface {
+	// Load returns every announcement reported on date with the keywords reported for it.
+	Load(date string) (map[string]map[string]bool, error)
+	// Claim marks the keywords of each key as reported on date and returns, by key, those
+	// that weren't already. It is atomic, so of several instances claiming the same match
+	// only one gets it. Nothing is new once AllKeywords has been recorded for a key.
+	Claim(date string, keywords map[string][]string) (map[string][]string, error)
+	// Clear forgets everything reported on date.
+	Clear(date string) error
+}
+
+// OpenStore returns the store described by spec: "file" (or "") for the history file,
+// "sqlite" or "sqlite:PATH" for a SQLite database, or a redis:// or rediss:// URL.
+func OpenStore(spec string) (Store, error) {
+	switch {
+	case spec == "" || spec == "file":
+		return nil, nil
+	case spec == "sqlite" || strings.HasPrefix(spec, "sqlite:"):
+		path := strings.TrimPrefix(strings.TrimPrefix(spec, "sqlite"), ":")
+		if path == "" {
+			path = filepath.Join(paths.DataDir(), "history.db")
+		}
+		return openSQLiteStore(path)
+	case strings.HasPrefix(spec, "redis://") || strings.HasPrefix(spec, "rediss://"):
+		return newRedisStore(spec)
+	}
+	return nil, fmt.Errorf("unknown history store %q (expected 'file', 'sqlite[:PATH]' or a redis:// URL)", spec)
+}
+
+// filterNew returns the keywords missing from reported.
+func filterNew(reported map[string]bool, keywords []string) []string {
+	if reported[AllKeywords] {
+		return nil
+	}
+	var fresh []string
+	for _, kw := range keywords {
+		if !reported[kw] {
+			fresh = append(fresh, kw)
+		}
+	}
+	return fresh
+}
+
+// fileStore keeps reported matches in the history file. The Manager starts a new History
+// each report date and saves it after changes, so the date is implied and writes are left
+// to the Manager. Calls are made with the Manager's mutex held.
+type fileStore struct {
+	history *History
+}
+
+func (s *fileStore) Load(string) (map[string]map[string]bool, error) {
+	return s.history.ReportedMatches, nil
+}
+
+func (s *fileStore) Claim(_ string, keywords map[string][]string) (map[string][]string, error) {
+	claimed := make(map[string][]string)
+	for key, kws := range keywords {
+		fresh := filterNew(s.history.ReportedMatches[key], kws)
+		if len(fresh) == 0 {
+			continue
+		}
+		if s.history.ReportedMatches[key] == nil {
+			s.history.ReportedMatches[key] = make(map[string]bool)
+		}
+		for _, kw := range fresh {
+			s.history.ReportedMatches[key][kw] = true
+		}
+		claimed[key] = fresh
+	}
+	return claimed, nil
+}
+
+func (s *fileStore) Clear(string) error {
+	s.history.ReportedMatches = make(map[string]map[string]bool)
+	return nil
+}
@@ -0,0 +1,240 @@
+package history
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisTimeout   = 10 * time.Second
+	redisKeyPrefix = "annscraper:history:"
+	redisExpiry    = 3 * 24 * time.Hour // report dates are only consulted on the day
+	redisSeparator = "\x1f"             // between an announcement key and a keyword in set members
+)
+
+// redisStore keeps each report date's matches in a Redis set of "key\x1fkeyword" members,
+// so any number of instances can share dedup state. It speaks just enough RESP to do so.
+type redisStore struct {
+	addr     string
+	tls      bool
+	username string
+	password string
+	db       int
+}
+
+func newRedisStore(rawURL string) (*redisStore, error) {
+	s, err := parseRedisURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail at startup rather than on the first match.
+	if _, err := s.do([][]string{{"PING"}}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parseRedisURL reads redis://[[user]:password@]host[:port][/db], or rediss:// for TLS.
+func parseRedisURL(rawURL string) (*redisStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	s := &redisStore{addr: u.Host, tls: u.Scheme == "rediss"}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+		if s.password == "" && s.username != "" {
+			return nil, fmt.Errorf("invalid Redis URL: user %q has no password (use redis://:PASSWORD@host for the default user)", s.username)
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if s.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q in URL", db)
+		}
+	}
+	return s, nil
+}
+
+func (s *redisStore) Load(date string) (map[string]map[string]bool, error) {
+	replies, err := s.do([][]string{{"SMEMBERS", redisKeyPrefix + date}})
+	if err != nil {
+		return nil, err
+	}
+	members, _ := replies[0].([]any)
+	reported := make(map[string]map[string]bool)
+	for _, m := range members {
+		member, _ := m.(string)
+		key, kw, ok := strings.Cut(member, redisSeparator)
+		if !ok {
+			continue
+		}
+		if reported[key] == nil {
+			reported[key] = make(map[string]bool)
+		}
+		reported[key][kw] = true
+	}
+	return reported, nil
+}
+
+// Claim adds each keyword with its own SADD, whose reply says whether this instance added
+// it, inside MULTI/EXEC so another instance's claim can't interleave.
+func (s *redisStore) Claim(date string, keywords map[string][]string) (map[string][]string, error) {
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+	set := redisKeyPrefix + date
+
+	type claim struct {
+		key, keyword string
+		check        bool // the SISMEMBER for AllKeywords, not an SADD
+	}
+	var claims []claim
+	commands := [][]string{{"MULTI"}}
+	for _, key := range slices.Sorted(maps.Keys(keywords)) {
+		commands = append(commands, []string{"SISMEMBER", set, key + redisSeparator + AllKeywords})
+		claims = append(claims, claim{key: key, check: true})
+		for _, kw := range keywords[key] {
+			commands = append(commands, []string{"SADD", set, key + redisSeparator + kw})
+			claims = append(claims, claim{key: key, keyword: kw})
+		}
+	}
+	commands = append(commands, []string{"EXPIRE", set, strconv.Itoa(int(redisExpiry.Seconds()))}, []string{"EXEC"})
+
+	replies, err := s.do(commands)
+	if err != nil {
+		return nil, err
+	}
+	results, ok := replies[len(replies)-1].([]any)
+	if !ok || len(results) != len(claims)+1 {
+		return nil, errors.New("redis EXEC: transaction aborted")
+	}
+
+	claimed := make(map[string][]string)
+	all := make(map[string]bool)
+	for i, c := range claims {
+		n, _ := results[i].(int64)
+		switch {
+		case c.check:
+			all[c.key] = n == 1
+		case n == 1 && !all[c.key]:
+			claimed[c.key] = append(claimed[c.key], c.keyword)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *redisStore) Clear(date string) error {
+	_, err := s.do([][]string{{"DEL", redisKeyPrefix + date}})
+	return err
+}
+
+// do connects, authenticates and sends commands as a pipeline, returning their replies.
+func (s *redisStore) do(commands [][]string) ([]any, error) {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if s.tls {
+		host, _, _ := net.SplitHostPort(s.addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", s.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %w", s.addr, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	var setup [][]string
+	switch {
+	case s.username != "":
+		setup = append(setup, []string{"AUTH", s.username, s.password})
+	case s.password != "":
+		setup = append(setup, []string{"AUTH", s.password}) // redis://:pass@host, the default user
+	}
+	if s.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.db)})
+	}
+	all := append(setup, commands...)
+
+	w := bufio.NewWriter(conn)
+	for _, args := range all {
+		fmt.Fprintf(w, "*%d\r\n", len(args))
+		for _, a := range args {
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(a), a)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write to Redis: %w", err)
+	}
+
+	r := bufio.NewReader(conn)
+	replies := make([]any, len(all))
+	for i := range all {
+		reply, err := readRESP(r)
+		if err != nil {
+			return nil, fmt.Errorf("redis %s: %w", all[i][0], err)
+		}
+		replies[i] = reply
+	}
+	return replies[len(setup):], nil
+}
+
+// readRESP reads one reply: a string, int64, []any or nil. Error replies are returned as errors.
+func readRESP(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
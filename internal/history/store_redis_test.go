@@ -0,0 +1,247 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis is a single-database Redis server with just the commands the store uses.
+type fakeRedis struct {
+	addr string
+
+	mutex sync.Mutex
+	sets  map[string]map[string]bool
+	auth  [][]string // arguments of each AUTH received
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{addr: ln.Addr().String(), sets: make(map[string]map[string]bool)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		reply, err := readRESP(r)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+
+		var out string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "MULTI":
+			inMulti, out = true, "+OK\r\n"
+		case cmd == "EXEC":
+			f.mutex.Lock()
+			out = fmt.Sprintf("*%d\r\n", len(queued))
+			for _, q := range queued {
+				out += f.exec(q)
+			}
+			f.mutex.Unlock()
+			inMulti, queued = false, nil
+		case inMulti:
+			queued, out = append(queued, args), "+QUEUED\r\n"
+		default:
+			f.mutex.Lock()
+			out = f.exec(args)
+			f.mutex.Unlock()
+		}
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "AUTH":
+		f.auth = append(f.auth, args[1:])
+		return "+OK\r\n"
+	case "SELECT", "EXPIRE":
+		return ":1\r\n"
+	case "SISMEMBER":
+		if f.sets[args[1]][args[2]] {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "SADD":
+		if f.sets[args[1]] == nil {
+			f.sets[args[1]] = make(map[string]bool)
+		}
+		added := 0
+		for _, m := range args[2:] {
+			if !f.sets[args[1]][m] {
+				f.sets[args[1]][m] = true
+				added++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", added)
+	case "SMEMBERS":
+		out := fmt.Sprintf("*%d\r\n", len(f.sets[args[1]]))
+		for m := range f.sets[args[1]] {
+			out += fmt.Sprintf("$%d\r\n%s\r\n", len(m), m)
+		}
+		return out
+	case "DEL":
+		delete(f.sets, args[1])
+		return ":1\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisStoreClaim(t *testing.T) {
+	f := newFakeRedis(t)
+	store, err := newRedisStore("redis://" + f.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testClaim(t, store)
+}
+
+func TestReadRESP(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    any
+		wantErr string
+	}{
+		{name: "simple string", input: "+OK\r\n", want: "OK"},
+		{name: "error", input: "-WRONGPASS invalid password\r\n", wantErr: "WRONGPASS invalid password"},
+		{name: "integer", input: ":42\r\n", want: int64(42)},
+		{name: "negative integer", input: ":-1\r\n", want: int64(-1)},
+		{name: "bulk string", input: "$5\r\nhello\r\n", want: "hello"},
+		{name: "bulk string with CRLF", input: "$4\r\na\r\nb\r\n", want: "a\r\nb"},
+		{name: "empty bulk string", input: "$0\r\n\r\n", want: ""},
+		{name: "null bulk string", input: "$-1\r\n", want: nil},
+		{name: "array", input: "*3\r\n:1\r\n$3\r\nfoo\r\n+bar\r\n", want: []any{int64(1), "foo", "bar"}},
+		{name: "nested array", input: "*2\r\n*1\r\n:1\r\n*0\r\n", want: []any{[]any{int64(1)}, []any{}}},
+		{name: "null array", input: "*-1\r\n", want: nil},
+		{name: "empty line", input: "\r\n", wantErr: "empty reply"},
+		{name: "unknown type", input: "!oops\r\n", wantErr: `unexpected reply "!oops"`},
+		{name: "bad integer", input: ":x\r\n", wantErr: "invalid syntax"},
+		{name: "truncated bulk string", input: "$5\r\nhel", wantErr: "EOF"},
+		{name: "truncated array", input: "*2\r\n:1\r\n", wantErr: "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readRESP(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readRESP() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readRESP() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readRESP() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisStoreAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		userinfo string
+		want     []string // AUTH arguments, nil = no AUTH
+		wantErr  bool
+	}{
+		{name: "none", userinfo: ""},
+		{name: "default user", userinfo: ":secret@", want: []string{"secret"}},
+		{name: "ACL user", userinfo: "alice:secret@", want: []string{"alice", "secret"}},
+		{name: "escaped password", userinfo: ":p%40ss%3Aword@", want: []string{"p@ss:word"}},
+		{name: "user without password", userinfo: "alice@", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeRedis(t)
+			_, err := newRedisStore("redis://" + tt.userinfo + f.addr + "/2")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("newRedisStore() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newRedisStore() error = %v", err)
+			}
+
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+			if tt.want == nil {
+				if len(f.auth) != 0 {
+					t.Errorf("AUTH sent with %q, want none", f.auth)
+				}
+				return
+			}
+			if len(f.auth) != 1 || !reflect.DeepEqual(f.auth[0], tt.want) {
+				t.Errorf("AUTH arguments = %q, want %q", f.auth, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRedisStoreURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		addr    string
+		tls     bool
+		db      int
+		wantErr bool
+	}{
+		{url: "redis://cache.internal", addr: "cache.internal:6379"},
+		{url: "redis://cache.internal:6380/3", addr: "cache.internal:6380", db: 3},
+		{url: "rediss://cache.internal/1", addr: "cache.internal:6379", tls: true, db: 1},
+		{url: "redis://cache.internal/main", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			s, err := parseRedisURL(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRedisURL() = %+v, want an error", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRedisURL() error = %v", err)
+			}
+			if s.addr != tt.addr || s.tls != tt.tls || s.db != tt.db {
+				t.Errorf("parseRedisURL() = %+v, want addr %s, tls %t, db %d", s, tt.addr, tt.tls, tt.db)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sqliteBinary     = "sqlite3"
+	sqliteBusyMillis = 5000 // wait this long for another instance's write to finish
+
+	sqliteSchema = `CREATE TABLE IF NOT EXISTS reported (
+	date    TEXT NOT NULL,
+	key     TEXT NOT NULL,
+	keyword TEXT NOT NULL,
+	PRIMARY KEY (date, key, keyword)
+);
+`
+)
+
+// sqliteStore keeps reported matches in a SQLite database through the sqlite3 command, as
+// the archive does, so instances on one host or a shared volume can share it.
+type sqliteStore struct {
+	path string
+}
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	if _, err := exec.LookPath(sqliteBinary); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH (install sqlite3 to use a SQLite history store): %w", sqliteBinary, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history store directory: %w", err)
+	}
+
+	s := &sqliteStore{path: path}
+	if _, err := s.exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to initialise history store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *sqliteStore) Load(date string) (map[string]map[string]bool, error) {
+	rows, err := s.query("SELECT key, keyword FROM reported WHERE date = " + quote(date) + ";")
+	if err != nil {
+		return nil, err
+	}
+	reported := make(map[string]map[string]bool)
+	for _, r := range rows {
+		if reported[r.Key] == nil {
+			reported[r.Key] = make(map[string]bool)
+		}
+		reported[r.Key][r.Keyword] = true
+	}
+	return reported, nil
+}
+
+// Claim selects the new keywords and inserts them in one immediate transaction, which holds
+// the database's write lock so another instance's claim waits for it.
+func (s *sqliteStore) Claim(date string, keywords map[string][]string) (map[string][]string, error) {
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+
+	var sql strings.Builder
+	sql.WriteString("BEGIN IMMEDIATE;\nCREATE TEMP TABLE claim (key TEXT NOT NULL, keyword TEXT NOT NULL);\n")
+	for key, kws := range keywords {
+		for _, kw := range kws {
+			fmt.Fprintf(&sql, "INSERT INTO claim VALUES (%s, %s);\n", quote(key), quote(kw))
+		}
+	}
+	fmt.Fprintf(&sql, `SELECT DISTINCT key, keyword FROM claim c WHERE NOT EXISTS (
+	SELECT 1 FROM reported r WHERE r.date = %[1]s AND r.key = c.key AND r.keyword IN (c.keyword, %[2]s)
+);
+INSERT OR IGNORE INTO reported (date, key, keyword) SELECT %[1]s, key, keyword FROM claim;
+COMMIT;
+`, quote(date), quote(AllKeywords))
+
+	rows, err := s.query(sql.String())
+	if err != nil {
+		return nil, err
+	}
+	claimed := make(map[string][]string)
+	for _, r := range rows {
+		claimed[r.Key] = append(claimed[r.Key], r.Keyword)
+	}
+	return claimed, nil
+}
+
+func (s *sqliteStore) Clear(date string) error {
+	_, err := s.exec("DELETE FROM reported WHERE date = " + quote(date) + ";")
+	return err
+}
+
+type sqliteRow struct {
+	Key     string `json:"key"`
+	Keyword string `json:"keyword"`
+}
+
+func (s *sqliteStore) query(sql string) ([]sqliteRow, error) {
+	out, err := s.exec(sql, "-json")
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var rows []sqliteRow
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse sqlite3 output: %w", err)
+	}
+	return rows, nil
+}
+
+func (s *sqliteStore) exec(sql string, args ...string) ([]byte, error) {
+	args = append(args, "-bail", "-cmd", fmt.Sprintf(".timeout %d", sqliteBusyMillis), s.path)
+	cmd := exec.Command(sqliteBinary, args...)
+	cmd.Stdin = strings.NewReader(sql)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// quote renders s as an SQL string literal.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,127 @@
+package history
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func testClaim(t *testing.T, store Store) {
+	t.Helper()
+	const date = "2026-10-16"
+	claims := []struct {
+		name     string
+		keywords map[string][]string
+		want     map[string][]string
+	}{
+		{
+			name:     "new",
+			keywords: map[string][]string{"BHP|Results": {"copper", "lithium"}},
+			want:     map[string][]string{"BHP|Results": {"copper", "lithium"}},
+		},
+		{
+			name:     "partly reported",
+			keywords: map[string][]string{"BHP|Results": {"lithium", "nickel"}, "CBA|Update": {"ticker"}},
+			want:     map[string][]string{"BHP|Results": {"nickel"}, "CBA|Update": {"ticker"}},
+		},
+		{
+			name:     "already claimed",
+			keywords: map[string][]string{"BHP|Results": {"copper"}},
+			want:     map[string][]string{},
+		},
+		{
+			name:     "all keywords",
+			keywords: map[string][]string{"RIO|Report": {AllKeywords}},
+			want:     map[string][]string{"RIO|Report": {AllKeywords}},
+		},
+		{
+			name:     "after all keywords",
+			keywords: map[string][]string{"RIO|Report": {"iron ore"}},
+			want:     map[string][]string{},
+		},
+	}
+	for _, c := range claims {
+		got, err := store.Claim(date, c.keywords)
+		if err != nil {
+			t.Fatalf("%s: Claim() error = %v", c.name, err)
+		}
+		for _, kws := range got {
+			slices.Sort(kws)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: Claim() = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	reported, err := store.Load(date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reported) != 3 || !reported["BHP|Results"]["nickel"] || !reported["RIO|Report"][AllKeywords] {
+		t.Errorf("Load() = %v", reported)
+	}
+
+	if err := store.Clear(date); err != nil {
+		t.Fatal(err)
+	}
+	if reported, _ := store.Load(date); len(reported) != 0 {
+		t.Errorf("Load() after Clear() = %v, want empty", reported)
+	}
+}
+
+func TestFileStoreClaim(t *testing.T) {
+	testClaim(t, &fileStore{history: &History{ReportedMatches: make(map[string]map[string]bool)}})
+}
+
+func TestSQLiteStoreClaim(t *testing.T) {
+	if _, err := exec.LookPath(sqliteBinary); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	store, err := openSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testClaim(t, store)
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "''"},
+		{"BHP|Results", "'BHP|Results'"},
+		{"O'Brien's", "'O''Brien''s'"},
+		{"'; DROP TABLE reported; --", "'''; DROP TABLE reported; --'"},
+		{"nul\x00byte", "'nulbyte'"},
+		{"line\nbreak", "'line\nbreak'"},
+	}
+	for _, tt := range tests {
+		if got := quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteStoreQuotedKeys(t *testing.T) {
+	if _, err := exec.LookPath(sqliteBinary); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	store, err := openSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "ABC|Chairman's address'); DELETE FROM reported; --"
+	if _, err := store.Claim("2026-10-16", map[string][]string{key: {"o'clock"}}); err != nil {
+		t.Fatal(err)
+	}
+	reported, err := store.Load("2026-10-16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reported[key]["o'clock"] {
+		t.Errorf("Load() = %v, want %q reported for %q", reported, "o'clock", key)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Package hooks runs user-provided extensions at fixed points of the match pipeline so that
+announcements and matches can be mutated or filtered without forking the scraper.
+*/
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const defaultCommandTimeout = 30 * time.Second
+
+type Stage string
+
+const (
+	PreDownload Stage = "pre-download" // before the PDF is fetched; Announcement is set
+	PostExtract Stage = "post-extract" // after text extraction; Announcement and Text are set
+	PostMatch   Stage = "post-match"   // after keyword/ticker matching; Match and Text are set
+	PreNotify   Stage = "pre-notify"   // before console/email output; Match and Analysis are set
+)
+
+// Payload is the document passed through each hook. Hooks may modify any field and set
+// Skip to drop the item from the pipeline.
+type Payload struct {
+	Stage        Stage               `json:"stage"`
+	Announcement *types.Announcement `json:"announcement,omitempty"`
+	Text         string              `json:"text,omitempty"`
+	Match        *types.Match        `json:"match,omitempty"`
+	Analysis     *ai.AIAnalysis      `json:"analysis,omitempty"`
+	Skip         bool                `json:"skip,omitempty"`
+}
+
+type Hook interface {
+	Run(ctx context.Context, p *Payload) error
+}
+
+// HookFunc adapts an ordinary function to the Hook interface.
+type HookFunc func(ctx context.Context, p *Payload) error
+
+func (f HookFunc) Run(ctx context.Context, p *Payload) error {
+	return f(ctx, p)
+}
+
+// Runner holds the hooks registered for each stage. A nil Runner is valid and runs nothing.
+type Runner struct {
+	hooks map[Stage][]Hook
+}
+
+func NewRunner() *Runner {
+	return &Runner{hooks: make(map[Stage][]Hook)}
+}
+
+func (r *Runner) Register(stage Stage, h Hook) {
+	r.hooks[stage] = append(r.hooks[stage], h)
+}
+
+// Has reports whether any hooks are registered for the stage.
+func (r *Runner) Has(stage Stage) bool {
+	return r != nil && len(r.hooks[stage]) > 0
+}
+
+// Run executes the hooks for p.Stage in registration order and reports whether the item
+// should be kept. A failing hook is logged and skipped, leaving the payload as it was.
+func (r *Runner) Run(ctx context.Context, p *Payload) bool {
+	if !r.Has(p.Stage) {
+		return true
+	}
+
+	stage := p.Stage
+	for _, h := range r.hooks[stage] {
+		before := *p
+		if err := h.Run(ctx, p); err != nil {
+			log.Printf("Warning: %s hook failed: %v", stage, err)
+			*p = before
+			continue
+		}
+		p.Stage = stage
+
+		if p.Skip {
+			return false
+		}
+	}
+	return true
+}
+
+// CommandHook pipes the payload as JSON to a shell command on stdin and reads the
+// (optionally modified) payload back from stdout. Fields missing from the output keep their
+// current values, and empty output leaves the payload unchanged.
+type CommandHook struct {
+	Command string
+	Timeout time.Duration // 0 = 30s
+}
+
+func (h CommandHook) Run(ctx context.Context, p *Payload) error {
+	input, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hook payload: %w", err)
+	}
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = defaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(input)
+
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command %q failed: %v. Stderr: %s", h.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if len(bytes.TrimSpace(out.Bytes())) == 0 {
+		return nil
+	}
+
+	// Decode over a copy so that partial output only overrides the fields it sets. The
+	// pointed-to values are copied too, so a failed decode cannot leave them half-updated.
+	result := *p
+	if p.Announcement != nil {
+		ann := *p.Announcement
+		result.Announcement = &ann
+	}
+	if p.Match != nil {
+		match := *p.Match
+		result.Match = &match
+	}
+	if p.Analysis != nil {
+		analysis := *p.Analysis
+		result.Analysis = &analysis
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return fmt.Errorf("command %q returned invalid JSON: %w", h.Command, err)
+	}
+
+	*p = result
+	return nil
+}
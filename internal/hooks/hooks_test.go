@@ -0,0 +1,57 @@
+package hooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+func TestCommandHookPartialOutput(t *testing.T) {
+	ann := &types.Announcement{Ticker: "BHP", Title: "Quarterly Activities Report"}
+	p := &Payload{
+		Stage:        PostExtract,
+		Announcement: ann,
+		Text:         "production guidance reaffirmed",
+	}
+
+	h := CommandHook{Command: `echo '{"text": "redacted"}'`}
+	if err := h.Run(context.Background(), p); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if p.Text != "redacted" {
+		t.Errorf("Text = %q, want %q", p.Text, "redacted")
+	}
+	if p.Stage != PostExtract {
+		t.Errorf("Stage = %q, want %q", p.Stage, PostExtract)
+	}
+	if p.Announcement == nil || p.Announcement.Ticker != "BHP" || p.Announcement.Title != "Quarterly Activities Report" {
+		t.Errorf("Announcement = %+v, want it unchanged", p.Announcement)
+	}
+}
+
+func TestCommandHookInvalidOutput(t *testing.T) {
+	ann := &types.Announcement{Ticker: "BHP"}
+	p := &Payload{Stage: PreDownload, Announcement: ann}
+
+	h := CommandHook{Command: `echo '{"announcement": {"Ticker": 42}}'`}
+	if err := h.Run(context.Background(), p); err == nil {
+		t.Fatal("Run: expected an error for a mistyped field")
+	}
+	if ann.Ticker != "BHP" {
+		t.Errorf("original announcement Ticker = %q, want it untouched", ann.Ticker)
+	}
+}
+
+func TestCommandHookEmptyOutput(t *testing.T) {
+	p := &Payload{Stage: PreNotify, Text: "unchanged"}
+
+	h := CommandHook{Command: "true"}
+	if err := h.Run(context.Background(), p); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if p.Text != "unchanged" || p.Stage != PreNotify {
+		t.Errorf("payload = %+v, want it unchanged", p)
+	}
+}
@@ -0,0 +1,294 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Email delivery providers selectable with EmailConfig.Provider.
+const (
+	ProviderSMTP     = "smtp"
+	ProviderSendGrid = "sendgrid"
+	ProviderSES      = "ses"
+	ProviderMailgun  = "mailgun"
+)
+
+const (
+	sendGridURL   = "https://api.sendgrid.com/v3/mail/send"
+	mailgunURL    = "https://api.mailgun.net/v3"
+	mailgunEUURL  = "https://api.eu.mailgun.net/v3"
+	sesURLPattern = "https://email.%s.amazonaws.com/v2/email/outbound-emails"
+)
+
+var apiClient = &http.Client{Timeout: 30 * time.Second}
+
+// SendGridSender delivers messages via the SendGrid v3 mail API.
+type SendGridSender struct {
+	cfg EmailConfig
+}
+
+// NewSendGridSender creates a sender using cfg.APIKey.
+func NewSendGridSender(cfg EmailConfig) *SendGridSender {
+	return &SendGridSender{cfg: cfg}
+}
+
+// Send delivers an email with HTML body and plain text fallback.
+func (s *SendGridSender) Send(msg *RenderedMessage) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	type address struct {
+		Email string `json:"email"`
+	}
+	type personalization struct {
+		To  []address `json:"to"`
+		CC  []address `json:"cc,omitempty"`
+		BCC []address `json:"bcc,omitempty"`
+	}
+	addresses := func(emails []string) []address {
+		var list []address
+		for _, e := range emails {
+			list = append(list, address{e})
+		}
+		return list
+	}
+	type content struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+	body := struct {
+		Personalizations []personalization `json:"personalizations"`
+		From             address           `json:"from"`
+		Subject          string            `json:"subject"`
+		Content          []content         `json:"content"`
+	}{
+		Personalizations: []personalization{{
+			To:  addresses(s.cfg.To),
+			CC:  addresses(s.cfg.CC),
+			BCC: addresses(s.cfg.BCC),
+		}},
+		From:    address{s.cfg.FromEmail},
+		Subject: msg.Subject,
+	}
+	// SendGrid requires text/plain to come before text/html.
+	if msg.Text != "" {
+		body.Content = append(body.Content, content{"text/plain", msg.Text})
+	}
+	if msg.HTML != "" {
+		body.Content = append(body.Content, content{"text/html", msg.HTML})
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, sendGridURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	return sendAPIRequest(req, strings.Join(s.cfg.To, ", "), msg.Subject)
+}
+
+// MailgunSender delivers messages via the Mailgun messages API.
+type MailgunSender struct {
+	cfg EmailConfig
+}
+
+// NewMailgunSender creates a sender using cfg.APIKey and cfg.Domain.
+func NewMailgunSender(cfg EmailConfig) *MailgunSender {
+	return &MailgunSender{cfg: cfg}
+}
+
+// Send delivers an email with HTML body and plain text fallback.
+func (s *MailgunSender) Send(msg *RenderedMessage) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	form := url.Values{
+		"from":    {s.cfg.FromEmail},
+		"to":      s.cfg.To,
+		"subject": {msg.Subject},
+	}
+	if len(s.cfg.CC) > 0 {
+		form["cc"] = s.cfg.CC
+	}
+	if len(s.cfg.BCC) > 0 {
+		form["bcc"] = s.cfg.BCC
+	}
+	if msg.Text != "" {
+		form.Set("text", msg.Text)
+	}
+	if msg.HTML != "" {
+		form.Set("html", msg.HTML)
+	}
+
+	base := mailgunURL
+	if strings.EqualFold(s.cfg.Region, "eu") {
+		base = mailgunEUURL
+	}
+	req, err := http.NewRequest(http.MethodPost, base+"/"+s.cfg.Domain+"/messages", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("api", s.cfg.APIKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return sendAPIRequest(req, strings.Join(s.cfg.To, ", "), msg.Subject)
+}
+
+// SESSender delivers messages via the Amazon SES v2 API. Credentials come from the standard
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+type SESSender struct {
+	cfg EmailConfig
+}
+
+// NewSESSender creates a sender for cfg.Region.
+func NewSESSender(cfg EmailConfig) *SESSender {
+	return &SESSender{cfg: cfg}
+}
+
+// Send delivers an email with HTML body and plain text fallback.
+func (s *SESSender) Send(msg *RenderedMessage) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	type text struct {
+		Data    string `json:"Data"`
+		Charset string `json:"Charset"`
+	}
+	body := map[string]*text{}
+	if msg.Text != "" {
+		body["Text"] = &text{msg.Text, "UTF-8"}
+	}
+	if msg.HTML != "" {
+		body["Html"] = &text{msg.HTML, "UTF-8"}
+	}
+	destination := map[string][]string{"ToAddresses": s.cfg.To}
+	if len(s.cfg.CC) > 0 {
+		destination["CcAddresses"] = s.cfg.CC
+	}
+	if len(s.cfg.BCC) > 0 {
+		destination["BccAddresses"] = s.cfg.BCC
+	}
+	payload, err := json.Marshal(map[string]any{
+		"FromEmailAddress": s.cfg.FromEmail,
+		"Destination":      destination,
+		"Content": map[string]any{
+			"Simple": map[string]any{
+				"Subject": text{msg.Subject, "UTF-8"},
+				"Body":    body,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(sesURLPattern, s.cfg.Region), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signAWSRequest(req, payload, s.cfg.Region, "ses", time.Now())
+
+	return sendAPIRequest(req, strings.Join(s.cfg.To, ", "), msg.Subject)
+}
+
+// AWSCredentialsAvailable reports whether SES credentials are set in the environment.
+func AWSCredentialsAvailable() bool {
+	return os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != ""
+}
+
+// signAWSRequest adds AWS Signature Version 4 headers to req.
+func signAWSRequest(req *http.Request, payload []byte, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signed := "content-type;host;x-amz-content-sha256;x-amz-date"
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+		signed += ";x-amz-security-token"
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range strings.Split(signed, ";") {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", h, strings.TrimSpace(value))
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+os.Getenv("AWS_SECRET_ACCESS_KEY")), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		os.Getenv("AWS_ACCESS_KEY_ID"), scope, signed, signature))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// sendAPIRequest performs an email API request, logging the outcome like SMTPSender does.
+func sendAPIRequest(req *http.Request, to, subject string) error {
+	resp, err := apiClient.Do(req)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			err = fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+		}
+	}
+	if err != nil {
+		log.Printf("Email error: failed to send to %s (Subject: %s): %v", to, subject, err)
+		return err
+	}
+
+	log.Printf("Email sent: %s", subject)
+	return nil
+}
@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ansiDim    = "\033[2m"
+	ansiBold   = "\033[1m"
+	ansiReset  = "\033[0m"
+	ansiCyan   = "\033[36m"
+	ansiYellow = "\033[33m"
+	ansiGreen  = "\033[32m"
+	ansiRed    = "\033[31m"
+	ansiOrange = "\033[38;5;208m"
+)
+
+// Console report colours, empty when colour is disabled.
+var dim, bold, reset, cyan, yellow, green, red, orange string
+
+const (
+	defaultRuleWidth = 42
+	minRuleWidth     = 20
+	minWrapWidth     = 20 // narrower text is left unwrapped rather than split a word per line
+)
+
+// Console controls how reports are drawn on the terminal.
+type Console struct {
+	Color   bool // ANSI colours and bold
+	Unicode bool // box drawing characters and symbols, otherwise plain ASCII
+	Width   int  // columns to wrap reports to, 0 = don't wrap
+}
+
+var (
+	console     Console
+	asciiGlyphs *strings.Replacer // nil when the terminal supports Unicode
+)
+
+func init() {
+	SetConsole(DetectConsole(false))
+}
+
+// DetectConsole describes stdout: colour is disabled by noColor, NO_COLOR or TERM=dumb,
+// Unicode needs a UTF-8 locale, and the width is only set when stdout is a terminal.
+func DetectConsole(noColor bool) Console {
+	c := Console{
+		Color:   !noColor && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb",
+		Unicode: utf8Locale(),
+	}
+	if IsTerminal(os.Stdout) {
+		c.Width = terminalWidth(os.Stdout)
+	}
+	return c
+}
+
+// SetConsole changes how console reports are drawn.
+func SetConsole(c Console) {
+	console = c
+
+	dim, bold, reset, cyan, yellow, green, red, orange = "", "", "", "", "", "", "", ""
+	if c.Color {
+		dim, bold, reset = ansiDim, ansiBold, ansiReset
+		cyan, yellow, green, red, orange = ansiCyan, ansiYellow, ansiGreen, ansiRed, ansiOrange
+	}
+
+	asciiGlyphs = nil
+	if !c.Unicode {
+		asciiGlyphs = strings.NewReplacer(
+			"─", "-", "│", "|", "┌", "+", "└", "+",
+			"═", "=", "║", "|", "╔", "+", "╗", "+", "╚", "+", "╝", "+",
+			"•", "*", "▸", ">", "⚡", "!", "✓", "+", "✗", "x",
+		)
+	}
+}
+
+// IsTerminal reports whether f is a terminal rather than a file or pipe.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the terminal's width in columns, preferring $COLUMNS, or 0 if unknown.
+func terminalWidth(f *os.File) int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return terminalColumns(f)
+}
+
+// utf8Locale reports whether the locale's character set is UTF-8. Windows terminals are
+// assumed to be, as Go writes to the console as UTF-16.
+func utf8Locale() bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
+
+// printf writes a console report line, replacing Unicode symbols when they aren't supported.
+func printf(format string, a ...any) {
+	s := fmt.Sprintf(format, a...)
+	if asciiGlyphs != nil {
+		s = asciiGlyphs.Replace(s)
+	}
+	fmt.Print(s)
+}
+
+// ruleWidth is the width of horizontal rules and the match box, narrowed to fit the terminal.
+func ruleWidth() int {
+	if console.Width > 0 {
+		return max(minRuleWidth, min(defaultRuleWidth, console.Width-2))
+	}
+	return defaultRuleWidth
+}
+
+func rule() string {
+	return strings.Repeat("─", ruleWidth())
+}
+
+// printWrapped prints text inside the match box after prefix, wrapping it to the terminal
+// width with continuation lines aligned under the start of the text.
+func printWrapped(prefix, text string) {
+	indent := utf8.RuneCountInString(prefix)
+	for i, line := range wrapText(text, console.Width-1-indent) {
+		if i > 0 {
+			prefix = strings.Repeat(" ", indent)
+		}
+		printf("%s│%s%s%s\n", dim, reset, prefix, line)
+	}
+}
+
+// wrapText splits text into lines of at most width runes at word boundaries. Words longer
+// than width are kept whole.
+func wrapText(text string, width int) []string {
+	if width < minWrapWidth || utf8.RuneCountInString(text) <= width {
+		return []string{text}
+	}
+
+	var lines []string
+	var line strings.Builder
+	lineLen := 0
+	for word := range strings.FieldsSeq(text) {
+		n := utf8.RuneCountInString(word)
+		if lineLen > 0 && lineLen+1+n > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			line.WriteByte(' ')
+			lineLen++
+		}
+		line.WriteString(word)
+		lineLen += n
+	}
+	return append(lines, line.String())
+}
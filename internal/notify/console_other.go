@@ -0,0 +1,10 @@
+//go:build !linux
+
+package notify
+
+import "os"
+
+// terminalColumns is unknown on this platform without $COLUMNS.
+func terminalColumns(*os.File) int {
+	return 0
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	desktopTimeout  = 10 * time.Second
+	desktopBodySize = 240
+)
+
+// DesktopRenderer renders a short notification suitable for an OS popup.
+type DesktopRenderer struct{}
+
+// NewDesktopRenderer creates a renderer for desktop popups.
+func NewDesktopRenderer() *DesktopRenderer {
+	return &DesktopRenderer{}
+}
+
+// Render produces a popup with the ticker as subject and a brief body.
+func (r *DesktopRenderer) Render(data NotificationData) (*RenderedMessage, error) {
+	m := data.Match
+
+	subject := "ASX Alert: " + m.Tickers()
+	if m.IsPriceSensitive {
+		subject += " ⚡"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(m.Title)
+	if len(m.KeywordsFound) > 0 {
+		sb.WriteString(fmt.Sprintf("\nKeywords: %s", strings.Join(m.KeywordsFound, ", ")))
+	}
+	if data.Analysis != nil && len(data.Analysis.Summary) > 0 {
+		sb.WriteString("\n" + data.Analysis.Summary[0])
+	}
+
+	body := sb.String()
+	if runes := []rune(body); len(runes) > desktopBodySize {
+		body = string(runes[:desktopBodySize]) + "..."
+	}
+
+	return &RenderedMessage{
+		Subject: subject,
+		Text:    body,
+		URL:     m.DocumentURL(),
+	}, nil
+}
+
+// DesktopSender shows messages as native desktop notifications.
+type DesktopSender struct{}
+
+// NewDesktopSender creates a sender for the current operating system.
+func NewDesktopSender() *DesktopSender {
+	return &DesktopSender{}
+}
+
+// Send displays the message using notify-send (Linux), osascript (macOS) or a PowerShell toast (Windows).
+func (s *DesktopSender) Send(msg *RenderedMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), desktopTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=annscraper", msg.Subject, msg.Text)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptQuote(msg.Text), appleScriptQuote(msg.Subject))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(msg.Subject, msg.Text))
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		err = fmt.Errorf("desktop notification failed: %v. Stderr: %s", err, strings.TrimSpace(stderr.String()))
+		log.Printf("Desktop error: %s (Subject: %s)", err, msg.Subject)
+		return err
+	}
+
+	return nil
+}
+
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func windowsToastScript(title, body string) string {
+	return fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode(%s)) | Out-Null
+$text.Item(1).AppendChild($template.CreateTextNode(%s)) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('annscraper').Show($toast)`,
+		powershellQuote(title), powershellQuote(body))
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// digestView is the data passed to the digest template.
+type digestView struct {
+	At      string
+	Matches []types.AnnotatedMatch
+}
+
+// RenderDigest renders queued matches as a single HTML email with a plain text alternative.
+func RenderDigest(matches []types.AnnotatedMatch, at time.Time) (*RenderedMessage, error) {
+	view := digestView{At: displayTime(at, "02 Jan 2006 3:04 PM"), Matches: matches}
+
+	var htmlBuf bytes.Buffer
+	tmpl := template.Must(template.New("digest").Funcs(emailFuncs).Parse(digestHTMLTemplate))
+	if err := tmpl.Execute(&htmlBuf, view); err != nil {
+		return nil, fmt.Errorf("failed to render digest template: %w", err)
+	}
+
+	return &RenderedMessage{
+		Subject: fmt.Sprintf("ASX Digest: %d match(es) to %s", len(matches), view.At),
+		Text:    renderDigestText(view),
+		HTML:    htmlBuf.String(),
+	}, nil
+}
+
+func renderDigestText(view digestView) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "ASX DIGEST: %s\n", view.At)
+	sb.WriteString(strings.Repeat("=", 50) + "\n\n")
+
+	for _, am := range view.Matches {
+		m := am.Match
+		flag := ""
+		if m.IsPriceSensitive {
+			flag = " ⚡"
+		}
+		fmt.Fprintf(&sb, "%s - %s%s\n", m.Tickers(), m.Title, flag)
+		fmt.Fprintf(&sb, "%s  %s\n", displayTime(m.DateTime, "02 Jan 3:04 PM"), m.DocumentURL())
+		if len(m.KeywordsFound) > 0 {
+			fmt.Fprintf(&sb, "Keywords: %s\n", strings.Join(m.KeywordsFound, ", "))
+		}
+		if am.Analysis != nil {
+			fmt.Fprintf(&sb, "AI Score: %d/100\n", am.Analysis.RelevanceScore)
+			for _, s := range am.Analysis.Summary {
+				fmt.Fprintf(&sb, "• %s\n", s)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// EmailDigest sends queued matches as one email. It returns an error so the queue is kept
+// for the next attempt.
+func EmailDigest(matches []types.AnnotatedMatch, at time.Time, cfg EmailConfig) error {
+	msg, err := RenderDigest(matches, at)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Emailing digest of %d matches (%s)", len(matches), cfg.Transport())
+	return NewEmailSender(cfg).Send(msg)
+}
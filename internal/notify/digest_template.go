@@ -0,0 +1,118 @@
+package notify
+
+const digestHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>ASX Digest</title>
+  <style>
+    body {
+      margin: 0;
+      padding: 24px;
+      background-color: #f3f4f6;
+      font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif;
+      color: #111827;
+      line-height: 1.5;
+    }
+
+    .container {
+      max-width: 640px;
+      margin: 0 auto;
+      background: #ffffff;
+      border-radius: 8px;
+      border: 1px solid #e5e7eb;
+      overflow: hidden;
+    }
+
+    .header {
+      padding: 20px 24px;
+      background: linear-gradient(135deg, #463737 0%, #37393b 100%);
+      color: #ffffff;
+    }
+
+    .header h1 {
+      font-size: 22px;
+      margin: 0 0 4px 0;
+    }
+
+    .header .period {
+      font-size: 14px;
+      opacity: 0.9;
+    }
+
+    .section {
+      padding: 16px 24px;
+      border-top: 1px solid #e5e7eb;
+    }
+
+    .section-title {
+      font-size: 13px;
+      font-weight: 600;
+      text-transform: uppercase;
+      letter-spacing: 0.05em;
+      color: #6b7280;
+      margin-bottom: 8px;
+    }
+
+    table {
+      width: 100%;
+      border-collapse: collapse;
+      font-size: 14px;
+    }
+
+    td {
+      padding: 4px 0;
+      vertical-align: top;
+    }
+
+    .ticker {
+      font-weight: 700;
+      width: 70px;
+    }
+
+    .muted {
+      color: #6b7280;
+      font-size: 13px;
+    }
+
+    .tag {
+      display: inline-block;
+      padding: 1px 6px;
+      border-radius: 4px;
+      background: #f3f4f6;
+      font-size: 12px;
+      color: #374151;
+    }
+
+    a {
+      color: #2563eb;
+      text-decoration: none;
+    }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <h1>ASX Digest</h1>
+      <div class="period">{{len .Matches}} match(es) to {{.At}}</div>
+    </div>
+
+    {{range .Matches}}
+    <div class="section">
+      <table>
+        <tr>
+          <td class="ticker"><a href="{{.Match.DocumentURL}}">{{.Match.Tickers}}</a></td>
+          <td>
+            {{.Match.Title}}{{if .Match.IsPriceSensitive}} <span class="tag">⚡ Price Sensitive</span>{{end}}
+            <div class="muted">{{displayTime .Match.DateTime "02 Jan 3:04 PM"}}{{with .Match.KeywordsFound}} · {{range $i, $k := .}}{{if $i}}, {{end}}{{$k}}{{end}}{{end}}{{with .Analysis}} · score {{.RelevanceScore}}{{end}}</div>
+            {{with .Analysis}}{{range .Summary}}<div>• {{.}}</div>{{end}}{{end}}
+          </td>
+        </tr>
+      </table>
+    </div>
+    {{end}}
+  </div>
+</body>
+</html>
+`
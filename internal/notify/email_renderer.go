@@ -4,32 +4,137 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"os"
+	"path/filepath"
 	"strings"
+	texttemplate "text/template"
+
+	"github.com/shanehull/annscraper/internal/ai"
+)
+
+// File names looked up in the directory passed to LoadEmailTemplates.
+const (
+	emailHTMLFile    = "email.html"
+	emailTextFile    = "email.txt"
+	emailSubjectFile = "subject.txt"
+)
+
+var emailFuncs = template.FuncMap{
+	"companyProfile": companyProfile,
+	"displayTime":    displayTime,
+	"join":           strings.Join,
+}
+
+// Custom templates set by LoadEmailTemplates; nil keeps the built-in layout.
+var (
+	customHTMLTmpl    *template.Template
+	customTextTmpl    *texttemplate.Template
+	customSubjectTmpl *texttemplate.Template
 )
 
+// LoadEmailTemplates overrides the built-in email layout with templates from dir: email.html,
+// email.txt and subject.txt, each optional. Templates receive NotificationData ({{.Match}},
+// {{.Analysis}}) and are test-rendered so mistakes surface at startup rather than mid-scan.
+func LoadEmailTemplates(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	var found bool
+	if data, ok, err := readTemplateFile(dir, emailHTMLFile); err != nil {
+		return err
+	} else if ok {
+		t, err := template.New(emailHTMLFile).Funcs(emailFuncs).Parse(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", emailHTMLFile, err)
+		}
+		customHTMLTmpl, found = t, true
+	}
+
+	for _, name := range []string{emailTextFile, emailSubjectFile} {
+		data, ok, err := readTemplateFile(dir, name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		t, err := texttemplate.New(name).Funcs(texttemplate.FuncMap(emailFuncs)).Parse(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if name == emailTextFile {
+			customTextTmpl = t
+		} else {
+			customSubjectTmpl = t
+		}
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("no %s, %s or %s in %s", emailHTMLFile, emailTextFile, emailSubjectFile, dir)
+	}
+
+	sample := NotificationData{Analysis: &ai.AIAnalysis{}}
+	if _, err := NewHTMLEmailRenderer().Render(sample); err != nil {
+		return fmt.Errorf("email template check failed: %w", err)
+	}
+	return nil
+}
+
+func readTemplateFile(dir, name string) (string, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read email template: %w", err)
+	}
+	return string(data), true, nil
+}
+
 // HTMLEmailRenderer renders notifications as HTML emails with a plain text fallback.
 type HTMLEmailRenderer struct {
 	tmpl *template.Template
 }
 
-// NewHTMLEmailRenderer creates a renderer with the default email template.
+// NewHTMLEmailRenderer creates a renderer with the default or custom email template.
 func NewHTMLEmailRenderer() *HTMLEmailRenderer {
-	t := template.Must(template.New("email").Parse(emailHTMLTemplate))
+	if customHTMLTmpl != nil {
+		return &HTMLEmailRenderer{tmpl: customHTMLTmpl}
+	}
+	t := template.Must(template.New("email").Funcs(emailFuncs).Parse(emailHTMLTemplate))
 	return &HTMLEmailRenderer{tmpl: t}
 }
 
 // Render produces an HTML email with plain text alternative.
 func (r *HTMLEmailRenderer) Render(data NotificationData) (*RenderedMessage, error) {
-	subject := fmt.Sprintf("ASX Alert: %s - %s", data.Match.Ticker, data.Match.Title)
+	subject := fmt.Sprintf("ASX Alert: %s - %s", data.Match.Tickers(), data.Match.Title)
+	if customSubjectTmpl != nil {
+		var sb strings.Builder
+		if err := customSubjectTmpl.Execute(&sb, data); err != nil {
+			return nil, fmt.Errorf("failed to render subject template: %w", err)
+		}
+		subject = strings.TrimSpace(sb.String())
+	}
 
 	var htmlBuf bytes.Buffer
 	if err := r.tmpl.Execute(&htmlBuf, data); err != nil {
 		return nil, fmt.Errorf("failed to render HTML template: %w", err)
 	}
 
+	text := renderPlainText(data)
+	if customTextTmpl != nil {
+		var sb strings.Builder
+		if err := customTextTmpl.Execute(&sb, data); err != nil {
+			return nil, fmt.Errorf("failed to render text template: %w", err)
+		}
+		text = sb.String()
+	}
+
 	return &RenderedMessage{
 		Subject: subject,
-		Text:    renderPlainText(data),
+		Text:    text,
 		HTML:    htmlBuf.String(),
 	}, nil
 }
@@ -39,21 +144,59 @@ func renderPlainText(data NotificationData) string {
 	m := data.Match
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("%s - %s\n", m.Ticker, m.Title))
+	sb.WriteString(fmt.Sprintf("%s - %s\n", m.Tickers(), m.Title))
 	sb.WriteString(strings.Repeat("=", 50) + "\n\n")
 
+	if m.CompanyName != "" {
+		sb.WriteString(m.CompanyName + "\n\n")
+	}
+
 	if m.IsPriceSensitive {
 		sb.WriteString("⚡ PRICE SENSITIVE\n\n")
 	}
 
-	sb.WriteString(fmt.Sprintf("Date: %s\n", m.DateTime.Format("02 Jan 2006 3:04 PM")))
-	sb.WriteString(fmt.Sprintf("URL: %s\n", m.PDFURL))
+	sb.WriteString(fmt.Sprintf("Date: %s\n", displayTime(m.DateTime, "02 Jan 2006 3:04 PM")))
+	sb.WriteString(fmt.Sprintf("URL: %s\n", m.DocumentURL()))
 
 	if len(m.KeywordsFound) > 0 {
 		sb.WriteString(fmt.Sprintf("Keywords: %s\n", strings.Join(m.KeywordsFound, ", ")))
 	}
+	if page := m.Page(); page > 0 {
+		sb.WriteString(fmt.Sprintf("Found on page %d\n", page))
+	}
+	if !m.Geo.Empty() {
+		sb.WriteString(fmt.Sprintf("Location: %s\n", m.Geo))
+	}
+	if company := companyProfile(m.Announcement); company != "" {
+		sb.WriteString(fmt.Sprintf("Company: %s\n", company))
+	}
+	if m.Reaction != nil {
+		sb.WriteString(fmt.Sprintf("Reaction: %s\n", m.Reaction))
+	}
+	if m.Short != nil {
+		sb.WriteString(fmt.Sprintf("Shorts: %s\n", m.Short))
+	}
+	if h := m.FollowsHalt; h != nil {
+		sb.WriteString(fmt.Sprintf("Follows trading halt: %s (%s)\n", h.Title, displayTime(h.DateTime, "02 Jan 2006 3:04 PM")))
+	}
 	sb.WriteString("\n")
 
+	if m.Insider != nil {
+		sb.WriteString("INSIDER ACTIVITY: " + strings.ToUpper(m.Insider.Direction()) + "\n")
+		sb.WriteString(strings.Repeat("-", 20) + "\n")
+		sb.WriteString(m.Insider.Summary() + "\n")
+		if m.Insider.HeldAfter != "" {
+			sb.WriteString("Holding after change: " + m.Insider.HeldAfter + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if m.Changes != nil {
+		sb.WriteString("WHAT CHANGED\n")
+		sb.WriteString(strings.Repeat("-", 20) + "\n")
+		sb.WriteString(m.Changes.Summary() + "\n\n")
+	}
+
 	if m.Context != "" {
 		sb.WriteString("CONTEXT\n")
 		sb.WriteString(strings.Repeat("-", 20) + "\n")
@@ -61,6 +204,18 @@ func renderPlainText(data NotificationData) string {
 	}
 
 	if data.Analysis != nil {
+		sb.WriteString(fmt.Sprintf("AI Score: %d/100 (confidence %d)\n\n", data.Analysis.RelevanceScore, data.Analysis.Confidence))
+
+		if fit := data.Analysis.ThesisFit; fit != nil {
+			verdict := "FITS THESIS"
+			if !fit.Fits {
+				verdict = "DOES NOT FIT THESIS"
+			}
+			sb.WriteString(verdict + "\n")
+			sb.WriteString(strings.Repeat("-", 20) + "\n")
+			sb.WriteString(fit.Reason + "\n\n")
+		}
+
 		if len(data.Analysis.Summary) > 0 {
 			sb.WriteString("AI SUMMARY\n")
 			sb.WriteString(strings.Repeat("-", 20) + "\n")
@@ -79,6 +234,15 @@ func renderPlainText(data NotificationData) string {
 			sb.WriteString("\n")
 		}
 
+		if len(data.Analysis.KeyDates) > 0 {
+			sb.WriteString("KEY DATES\n")
+			sb.WriteString(strings.Repeat("-", 20) + "\n")
+			for _, d := range data.Analysis.KeyDates {
+				sb.WriteString(fmt.Sprintf("• %s [%s] %s\n", d.Date, d.Type, d.Event))
+			}
+			sb.WriteString("\n")
+		}
+
 	}
 
 	return sb.String()
@@ -1,42 +1,85 @@
 package notify
 
 import (
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	gomail "gopkg.in/mail.v2"
 )
 
-// EmailConfig holds SMTP configuration for sending emails.
+// EmailConfig holds configuration for sending emails via SMTP or an email API.
 type EmailConfig struct {
+	Provider   string // ProviderSMTP (default), ProviderSendGrid, ProviderSES or ProviderMailgun
 	SMTPServer string
 	SMTPPort   int
 	SMTPUser   string
 	SMTPPass   string
 	FromEmail  string
-	ToEmail    string
+	To         []string
+	CC         []string
+	BCC        []string
+	OAuth2     OAuth2Config // authenticate with XOAUTH2 instead of SMTPPass when enabled
+	APIKey     string       // SendGrid or Mailgun API key
+	Domain     string       // Mailgun sending domain
+	Region     string       // SES region, or "eu" for Mailgun's EU endpoint
 	Enabled    bool
 }
 
-// EmailSender delivers messages via SMTP.
-type EmailSender struct {
+// Transport describes how emails are delivered, for log messages.
+func (c EmailConfig) Transport() string {
+	switch c.Provider {
+	case ProviderSendGrid:
+		return "SendGrid"
+	case ProviderSES:
+		return "SES: " + c.Region
+	case ProviderMailgun:
+		return "Mailgun: " + c.Domain
+	default:
+		return fmt.Sprintf("SMTP: %s:%d", c.SMTPServer, c.SMTPPort)
+	}
+}
+
+// NewEmailSender creates a sender for the configured provider.
+func NewEmailSender(cfg EmailConfig) Sender {
+	switch cfg.Provider {
+	case ProviderSendGrid:
+		return NewSendGridSender(cfg)
+	case ProviderSES:
+		return NewSESSender(cfg)
+	case ProviderMailgun:
+		return NewMailgunSender(cfg)
+	default:
+		return NewSMTPSender(cfg)
+	}
+}
+
+// SMTPSender delivers messages via SMTP.
+type SMTPSender struct {
 	cfg EmailConfig
 }
 
-// NewEmailSender creates a sender with the given SMTP configuration.
-func NewEmailSender(cfg EmailConfig) *EmailSender {
-	return &EmailSender{cfg: cfg}
+// NewSMTPSender creates a sender with the given SMTP configuration.
+func NewSMTPSender(cfg EmailConfig) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
 }
 
 // Send delivers an email with HTML body and plain text fallback.
-func (s *EmailSender) Send(msg *RenderedMessage) error {
+func (s *SMTPSender) Send(msg *RenderedMessage) error {
 	if !s.cfg.Enabled {
 		return nil
 	}
 
 	m := gomail.NewMessage()
 	m.SetHeader("From", s.cfg.FromEmail)
-	m.SetHeader("To", s.cfg.ToEmail)
+	m.SetHeader("To", s.cfg.To...)
+	if len(s.cfg.CC) > 0 {
+		m.SetHeader("Cc", s.cfg.CC...)
+	}
+	if len(s.cfg.BCC) > 0 {
+		m.SetHeader("Bcc", s.cfg.BCC...)
+	}
 	m.SetHeader("Subject", msg.Subject)
 
 	if msg.HTML != "" && msg.Text != "" {
@@ -50,9 +93,12 @@ func (s *EmailSender) Send(msg *RenderedMessage) error {
 
 	dialer := gomail.NewDialer(s.cfg.SMTPServer, s.cfg.SMTPPort, s.cfg.SMTPUser, s.cfg.SMTPPass)
 	dialer.Timeout = 10 * time.Second
+	if s.cfg.OAuth2.Enabled() {
+		dialer.Auth = &xoauth2Auth{username: s.cfg.SMTPUser, config: s.cfg.OAuth2}
+	}
 
 	if err := dialer.DialAndSend(m); err != nil {
-		log.Printf("Email error: failed to send to %s (Subject: %s): %v", s.cfg.ToEmail, msg.Subject, err)
+		log.Printf("Email error: failed to send to %s (Subject: %s): %v", strings.Join(s.cfg.To, ", "), msg.Subject, err)
 		return err
 	}
 
@@ -1,12 +1,7 @@
 package notify
 
-const emailHTMLTemplate = `<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8" />
-  <meta name="viewport" content="width=device-width, initial-scale=1" />
-  <title>{{.Match.Ticker}} – {{.Match.Title}}</title>
-  <style>
+// emailStyle is the stylesheet shared by match emails and HTML reports.
+const emailStyle = `  <style>
     body {
       margin: 0;
       padding: 24px;
@@ -38,6 +33,13 @@ const emailHTMLTemplate = `<!DOCTYPE html>
       margin-bottom: 4px;
     }
 
+    .company {
+      font-size: 15px;
+      font-weight: 400;
+      letter-spacing: normal;
+      opacity: 0.85;
+    }
+
     .title {
       font-size: 15px;
       opacity: 0.9;
@@ -176,11 +178,13 @@ const emailHTMLTemplate = `<!DOCTYPE html>
       text-decoration: none;
     }
   </style>
-</head>
-<body>
-  <div class="container">
+`
+
+// emailMatchTemplate defines "match", the body of a single match card, shared by match
+// emails and HTML reports.
+const emailMatchTemplate = `{{define "match"}}
     <div class="header">
-      <div class="ticker">{{.Match.Ticker}}</div>
+      <div class="ticker">{{.Match.Tickers}}{{with .Match.CompanyName}} <span class="company">{{.}}</span>{{end}}</div>
       <div class="title">{{.Match.Title}}</div>
       {{if .Match.IsPriceSensitive}}
       <span class="badge">⚡ Price Sensitive</span>
@@ -192,8 +196,14 @@ const emailHTMLTemplate = `<!DOCTYPE html>
       <div class="meta-grid">
         <div class="meta-row">
           <div class="meta-label">Date</div>
-          <div class="meta-value">{{.Match.DateTime.Format "02 Jan 2006 3:04 PM"}}</div>
+          <div class="meta-value">{{displayTime .Match.DateTime "02 Jan 2006 3:04 PM"}}</div>
+        </div>
+        {{if .Analysis}}
+        <div class="meta-row">
+          <div class="meta-label">AI Score</div>
+          <div class="meta-value">{{.Analysis.RelevanceScore}}/100 (confidence {{.Analysis.Confidence}})</div>
         </div>
+        {{end}}
         {{if .Match.KeywordsFound}}
         <div class="meta-row">
           <div class="meta-label">Keywords</div>
@@ -206,12 +216,62 @@ const emailHTMLTemplate = `<!DOCTYPE html>
           </div>
         </div>
         {{end}}
+        {{with .Match.Page}}
+        <div class="meta-row">
+          <div class="meta-label">Page</div>
+          <div class="meta-value">found on page {{.}}</div>
+        </div>
+        {{end}}
+        {{if not .Match.Geo.Empty}}
+        <div class="meta-row">
+          <div class="meta-label">Location</div>
+          <div class="meta-value">{{.Match.Geo}}</div>
+        </div>
+        {{end}}
+        {{with companyProfile .Match.Announcement}}
+        <div class="meta-row">
+          <div class="meta-label">Company</div>
+          <div class="meta-value">{{.}}</div>
+        </div>
+        {{end}}
+        {{with .Match.Reaction}}
+        <div class="meta-row">
+          <div class="meta-label">Reaction</div>
+          <div class="meta-value">{{.String}}</div>
+        </div>
+        {{end}}
+        {{with .Match.Short}}
+        <div class="meta-row">
+          <div class="meta-label">Shorts</div>
+          <div class="meta-value">{{.String}}</div>
+        </div>
+        {{end}}
+        {{with .Match.FollowsHalt}}
+        <div class="meta-row">
+          <div class="meta-label">Follows Halt</div>
+          <div class="meta-value">{{.Title}} ({{displayTime .DateTime "02 Jan 2006 3:04 PM"}})</div>
+        </div>
+        {{end}}
       </div>
-      <a href="{{.Match.PDFURL}}" class="cta-button" target="_blank" rel="noopener">
+      <a href="{{.Match.DocumentURL}}" class="cta-button" target="_blank" rel="noopener">
         View ASX Announcement →
       </a>
     </div>
 
+    {{with .Match.Insider}}
+    <div class="section">
+      <div class="section-title">Insider Activity · {{.Direction}}</div>
+      <div class="context-box">{{.Summary}}{{if .HeldAfter}}<br />Holding after change: {{.HeldAfter}}{{end}}</div>
+    </div>
+    {{end}}
+
+    {{with .Match.Changes}}
+    <div class="section">
+      <div class="section-title">What Changed · since <a href="{{.PreviousURL}}">{{.PreviousTitle}}</a> ({{.PreviousDate.Format "02 Jan 2006"}})</div>
+      <div class="context-box">{{range .Added}}+ {{.}}<br />{{end}}{{range .Removed}}- {{.}}<br />{{end}}</div>
+    </div>
+    {{end}}
+
     {{if .Match.Context}}
     <div class="section">
       <div class="section-title">Context Snippet</div>
@@ -220,6 +280,13 @@ const emailHTMLTemplate = `<!DOCTYPE html>
     {{end}}
 
     {{if .Analysis}}
+      {{with .Analysis.ThesisFit}}
+      <div class="section">
+        <div class="section-title">{{if .Fits}}Fits Thesis{{else}}Does Not Fit Thesis{{end}}</div>
+        <div class="context-box">{{.Reason}}</div>
+      </div>
+      {{end}}
+
       {{if .Analysis.Summary}}
       <div class="section">
         <div class="section-title">AI Summary</div>
@@ -244,7 +311,34 @@ const emailHTMLTemplate = `<!DOCTYPE html>
         </ul>
       </div>
       {{end}}
+
+      {{if .Analysis.KeyDates}}
+      <div class="section">
+        <div class="section-title">Key Dates</div>
+        <ul class="catalyst-list">
+          {{range .Analysis.KeyDates}}
+          <li>
+            <strong>{{.Date}}</strong>
+            <span class="catalyst-category">{{.Type}}</span>
+            <span>{{.Event}}</span>
+          </li>
+          {{end}}
+        </ul>
+      </div>
+      {{end}}
     {{end}}
+{{end}}`
+
+const emailHTMLTemplate = emailMatchTemplate + `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>{{.Match.Tickers}} – {{.Match.Title}}</title>
+` + emailStyle + `</head>
+<body>
+  <div class="container">
+    {{template "match" .}}
 
     <div class="footer">
       Generated by <a href=https://github.com/shanehull/annscraper  target="_blank" rel="noopener">annscraper</a>
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const execTimeout = 30 * time.Second
+
+// ExecConfig holds configuration for piping notifications to a local command.
+type ExecConfig struct {
+	Command string
+	Format  string // "json" or "text"
+	Enabled bool
+}
+
+// ExecSender delivers messages by running a shell command with the message text on stdin.
+type ExecSender struct {
+	cfg ExecConfig
+}
+
+// NewExecSender creates a sender with the given command configuration.
+func NewExecSender(cfg ExecConfig) *ExecSender {
+	return &ExecSender{cfg: cfg}
+}
+
+// Send runs the command once per message. The subject is exposed as ANNSCRAPER_SUBJECT.
+func (s *ExecSender) Send(msg *RenderedMessage) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.cfg.Command)
+	cmd.Stdin = strings.NewReader(msg.Text)
+	cmd.Env = append(os.Environ(), "ANNSCRAPER_SUBJECT="+msg.Subject)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		err = fmt.Errorf("command %q failed: %v. Stderr: %s", s.cfg.Command, err, strings.TrimSpace(stderr.String()))
+		log.Printf("Exec error: %s (Subject: %s)", err, msg.Subject)
+		return err
+	}
+
+	log.Printf("Exec notification sent: %s", msg.Subject)
+	return nil
+}
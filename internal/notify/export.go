@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// csvColumns are the columns written by WriteCSV.
+var csvColumns = []string{"ticker", "time", "type", "price_sensitive", "title", "keywords", "snippet", "ai_score", "url"}
+
+// WriteMatches writes matches to w as "md" (Markdown) or "csv".
+func WriteMatches(w io.Writer, format, date string, matches []types.AnnotatedMatch) error {
+	switch format {
+	case "md":
+		return WriteMarkdown(w, date, matches)
+	case "csv":
+		return WriteCSV(w, matches)
+	}
+	return fmt.Errorf("unknown output format %q (expected 'md' or 'csv')", format)
+}
+
+// WriteCSV writes one row per match, headed by csvColumns. Keywords are ';' separated and
+// ai_score is empty for matches without an analysis.
+func WriteCSV(w io.Writer, matches []types.AnnotatedMatch) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write(csvColumns)
+	for _, am := range matches {
+		m := am.Match
+		score := ""
+		if am.Analysis != nil {
+			score = strconv.Itoa(am.Analysis.RelevanceScore)
+		}
+		_ = cw.Write([]string{
+			m.Tickers(),
+			m.DateTime.Format(time.RFC3339),
+			matchType(m),
+			strconv.FormatBool(m.IsPriceSensitive),
+			m.Title,
+			strings.Join(m.KeywordsFound, ";"),
+			m.Context,
+			score,
+			m.DocumentURL(),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes matches as a Markdown note with a section per match.
+func WriteMarkdown(w io.Writer, date string, matches []types.AnnotatedMatch) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# ASX matches for %s\n\n", date)
+	if len(matches) == 0 {
+		b.WriteString("No matches.\n")
+	}
+
+	for _, am := range matches {
+		m := am.Match
+		fmt.Fprintf(&b, "## %s – %s\n\n", m.Tickers(), markdownEscape(m.Title))
+		fmt.Fprintf(&b, "- **Time:** %s\n", displayTime(m.DateTime, "02 Jan 2006 3:04 PM"))
+		fmt.Fprintf(&b, "- **Type:** %s\n", matchType(m))
+		if m.IsPriceSensitive {
+			b.WriteString("- **Price sensitive:** yes\n")
+		}
+		if len(m.KeywordsFound) > 0 {
+			fmt.Fprintf(&b, "- **Keywords:** %s\n", markdownEscape(strings.Join(m.KeywordsFound, ", ")))
+		}
+		if am.Analysis != nil {
+			fmt.Fprintf(&b, "- **AI score:** %d/100 (confidence %d)\n", am.Analysis.RelevanceScore, am.Analysis.Confidence)
+		}
+		fmt.Fprintf(&b, "- **URL:** <%s>\n\n", m.DocumentURL())
+
+		if m.Context != "" {
+			for line := range strings.Lines(m.Context) {
+				b.WriteString("> " + markdownEscape(strings.TrimRight(line, "\n")) + "\n")
+			}
+			b.WriteString("\n")
+		}
+		if am.Analysis != nil && len(am.Analysis.Summary) > 0 {
+			b.WriteString("### AI Summary\n\n")
+			for _, s := range am.Analysis.Summary {
+				b.WriteString("- " + markdownEscape(s) + "\n")
+			}
+			b.WriteString("\n")
+		}
+		if am.Analysis != nil && len(am.Analysis.PotentialCatalysts) > 0 {
+			b.WriteString("### Potential Catalysts\n\n")
+			for _, c := range am.Analysis.PotentialCatalysts {
+				fmt.Fprintf(&b, "- **%s:** %s\n", markdownEscape(c.Category), markdownEscape(c.Details))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// matchType describes why an announcement matched: a halt follow-up, one of the configured
+// tickers, or a keyword.
+func matchType(m types.Match) string {
+	switch {
+	case m.FollowsHalt != nil:
+		return "halt"
+	case m.TickerMatched:
+		return "ticker"
+	default:
+		return "keyword"
+	}
+}
+
+// markdownEscape stops text being read as Markdown or Obsidian link syntax.
+var markdownEscape = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, "|", `\|`,
+).Replace
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"sync"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// Channels that deliver to the local machine, with nothing to retry.
+const (
+	ChannelConsole = "console"
+	ChannelDesktop = "desktop"
+	ChannelSyslog  = "syslog"
+)
+
+// Channels whose failed deliveries are queued for retry.
+const (
+	ChannelEmail  = "email"
+	ChannelExec   = "exec"
+	ChannelNtfy   = "ntfy"
+	ChannelSMS    = "sms"
+	ChannelTeams  = "teams"
+	ChannelMatrix = "matrix"
+	ChannelNATS   = "nats"
+	ChannelKafka  = "kafka"
+	ChannelMQTT   = "mqtt"
+)
+
+// FailureQueue keeps matches a channel failed to deliver so they can be sent again later.
+type FailureQueue interface {
+	QueueFailed(channel string, matches []types.AnnotatedMatch, err error)
+}
+
+var (
+	failureMutex sync.Mutex
+	failureQueue FailureQueue
+)
+
+// SetFailureQueue routes failed deliveries to q. nil drops them after logging, as before.
+func SetFailureQueue(q FailureQueue) {
+	failureMutex.Lock()
+	defer failureMutex.Unlock()
+	failureQueue = q
+}
+
+// queueFailed records matches that channel failed to deliver.
+func queueFailed(channel string, matches []types.AnnotatedMatch, err error) {
+	failureMutex.Lock()
+	q := failureQueue
+	failureMutex.Unlock()
+	if q != nil && len(matches) > 0 {
+		q.QueueFailed(channel, matches, err)
+	}
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const icsDateLayout = "20060102"
+
+// WriteCalendar exports the AI-extracted key dates of all matches as all-day events in an
+// iCalendar (.ics) file. It returns the number of events written.
+func WriteCalendar(path string, matches []types.AnnotatedMatch) (int, error) {
+	var sb strings.Builder
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	count := 0
+
+	sb.WriteString("BEGIN:VCALENDAR\r\n")
+	sb.WriteString("VERSION:2.0\r\n")
+	sb.WriteString("PRODID:-//annscraper//ASX key dates//EN\r\n")
+	sb.WriteString("CALSCALE:GREGORIAN\r\n")
+
+	for _, am := range matches {
+		if am.Analysis == nil {
+			continue
+		}
+
+		for _, d := range am.Analysis.KeyDates {
+			day, err := time.Parse("2006-01-02", d.Date)
+			if err != nil {
+				log.Printf("Warning: Skipping key date %q for %s: %v", d.Date, am.Match.Ticker, err)
+				continue
+			}
+
+			uid := sha1.Sum([]byte(am.Match.Ticker + "|" + am.Match.Title + "|" + d.Date + "|" + d.Event))
+
+			sb.WriteString("BEGIN:VEVENT\r\n")
+			writeICSLine(&sb, "UID:"+hex.EncodeToString(uid[:])+"@annscraper")
+			writeICSLine(&sb, "DTSTAMP:"+stamp)
+			writeICSLine(&sb, "DTSTART;VALUE=DATE:"+day.Format(icsDateLayout))
+			writeICSLine(&sb, "DTEND;VALUE=DATE:"+day.AddDate(0, 0, 1).Format(icsDateLayout))
+			writeICSLine(&sb, "SUMMARY:"+icsEscape(fmt.Sprintf("%s: %s (%s)", am.Match.Ticker, d.Event, d.Type)))
+			writeICSLine(&sb, "DESCRIPTION:"+icsEscape(am.Match.Title+"\n"+am.Match.PDFURL))
+			writeICSLine(&sb, "URL:"+am.Match.PDFURL)
+			sb.WriteString("END:VEVENT\r\n")
+			count++
+		}
+	}
+
+	sb.WriteString("END:VCALENDAR\r\n")
+
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		return 0, fmt.Errorf("failed to write calendar file %s: %w", path, err)
+	}
+	return count, nil
+}
+
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line folded at 75 octets as required by RFC 5545.
+func writeICSLine(sb *strings.Builder, line string) {
+	const limit = 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	sb.WriteString(line + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
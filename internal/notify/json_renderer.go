@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/shanehull/annscraper/internal/schema"
+)
+
+// JSONRenderer renders notifications as a JSON document in the message text.
+type JSONRenderer struct{}
+
+// NewJSONRenderer creates a renderer that emits NotificationData as indented JSON.
+func NewJSONRenderer() *JSONRenderer {
+	return &JSONRenderer{}
+}
+
+// Render produces a message whose Text is the JSON encoding of the notification.
+func (r *JSONRenderer) Render(data NotificationData) (*RenderedMessage, error) {
+	body, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification JSON: %w", err)
+	}
+	if err := schema.Validate(body); err != nil {
+		return nil, err
+	}
+
+	return &RenderedMessage{
+		Subject: fmt.Sprintf("ASX Alert: %s - %s", data.Match.Tickers(), data.Match.Title),
+		Text:    string(body),
+	}, nil
+}
+
+// PlainTextRenderer renders notifications as plain text only.
+type PlainTextRenderer struct{}
+
+// NewPlainTextRenderer creates a renderer using the plain text email layout.
+func NewPlainTextRenderer() *PlainTextRenderer {
+	return &PlainTextRenderer{}
+}
+
+// Render produces a plain text message.
+func (r *PlainTextRenderer) Render(data NotificationData) (*RenderedMessage, error) {
+	return &RenderedMessage{
+		Subject: fmt.Sprintf("ASX Alert: %s - %s", data.Match.Tickers(), data.Match.Title),
+		Text:    renderPlainText(data),
+	}, nil
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/schema"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const kafkaTimeout = 30 * time.Second
+
+// KafkaConfig holds configuration for producing matches to Kafka through a REST proxy
+// (Confluent REST Proxy v2 API, also served by Redpanda's HTTP proxy).
+type KafkaConfig struct {
+	RESTURL string // e.g. http://localhost:8082
+	Topic   string // "{ticker}" is replaced with the match's ticker
+	Enabled bool
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// KafkaMatches produces each match as a JSON record keyed by ticker, so a ticker's
+// matches stay ordered within a partition.
+func KafkaMatches(matches []types.AnnotatedMatch, cfg KafkaConfig) {
+	if !cfg.Enabled || len(matches) == 0 {
+		return
+	}
+
+	byTopic := map[string][]kafkaRecord{}
+	pending := map[string][]types.AnnotatedMatch{}
+	var topics []string
+	for _, am := range matches {
+		value, err := schema.MarshalMatch(am)
+		if err != nil {
+			log.Printf("Kafka render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+		topic := matchSubject(cfg.Topic, am.Match)
+		if _, ok := byTopic[topic]; !ok {
+			topics = append(topics, topic)
+		}
+		byTopic[topic] = append(byTopic[topic], kafkaRecord{Key: am.Match.Ticker, Value: value})
+		pending[topic] = append(pending[topic], am)
+	}
+
+	client := &http.Client{Timeout: kafkaTimeout}
+	for i, topic := range topics {
+		if err := produceKafka(client, cfg.RESTURL, topic, byTopic[topic]); err != nil {
+			log.Printf("Kafka error: %v", err)
+			for _, t := range topics[i:] {
+				queueFailed(ChannelKafka, pending[t], err)
+			}
+			return
+		}
+	}
+	log.Printf("Produced %d matches to Kafka", len(matches))
+}
+
+func produceKafka(client *http.Client, restURL, topic string, records []kafkaRecord) error {
+	payload, err := json.Marshal(map[string]any{"records": records})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(restURL, "/") + "/topics/" + url.PathEscape(topic)
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid Kafka REST URL %q: %w", restURL, err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("producing to %s failed: %w", topic, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("producing to %s returned %s: %s", topic, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// The proxy reports per-record failures in an otherwise successful response.
+	var result struct {
+		Offsets []struct {
+			Error string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(body, &result); err == nil {
+		for _, o := range result.Offsets {
+			if o.Error != "" {
+				return fmt.Errorf("producing to %s: %s", topic, o.Error)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const matrixTimeout = 15 * time.Second
+
+// MatrixConfig holds configuration for posting to a Matrix room.
+type MatrixConfig struct {
+	Homeserver  string // e.g. https://matrix.example.org
+	AccessToken string
+	RoomID      string // e.g. !abcdef:example.org
+	Enabled     bool
+}
+
+// MatrixRenderer renders a match as a formatted Matrix message.
+type MatrixRenderer struct{}
+
+// NewMatrixRenderer creates a renderer for Matrix messages.
+func NewMatrixRenderer() *MatrixRenderer {
+	return &MatrixRenderer{}
+}
+
+// Render produces a plain text body and an HTML formatted_body.
+func (r *MatrixRenderer) Render(data NotificationData) (*RenderedMessage, error) {
+	m := data.Match
+
+	flag := ""
+	if m.IsPriceSensitive {
+		flag = " ⚡"
+	}
+
+	var text, formatted strings.Builder
+	fmt.Fprintf(&text, "%s - %s%s\n", m.Tickers(), m.Title, flag)
+	fmt.Fprintf(&formatted, "<p><b>%s</b> – %s%s</p>", html.EscapeString(m.Tickers()), html.EscapeString(m.Title), flag)
+
+	var details []string
+	details = append(details, displayTime(m.DateTime, "02 Jan 2006 3:04 PM"))
+	if len(m.KeywordsFound) > 0 {
+		details = append(details, "Keywords: "+strings.Join(m.KeywordsFound, ", "))
+	}
+	if data.Analysis != nil {
+		details = append(details, fmt.Sprintf("AI Score: %d/100", data.Analysis.RelevanceScore))
+	}
+	text.WriteString(strings.Join(details, " · ") + "\n")
+	formatted.WriteString("<p>" + html.EscapeString(strings.Join(details, " · ")) + "</p>")
+
+	if data.Analysis != nil && len(data.Analysis.Summary) > 0 {
+		formatted.WriteString("<ul>")
+		for _, s := range data.Analysis.Summary {
+			fmt.Fprintf(&text, "• %s\n", s)
+			fmt.Fprintf(&formatted, "<li>%s</li>", html.EscapeString(s))
+		}
+		formatted.WriteString("</ul>")
+	}
+
+	text.WriteString(m.DocumentURL())
+	fmt.Fprintf(&formatted, `<p><a href="%s">Open announcement</a></p>`, html.EscapeString(m.DocumentURL()))
+
+	return &RenderedMessage{
+		Subject: fmt.Sprintf("ASX Alert: %s - %s", m.Tickers(), m.Title),
+		Text:    text.String(),
+		HTML:    formatted.String(),
+		URL:     m.DocumentURL(),
+	}, nil
+}
+
+// MatrixSender sends messages to a Matrix room via the client-server API.
+type MatrixSender struct {
+	cfg    MatrixConfig
+	client *http.Client
+}
+
+// NewMatrixSender creates a sender for the configured room.
+func NewMatrixSender(cfg MatrixConfig) *MatrixSender {
+	return &MatrixSender{cfg: cfg, client: &http.Client{Timeout: matrixTimeout}}
+}
+
+var matrixTxn atomic.Int64
+
+// Send posts the message as an m.room.message event, formatted when HTML is present.
+func (s *MatrixSender) Send(msg *RenderedMessage) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	content := map[string]string{"msgtype": "m.text", "body": msg.Text}
+	if msg.HTML != "" {
+		content["format"] = "org.matrix.custom.html"
+		content["formatted_body"] = msg.HTML
+	}
+	payload, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	txnID := fmt.Sprintf("annscraper-%d-%d", time.Now().UnixNano(), matrixTxn.Add(1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(s.cfg.Homeserver, "/"), url.PathEscape(s.cfg.RoomID), txnID)
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid Matrix homeserver %q: %w", s.cfg.Homeserver, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("matrix request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("matrix returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	log.Printf("Matrix message sent: %s", msg.Subject)
+	return nil
+}
+
+// MatrixMatches posts each match to the configured Matrix room.
+func MatrixMatches(matches []types.AnnotatedMatch, cfg MatrixConfig) {
+	if !cfg.Enabled || len(matches) == 0 {
+		return
+	}
+
+	log.Printf("Posting %d matches to Matrix room %s", len(matches), cfg.RoomID)
+
+	renderer := NewMatrixRenderer()
+	sender := NewMatrixSender(cfg)
+
+	for i, am := range matches {
+		msg, err := renderer.Render(NotificationData{
+			Match:    am.Match,
+			Analysis: am.Analysis,
+		})
+		if err != nil {
+			log.Printf("Matrix render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+
+		if err := sender.Send(msg); err != nil {
+			log.Printf("Matrix error: %v", err)
+			queueFailed(ChannelMatrix, matches[i:], err)
+			return // the homeserver is unreachable, don't repeat the same error for every match
+		}
+	}
+}
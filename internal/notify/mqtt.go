@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/schema"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const mqttTimeout = 15 * time.Second
+
+// MQTT 3.1.1 control packet types.
+const (
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublish    = 0x30
+	mqttPubAck     = 0x40
+	mqttDisconnect = 0xE0
+)
+
+// MQTTConfig holds configuration for publishing matches to an MQTT broker.
+type MQTTConfig struct {
+	URL     string // mqtt://[user:pass@]host:1883, or mqtts:// for TLS
+	Topic   string // "{ticker}" is replaced with the match's ticker
+	Enabled bool
+}
+
+// mqttConn is a minimal MQTT 3.1.1 client, enough to publish at QoS 1.
+type mqttConn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	nextID uint16
+}
+
+func dialMQTT(rawURL string) (*mqttConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MQTT URL %q: %w", rawURL, err)
+	}
+	secure := u.Scheme == "mqtts" || u.Scheme == "ssl" || u.Scheme == "tls"
+	host := u.Host
+	if u.Port() == "" {
+		port := "1883"
+		if secure {
+			port = "8883"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+	var conn net.Conn
+	if secure {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker at %s: %w", host, err)
+	}
+	c := &mqttConn{conn: conn, r: bufio.NewReader(conn)}
+
+	var flags byte = 0x02 // clean session
+	hostname, _ := os.Hostname()
+	payload := mqttString(fmt.Sprintf("annscraper-%s-%d", hostname, os.Getpid()))
+	if u.User != nil {
+		flags |= 0x80
+		payload = append(payload, mqttString(u.User.Username())...)
+		if pass, ok := u.User.Password(); ok {
+			flags |= 0x40
+			payload = append(payload, mqttString(pass)...)
+		}
+	}
+	header := append(mqttString("MQTT"), 4, flags, 0, 60) // protocol level 4, 60s keep-alive
+
+	if err := c.write(mqttConnect, append(header, payload...)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	packetType, body, err := c.read()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if packetType != mqttConnAck || len(body) != 2 {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected MQTT packet 0x%x instead of CONNACK", packetType)
+	}
+	if body[1] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("MQTT broker refused the connection (%s)", mqttConnectError(body[1]))
+	}
+	return c, nil
+}
+
+func mqttConnectError(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "client identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("code %d", code)
+}
+
+func mqttString(s string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(s))), s...)
+}
+
+func (c *mqttConn) write(header byte, body []byte) error {
+	packet := []byte{header}
+	// Remaining length is a base-128 varint.
+	for n := len(body); ; {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	c.conn.SetDeadline(time.Now().Add(mqttTimeout))
+	_, err := c.conn.Write(append(packet, body...))
+	return err
+}
+
+func (c *mqttConn) read() (byte, []byte, error) {
+	c.conn.SetDeadline(time.Now().Add(mqttTimeout))
+	header, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var length, shift int
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(c.r, body)
+	return header & 0xF0, body, err
+}
+
+// publish sends a retained QoS 1 message and waits for the broker's PUBACK.
+func (c *mqttConn) publish(topic string, payload []byte) error {
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID = 1
+	}
+	body := append(mqttString(topic), byte(c.nextID>>8), byte(c.nextID))
+	if err := c.write(mqttPublish|0x02|0x01, append(body, payload...)); err != nil {
+		return err
+	}
+
+	packetType, ack, err := c.read()
+	if err != nil {
+		return err
+	}
+	if packetType != mqttPubAck || len(ack) < 2 || binary.BigEndian.Uint16(ack) != c.nextID {
+		return fmt.Errorf("unexpected MQTT packet 0x%x instead of PUBACK", packetType)
+	}
+	return nil
+}
+
+func (c *mqttConn) Close() error {
+	c.write(mqttDisconnect, nil)
+	return c.conn.Close()
+}
+
+// MQTTMatches publishes each match as a retained JSON message, so each ticker's topic always
+// holds its latest match for subscribers that connect later.
+func MQTTMatches(matches []types.AnnotatedMatch, cfg MQTTConfig) {
+	if !cfg.Enabled || len(matches) == 0 {
+		return
+	}
+
+	c, err := dialMQTT(cfg.URL)
+	if err != nil {
+		log.Printf("MQTT error: %v", err)
+		queueFailed(ChannelMQTT, matches, err)
+		return
+	}
+	defer c.Close()
+
+	for i, am := range matches {
+		payload, err := schema.MarshalMatch(am)
+		if err != nil {
+			log.Printf("MQTT render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+		topic := matchSubject(cfg.Topic, am.Match)
+		if err := c.publish(topic, payload); err != nil {
+			log.Printf("MQTT error publishing to %s: %v", topic, err)
+			queueFailed(ChannelMQTT, matches[i:], err)
+			return
+		}
+	}
+	log.Printf("Published %d matches to MQTT", len(matches))
+}
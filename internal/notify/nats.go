@@ -0,0 +1,232 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/schema"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const natsTimeout = 15 * time.Second
+
+// NATSConfig holds configuration for publishing matches to NATS.
+type NATSConfig struct {
+	URL       string // nats://[user:pass@]host:4222, or tls:// for TLS; a lone user is sent as a token
+	Subject   string // "{ticker}" is replaced with the match's ticker
+	JetStream bool   // wait for a JetStream ack for each message
+	Enabled   bool
+}
+
+// matchSubject expands "{ticker}" in a subject or topic pattern.
+func matchSubject(pattern string, m types.Match) string {
+	return strings.ReplaceAll(pattern, "{ticker}", m.Ticker)
+}
+
+// natsConn is a minimal NATS client speaking the text protocol, enough to publish.
+type natsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func dialNATS(rawURL string) (*natsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL %q: %w", rawURL, err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	dialer := &net.Dialer{Timeout: natsTimeout}
+	var conn net.Conn
+	if u.Scheme == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %s: %w", host, err)
+	}
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+
+	c := &natsConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	line, err := c.readLine()
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected NATS greeting %q: %v", line, err)
+	}
+
+	// no_responders makes a publish to a subject without a stream fail fast instead of timing out.
+	connect := map[string]any{"verbose": false, "pedantic": false, "name": "annscraper", "lang": "go", "protocol": 1,
+		"headers": true, "no_responders": true}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			connect["user"] = u.User.Username()
+			connect["pass"] = pass
+		} else {
+			connect["auth_token"] = u.User.Username()
+		}
+	}
+	opts, _ := json.Marshal(connect)
+	fmt.Fprintf(c.w, "CONNECT %s\r\n", opts)
+	if err := c.flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *natsConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// next returns the next protocol line that isn't a keep-alive or informational message.
+func (c *natsConn) next() (string, error) {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case line == "PING":
+			c.w.WriteString("PONG\r\n")
+			c.w.Flush()
+		case strings.HasPrefix(line, "INFO "), line == "+OK":
+		case strings.HasPrefix(line, "-ERR"):
+			return "", errors.New(strings.Trim(strings.TrimPrefix(line, "-ERR "), "'"))
+		default:
+			return line, nil
+		}
+	}
+}
+
+// flush sends buffered commands and waits for the server to process them.
+func (c *natsConn) flush() error {
+	c.conn.SetDeadline(time.Now().Add(natsTimeout))
+	c.w.WriteString("PING\r\n")
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	line, err := c.next()
+	if err != nil {
+		return fmt.Errorf("NATS error: %w", err)
+	}
+	if line != "PONG" {
+		return fmt.Errorf("unexpected NATS reply %q", line)
+	}
+	return nil
+}
+
+func (c *natsConn) publish(subject, reply string, payload []byte) {
+	if reply != "" {
+		fmt.Fprintf(c.w, "PUB %s %s %d\r\n", subject, reply, len(payload))
+	} else {
+		fmt.Fprintf(c.w, "PUB %s %d\r\n", subject, len(payload))
+	}
+	c.w.Write(payload)
+	c.w.WriteString("\r\n")
+}
+
+// publishAcked publishes to a JetStream subject and waits for the stream's ack.
+func (c *natsConn) publishAcked(subject string, payload []byte, seq int) error {
+	inbox := fmt.Sprintf("_INBOX.annscraper.%d.%d", time.Now().UnixNano(), seq)
+	fmt.Fprintf(c.w, "SUB %s %d\r\nUNSUB %d 1\r\n", inbox, seq, seq)
+	c.publish(subject, inbox, payload)
+	c.conn.SetDeadline(time.Now().Add(natsTimeout))
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+
+	line, err := c.next()
+	if err != nil {
+		return fmt.Errorf("NATS error: %w", err)
+	}
+	var sub, sid string
+	var headerSize, size int
+	if strings.HasPrefix(line, "HMSG ") {
+		// Only status replies carry headers here, e.g. "NATS/1.0 503" for no responders.
+		if _, err := fmt.Sscanf(line, "HMSG %s %s %d %d", &sub, &sid, &headerSize, &size); err != nil {
+			return fmt.Errorf("unexpected NATS reply %q", line)
+		}
+		io.CopyN(io.Discard, c.r, int64(size+2))
+		return fmt.Errorf("no JetStream stream is bound to %s", subject)
+	}
+	if _, err := fmt.Sscanf(line, "MSG %s %s %d", &sub, &sid, &size); err != nil {
+		return fmt.Errorf("unexpected NATS reply %q", line)
+	}
+	body := make([]byte, size+2)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return err
+	}
+
+	var ack struct {
+		Stream string `json:"stream"`
+		Error  *struct {
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body[:size], &ack); err != nil {
+		return fmt.Errorf("invalid JetStream ack: %s", body[:size])
+	}
+	if ack.Error != nil {
+		return fmt.Errorf("JetStream rejected the message: %s", ack.Error.Description)
+	}
+	return nil
+}
+
+func (c *natsConn) Close() error {
+	return c.conn.Close()
+}
+
+// NATSMatches publishes each match as JSON to NATS, waiting for acks with JetStream.
+func NATSMatches(matches []types.AnnotatedMatch, cfg NATSConfig) {
+	if !cfg.Enabled || len(matches) == 0 {
+		return
+	}
+
+	if err := publishNATS(matches, cfg); err != nil {
+		log.Printf("NATS error: %v", err)
+		queueFailed(ChannelNATS, matches, err) // some may have been published, retrying is at least once
+		return
+	}
+	log.Printf("Published %d matches to NATS", len(matches))
+}
+
+func publishNATS(matches []types.AnnotatedMatch, cfg NATSConfig) error {
+	c, err := dialNATS(cfg.URL)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	for i, am := range matches {
+		payload, err := schema.MarshalMatch(am)
+		if err != nil {
+			return fmt.Errorf("failed to marshal match for %s: %w", am.Match.Ticker, err)
+		}
+		subject := matchSubject(cfg.Subject, am.Match)
+
+		if cfg.JetStream {
+			if err := c.publishAcked(subject, payload, i+1); err != nil {
+				return fmt.Errorf("publishing %s to %s: %w", am.Match.Ticker, subject, err)
+			}
+			continue
+		}
+		c.publish(subject, "", payload)
+	}
+
+	return c.flush()
+}
@@ -1,11 +1,13 @@
 /*
-Package notify handles reporting of matches via console output and email notifications.
+Package notify handles reporting of matches via console output, email, desktop and push notifications, and local commands.
 */
 package notify
 
 import (
 	"fmt"
 	"log"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 
@@ -22,6 +24,7 @@ type RenderedMessage struct {
 	Subject string
 	Text    string
 	HTML    string
+	URL     string // link to the announcement, for channels that can attach one
 }
 
 type Renderer interface {
@@ -32,39 +35,73 @@ type Sender interface {
 	Send(msg *RenderedMessage) error
 }
 
-const (
-	dim    = "\033[2m"
-	bold   = "\033[1m"
-	reset  = "\033[0m"
-	cyan   = "\033[36m"
-	yellow = "\033[33m"
-	green  = "\033[32m"
-	orange = "\033[38;5;208m"
-)
-
 // ReportMatches prints matches to the console.
 func ReportMatches(matches []types.AnnotatedMatch, historyFilePath string) {
 	if len(matches) == 0 {
-		fmt.Printf("\n%s──────────────────────────────────────────%s\n", dim, reset)
-		fmt.Println("  No new matching keywords found today.")
-		fmt.Printf("%s──────────────────────────────────────────%s\n\n", dim, reset)
+		printf("\n%s%s%s\n", dim, rule(), reset)
+		printf("  No new matching keywords found today.\n")
+		printf("%s%s%s\n\n", dim, rule(), reset)
 		return
 	}
 
 	headerText := fmt.Sprintf("%d MATCH(ES) FOUND", len(matches))
-	boxWidth := 42
-	padding := boxWidth - len(headerText) - 3
+	boxWidth := ruleWidth()
+	padding := max(0, boxWidth-len(headerText)-3)
 
-	fmt.Printf("\n%s╔%s╗%s\n", cyan, strings.Repeat("═", boxWidth), reset)
-	fmt.Printf("%s║%s  %s%s%s%s %s║%s\n", cyan, reset, bold, headerText, reset, strings.Repeat(" ", padding), cyan, reset)
-	fmt.Printf("%s╚%s╝%s\n", cyan, strings.Repeat("═", boxWidth), reset)
+	printf("\n%s╔%s╗%s\n", cyan, strings.Repeat("═", boxWidth), reset)
+	printf("%s║%s  %s%s%s%s %s║%s\n", cyan, reset, bold, headerText, reset, strings.Repeat(" ", padding), cyan, reset)
+	printf("%s╚%s╝%s\n", cyan, strings.Repeat("═", boxWidth), reset)
 
 	for i, am := range matches {
 		printMatch(i+1, am)
 	}
 
-	fmt.Printf("\n%s──────────────────────────────────────────%s\n", dim, reset)
-	fmt.Printf("%sHistory saved to %s%s\n", dim, historyFilePath, reset)
+	printf("\n%s%s%s\n", dim, rule(), reset)
+	printf("%sHistory saved to %s%s\n", dim, historyFilePath, reset)
+}
+
+// ReportUsage prints the per-model token usage and estimated AI cost for the run.
+func ReportUsage(usage []ai.Usage) {
+	if len(usage) == 0 {
+		return
+	}
+
+	var totalCost float64
+	printf("%sAI usage%s\n", dim, reset)
+	for _, u := range usage {
+		cost := "n/a"
+		if u.Priced {
+			cost = fmt.Sprintf("~$%.4f", u.CostUSD)
+			totalCost += u.CostUSD
+		}
+		printf("%s  %-24s %3d calls  %9d in  %8d out  %s%s\n", dim, u.Model, u.Calls, u.InputTokens, u.OutputTokens, cost, reset)
+	}
+	printf("%s  Estimated total: ~$%.4f%s\n", dim, totalCost, reset)
+}
+
+// ReportBandwidth prints the bytes downloaded during the run, by category.
+func ReportBandwidth(usage map[string]int64) {
+	var total int64
+	var parts []string
+	for _, category := range slices.Sorted(maps.Keys(usage)) {
+		total += usage[category]
+		parts = append(parts, fmt.Sprintf("%s %s", category, FormatBytes(usage[category])))
+	}
+	printf("%sDownloaded %s (%s)%s\n", dim, FormatBytes(total), strings.Join(parts, ", "), reset)
+}
+
+// FormatBytes renders a byte count using binary units.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
 func printMatch(num int, am types.AnnotatedMatch) {
@@ -75,46 +112,140 @@ func printMatch(num int, am types.AnnotatedMatch) {
 	if m.IsPriceSensitive {
 		priceSensitive = fmt.Sprintf(" %s⚡ PRICE SENSITIVE%s", orange, reset)
 	}
-	fmt.Printf("\n%s┌─ %s#%d%s %s%s%s%s\n", dim, bold, num, reset, cyan+bold, m.Ticker, reset, priceSensitive)
+	score := ""
+	if am.Analysis != nil {
+		score = fmt.Sprintf(" %sscore %d/100 (confidence %d)%s", dim, am.Analysis.RelevanceScore, am.Analysis.Confidence, reset)
+	}
+	company := ""
+	if m.CompanyName != "" {
+		company = " " + dim + m.CompanyName + reset
+	}
+	printf("\n%s┌─ %s#%d%s %s%s%s%s%s%s\n", dim, bold, num, reset, cyan+bold, m.Tickers(), reset, company, priceSensitive, score)
 
 	// Title
-	fmt.Printf("%s│%s  %s\n", dim, reset, m.Title)
+	printWrapped("  ", m.Title)
 
 	// Metadata
-	fmt.Printf("%s│%s\n", dim, reset)
-	fmt.Printf("%s│%s  %sDate%s      %s\n", dim, reset, dim, reset, m.DateTime.Format("02 Jan 2006 3:04 PM"))
+	printf("%s│%s\n", dim, reset)
+	printf("%s│%s  %sDate%s      %s\n", dim, reset, dim, reset, displayTime(m.DateTime, "02 Jan 2006 3:04 PM"))
 	if len(m.KeywordsFound) > 0 {
-		fmt.Printf("%s│%s  %sKeywords%s  %s\n", dim, reset, dim, reset, strings.Join(m.KeywordsFound, ", "))
+		printf("%s│%s  %sKeywords%s  %s\n", dim, reset, dim, reset, strings.Join(m.KeywordsFound, ", "))
+		if page := m.Page(); page > 0 {
+			printf("%s│%s  %sPage%s      found on page %d\n", dim, reset, dim, reset, page)
+		}
+	}
+	if !m.Geo.Empty() {
+		printf("%s│%s  %sLocation%s  %s\n", dim, reset, dim, reset, m.Geo)
+	}
+	if company := companyProfile(m.Announcement); company != "" {
+		printf("%s│%s  %sCompany%s   %s\n", dim, reset, dim, reset, company)
+	}
+	if m.Reaction != nil {
+		color := green
+		if m.Reaction.ChangePct < 0 {
+			color = red
+		}
+		printf("%s│%s  %sReaction%s  %s%s%s\n", dim, reset, dim, reset, color, m.Reaction, reset)
+	}
+	if m.Short != nil {
+		printf("%s│%s  %sShorts%s    %s\n", dim, reset, dim, reset, m.Short)
+	}
+	if h := m.FollowsHalt; h != nil {
+		printf("%s│%s  %sHalt%s      %sfollows %s (%s)%s\n", dim, reset, dim, reset, yellow, h.Title, displayTime(h.DateTime, "02 Jan 3:04 PM"), reset)
+	}
+	printf("%s│%s  %sURL%s       %s\n", dim, reset, dim, reset, m.DocumentURL())
+
+	// Insider activity
+	if m.Insider != nil {
+		printf("%s│%s\n", dim, reset)
+		printf("%s│%s  %s▸ Insider Activity%s  %s%s%s\n", dim, reset, yellow, reset, bold, m.Insider.Direction(), reset)
+		printIndented(m.Insider.Summary(), 5)
+		if m.Insider.HeldAfter != "" {
+			printIndented("Holding after change: "+m.Insider.HeldAfter, 5)
+		}
+	}
+
+	// Changes since the previous report
+	if c := m.Changes; c != nil {
+		printf("%s│%s\n", dim, reset)
+		printf("%s│%s  %s▸ What Changed%s  %ssince %s (%s)%s\n", dim, reset, yellow, reset, dim, c.PreviousTitle, c.PreviousDate.Format("02 Jan 2006"), reset)
+		for _, line := range c.Added {
+			printf("%s│%s    %s+ %s%s\n", dim, reset, green, line, reset)
+		}
+		for _, line := range c.Removed {
+			printf("%s│%s    %s- %s%s\n", dim, reset, red, line, reset)
+		}
 	}
-	fmt.Printf("%s│%s  %sURL%s       %s\n", dim, reset, dim, reset, m.PDFURL)
 
 	// Context
 	if m.Context != "" {
-		fmt.Printf("%s│%s\n", dim, reset)
-		fmt.Printf("%s│%s  %s▸ Context%s\n", dim, reset, yellow, reset)
+		printf("%s│%s\n", dim, reset)
+		printf("%s│%s  %s▸ Context%s\n", dim, reset, yellow, reset)
 		printIndented(m.Context, 5)
 	}
 
 	// AI Summary
 	if am.Analysis != nil {
+		if fit := am.Analysis.ThesisFit; fit != nil {
+			verdict := fmt.Sprintf("%s✓ Fits thesis%s", green, reset)
+			if !fit.Fits {
+				verdict = fmt.Sprintf("%s✗ Does not fit thesis%s", orange, reset)
+			}
+			printf("%s│%s\n", dim, reset)
+			printf("%s│%s  %s\n", dim, reset, verdict)
+			printIndented(fit.Reason, 5)
+		}
+
 		if len(am.Analysis.Summary) > 0 {
-			fmt.Printf("%s│%s\n", dim, reset)
-			fmt.Printf("%s│%s  %s▸ AI Summary%s\n", dim, reset, green, reset)
+			printf("%s│%s\n", dim, reset)
+			printf("%s│%s  %s▸ AI Summary%s\n", dim, reset, green, reset)
 			for _, s := range am.Analysis.Summary {
-				fmt.Printf("%s│%s    • %s\n", dim, reset, s)
+				printWrapped("    • ", s)
 			}
 		}
 
 		if len(am.Analysis.PotentialCatalysts) > 0 {
-			fmt.Printf("%s│%s\n", dim, reset)
-			fmt.Printf("%s│%s  %s▸ Potential Catalysts%s\n", dim, reset, green, reset)
+			printf("%s│%s\n", dim, reset)
+			printf("%s│%s  %s▸ Potential Catalysts%s\n", dim, reset, green, reset)
 			for _, c := range am.Analysis.PotentialCatalysts {
-				fmt.Printf("%s│%s    %s[%s]%s %s\n", dim, reset, dim, c.Category, reset, c.Details)
+				printf("%s│%s    %s[%s]%s %s\n", dim, reset, dim, c.Category, reset, c.Details)
+			}
+		}
+
+		if len(am.Analysis.KeyDates) > 0 {
+			printf("%s│%s\n", dim, reset)
+			printf("%s│%s  %s▸ Key Dates%s\n", dim, reset, green, reset)
+			for _, d := range am.Analysis.KeyDates {
+				printf("%s│%s    %s  %s%s%s %s\n", dim, reset, d.Date, dim, d.Type, reset, d.Event)
 			}
 		}
 	}
 
-	fmt.Printf("%s└──────────────────────────────────────────%s\n", dim, reset)
+	printf("%s└%s%s\n", dim, rule(), reset)
+}
+
+// companyProfile describes the company's sector and market cap, e.g. "Materials, $420m market cap".
+func companyProfile(a types.Announcement) string {
+	var parts []string
+	if a.Sector != "" {
+		parts = append(parts, a.Sector)
+	}
+	if a.MarketCap > 0 {
+		parts = append(parts, formatMarketCap(a.MarketCap)+" market cap")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatMarketCap renders an AUD amount as "$420m" or "$1.2bn".
+func formatMarketCap(v float64) string {
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("$%.1fbn", v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("$%.0fm", v/1e6)
+	default:
+		return fmt.Sprintf("$%.0fk", v/1e3)
+	}
 }
 
 func printIndented(text string, indent int) {
@@ -122,7 +253,7 @@ func printIndented(text string, indent int) {
 	lines := strings.SplitSeq(text, "\n")
 	for line := range lines {
 		if line != "" {
-			fmt.Printf("%s│%s%s%s\n", dim, reset, prefix, line)
+			printWrapped(prefix, line)
 		}
 	}
 }
@@ -133,7 +264,7 @@ func EmailMatches(matches []types.AnnotatedMatch, cfg EmailConfig) {
 		return
 	}
 
-	log.Printf("Emailing %d matches (SMTP: %s:%d)", len(matches), cfg.SMTPServer, cfg.SMTPPort)
+	log.Printf("Emailing %d matches (%s)", len(matches), cfg.Transport())
 
 	renderer := NewHTMLEmailRenderer()
 	sender := NewEmailSender(cfg)
@@ -149,11 +280,71 @@ func EmailMatches(matches []types.AnnotatedMatch, cfg EmailConfig) {
 			msg, err := renderer.Render(data)
 			if err != nil {
 				log.Printf("Email render error for %s: %v", am.Match.Ticker, err)
+				queueFailed(ChannelEmail, []types.AnnotatedMatch{am}, err)
 				return
 			}
 
-			_ = sender.Send(msg)
+			if err := sender.Send(msg); err != nil {
+				queueFailed(ChannelEmail, []types.AnnotatedMatch{am}, err)
+			}
 		})
 	}
 	wg.Wait()
 }
+
+// ExecMatches pipes each match to the configured command, one invocation per match.
+func ExecMatches(matches []types.AnnotatedMatch, cfg ExecConfig) {
+	if !cfg.Enabled || len(matches) == 0 {
+		return
+	}
+
+	log.Printf("Piping %d matches to command: %s", len(matches), cfg.Command)
+
+	var renderer Renderer = NewJSONRenderer()
+	if cfg.Format == "text" {
+		renderer = NewPlainTextRenderer()
+	}
+	sender := NewExecSender(cfg)
+
+	for _, am := range matches {
+		data := NotificationData{
+			Match:    am.Match,
+			Analysis: am.Analysis,
+		}
+
+		msg, err := renderer.Render(data)
+		if err != nil {
+			log.Printf("Exec render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+
+		if err := sender.Send(msg); err != nil {
+			queueFailed(ChannelExec, []types.AnnotatedMatch{am}, err)
+		}
+	}
+}
+
+// DesktopMatches shows each match as a native desktop notification.
+func DesktopMatches(matches []types.AnnotatedMatch) {
+	if len(matches) == 0 {
+		return
+	}
+
+	renderer := NewDesktopRenderer()
+	sender := NewDesktopSender()
+
+	for _, am := range matches {
+		msg, err := renderer.Render(NotificationData{
+			Match:    am.Match,
+			Analysis: am.Analysis,
+		})
+		if err != nil {
+			log.Printf("Desktop render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+
+		if err := sender.Send(msg); err != nil {
+			return // the notifier is unavailable, don't repeat the same error for every match
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// ntfy message priorities.
+const (
+	NtfyPriorityDefault = 3
+	NtfyPriorityHigh    = 4
+)
+
+// NtfyConfig holds configuration for ntfy push notifications.
+type NtfyConfig struct {
+	URL     string // topic URL, e.g. https://ntfy.sh/my-asx-alerts; user:pass@ in the URL enables basic auth
+	Token   string // optional access token
+	Enabled bool
+}
+
+// NtfySender publishes messages to an ntfy topic.
+type NtfySender struct {
+	cfg    NtfyConfig
+	client *http.Client
+}
+
+// NewNtfySender creates a sender for the configured topic.
+func NewNtfySender(cfg NtfyConfig) *NtfySender {
+	return &NtfySender{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Send publishes the message with the default priority.
+func (s *NtfySender) Send(msg *RenderedMessage) error {
+	return s.SendPriority(NtfyPriorityDefault, msg)
+}
+
+// SendPriority publishes the message with the given ntfy priority (1-5). The announcement URL
+// becomes the notification's click action.
+func (s *NtfySender) SendPriority(priority int, msg *RenderedMessage) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.URL, strings.NewReader(msg.Text))
+	if err != nil {
+		return fmt.Errorf("invalid ntfy URL %q: %w", s.cfg.URL, err)
+	}
+	req.Header.Set("Title", msg.Subject)
+	req.Header.Set("Priority", fmt.Sprint(priority))
+	req.Header.Set("Tags", "chart_with_upwards_trend")
+	if msg.URL != "" {
+		req.Header.Set("Click", msg.URL)
+	}
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ntfy request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("ntfy returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// NtfyMatches pushes each match to ntfy, with price sensitive matches at high priority.
+func NtfyMatches(matches []types.AnnotatedMatch, cfg NtfyConfig) {
+	if !cfg.Enabled || len(matches) == 0 {
+		return
+	}
+
+	log.Printf("Pushing %d matches to ntfy", len(matches))
+
+	renderer := NewDesktopRenderer()
+	sender := NewNtfySender(cfg)
+
+	for i, am := range matches {
+		msg, err := renderer.Render(NotificationData{
+			Match:    am.Match,
+			Analysis: am.Analysis,
+		})
+		if err != nil {
+			log.Printf("Ntfy render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+
+		priority := NtfyPriorityDefault
+		if am.Match.IsPriceSensitive {
+			priority = NtfyPriorityHigh
+		}
+		if err := sender.SendPriority(priority, msg); err != nil {
+			log.Printf("Ntfy error: %v", err)
+			queueFailed(ChannelNtfy, matches[i:], err)
+			return // the topic is unreachable, don't repeat the same error for every match
+		}
+	}
+}
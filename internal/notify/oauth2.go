@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token endpoints and SMTP scopes of the supported OAuth2 providers.
+const (
+	googleTokenURL     = "https://oauth2.googleapis.com/token"
+	microsoftTokenURL  = "https://login.microsoftonline.com/common/oauth2/v2.0/token"
+	microsoftSMTPScope = "https://outlook.office.com/SMTP.Send offline_access"
+
+	tokenExpiryMargin = time.Minute
+)
+
+// OAuth2Config holds the credentials for XOAUTH2 SMTP authentication. Access tokens are
+// obtained from the refresh token and renewed shortly before they expire.
+type OAuth2Config struct {
+	Provider     string // "google" or "microsoft"; sets TokenURL and Scope defaults
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	Scope        string
+}
+
+// Enabled reports whether OAuth2 should be used instead of a password.
+func (c OAuth2Config) Enabled() bool {
+	return c.RefreshToken != ""
+}
+
+// WithDefaults fills TokenURL and Scope from Provider.
+func (c OAuth2Config) WithDefaults() (OAuth2Config, error) {
+	switch strings.ToLower(c.Provider) {
+	case "", "custom":
+	case "google", "gmail":
+		if c.TokenURL == "" {
+			c.TokenURL = googleTokenURL
+		}
+	case "microsoft", "office365", "m365":
+		if c.TokenURL == "" {
+			c.TokenURL = microsoftTokenURL
+		}
+		if c.Scope == "" {
+			c.Scope = microsoftSMTPScope
+		}
+	default:
+		return c, fmt.Errorf("unknown OAuth2 provider %q (expected google or microsoft)", c.Provider)
+	}
+	if c.Enabled() && c.TokenURL == "" {
+		return c, errors.New("an OAuth2 token URL or provider is required")
+	}
+	return c, nil
+}
+
+// oauth2Token is a cached access token.
+type oauth2Token struct {
+	accessToken  string
+	refreshToken string
+	expiry       time.Time
+}
+
+var (
+	tokenMutex sync.Mutex
+	tokens     = map[string]*oauth2Token{} // by original refresh token
+)
+
+var oauth2Client = &http.Client{Timeout: 30 * time.Second}
+
+// accessToken returns a valid access token, refreshing it if needed. Providers that rotate
+// refresh tokens are followed for the life of the process.
+func (c OAuth2Config) accessToken() (string, error) {
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+
+	tok := tokens[c.RefreshToken]
+	if tok != nil && time.Now().Add(tokenExpiryMargin).Before(tok.expiry) {
+		return tok.accessToken, nil
+	}
+
+	refreshToken := c.RefreshToken
+	if tok != nil && tok.refreshToken != "" {
+		refreshToken = tok.refreshToken
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {c.ClientID},
+	}
+	if c.ClientSecret != "" {
+		form.Set("client_secret", c.ClientSecret)
+	}
+	if c.Scope != "" {
+		form.Set("scope", c.Scope)
+	}
+
+	resp, err := oauth2Client.PostForm(c.TokenURL, form)
+	if err != nil {
+		return "", fmt.Errorf("OAuth2 token refresh failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("OAuth2 token refresh failed with %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return "", fmt.Errorf("OAuth2 token refresh failed with %s: %s %s", resp.Status, result.Error, result.ErrorDescription)
+	}
+
+	tok = &oauth2Token{
+		accessToken:  result.AccessToken,
+		refreshToken: refreshToken,
+		expiry:       time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}
+	if result.RefreshToken != "" {
+		tok.refreshToken = result.RefreshToken
+	}
+	tokens[c.RefreshToken] = tok
+	return tok.accessToken, nil
+}
+
+// xoauth2Auth implements the SASL XOAUTH2 mechanism used by Gmail and Microsoft 365.
+type xoauth2Auth struct {
+	username string
+	config   OAuth2Config
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS {
+		return "", nil, errors.New("refusing XOAUTH2 over an unencrypted connection")
+	}
+	token, err := a.config.accessToken()
+	if err != nil {
+		return "", nil, err
+	}
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + token + "\x01\x01"), nil
+}
+
+// Next answers the server's error challenge with an empty response so it reports the failure.
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
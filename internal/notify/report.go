@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// reportView is the data passed to the HTML report template.
+type reportView struct {
+	Date      string
+	Generated string
+	Matches   []types.AnnotatedMatch
+	IndexURL  string // link back to a site index, "" = none
+}
+
+const reportHTMLTemplate = emailMatchTemplate + `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>ASX matches for {{.Date}}</title>
+` + emailStyle + `  <style>
+    .container {
+      margin-bottom: 24px;
+    }
+
+    .contents li {
+      font-size: 14px;
+      margin-bottom: 4px;
+    }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <div class="ticker">ASX matches for {{.Date}}</div>
+      <div class="title">{{len .Matches}} match(es) · generated {{.Generated}}</div>
+    </div>
+    {{with .IndexURL}}
+    <div class="section"><a href="{{.}}">← All days</a></div>
+    {{end}}
+    {{if .Matches}}
+    <div class="section">
+      <ul class="contents">
+        {{range $i, $am := .Matches}}
+        <li><a href="#match-{{$i}}"><strong>{{$am.Match.Tickers}}</strong> {{$am.Match.Title}}</a>{{with $am.Analysis}} · score {{.RelevanceScore}}{{end}}</li>
+        {{end}}
+      </ul>
+    </div>
+    {{end}}
+  </div>
+
+  {{range $i, $am := .Matches}}
+  <div class="container" id="match-{{$i}}">
+    {{template "match" $am}}
+  </div>
+  {{end}}
+
+  <div class="footer">
+    Generated by <a href=https://github.com/shanehull/annscraper  target="_blank" rel="noopener">annscraper</a>
+  </div>
+</body>
+</html>`
+
+// RenderHTMLReport renders matches as a standalone HTML page, each laid out as in the
+// match emails.
+func RenderHTMLReport(date string, matches []types.AnnotatedMatch) ([]byte, error) {
+	return renderReport(reportView{Date: date, Matches: matches})
+}
+
+func renderReport(view reportView) ([]byte, error) {
+	tmpl, err := template.New("report").Funcs(emailFuncs).Parse(reportHTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	view.Generated = displayTime(time.Now(), "02 Jan 2006 3:04 PM")
+	if err := tmpl.Execute(&buf, view); err != nil {
+		return nil, fmt.Errorf("failed to render report template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteHTMLReport writes the HTML report of matches to path, replacing {date} in path with
+// date and creating missing directories. It returns the path written.
+func WriteHTMLReport(path, date string, matches []types.AnnotatedMatch) (string, error) {
+	path = strings.ReplaceAll(path, "{date}", date)
+
+	data, err := RenderHTMLReport(date, matches)
+	if err != nil {
+		return "", err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write report %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// siteManifest records the days a static site has pages for, so days whose matches have
+// since expired from the history keep their place in the index.
+const siteManifest = "days.json"
+
+// siteDay is a day listed on the site index.
+type siteDay struct {
+	Date    string `json:"date"`
+	Matches int    `json:"matches"`
+}
+
+const siteIndexTemplate = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="UTF-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>ASX matches</title>
+` + emailStyle + `</head>
+<body>
+  <div class="container">
+    <div class="header">
+      <div class="ticker">ASX matches</div>
+      <div class="title">{{len .Days}} day(s) · updated {{.Generated}}</div>
+    </div>
+    <div class="section">
+      {{if .Days}}
+      <ul>
+        {{range .Days}}
+        <li><a href="{{.Date}}.html">{{.Date}}</a> · {{.Matches}} match(es)</li>
+        {{end}}
+      </ul>
+      {{else}}
+      No matches yet.
+      {{end}}
+    </div>
+    <div class="footer">
+      Generated by <a href=https://github.com/shanehull/annscraper  target="_blank" rel="noopener">annscraper</a>
+    </div>
+  </div>
+</body>
+</html>`
+
+// WriteSite renders matches grouped by date (YYYY-MM-DD) into dir as a static site: a
+// DATE.html report per day and an index.html listing every day published so far, newest
+// first. Pages for days not in days are left alone. It returns the number of pages written.
+func WriteSite(dir string, days map[string][]types.AnnotatedMatch) (int, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create site directory: %w", err)
+	}
+
+	counts, err := readSiteManifest(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for date, matches := range days {
+		page, err := renderReport(reportView{Date: date, Matches: matches, IndexURL: "index.html"})
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, date+".html"), page, 0o644); err != nil {
+			return written, fmt.Errorf("failed to write page for %s: %w", date, err)
+		}
+		counts[date] = len(matches)
+		written++
+	}
+
+	var index []siteDay
+	for _, date := range slices.Backward(slices.Sorted(maps.Keys(counts))) {
+		index = append(index, siteDay{Date: date, Matches: counts[date]})
+	}
+
+	tmpl := template.Must(template.New("index").Parse(siteIndexTemplate))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Days      []siteDay
+		Generated string
+	}{index, displayTime(time.Now(), "02 Jan 2006 3:04 PM")}); err != nil {
+		return written, fmt.Errorf("failed to render site index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), buf.Bytes(), 0o644); err != nil {
+		return written, fmt.Errorf("failed to write site index: %w", err)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return written, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, siteManifest), data, 0o644); err != nil {
+		return written, fmt.Errorf("failed to write %s: %w", siteManifest, err)
+	}
+	return written + 1, nil
+}
+
+func readSiteManifest(dir string) (map[string]int, error) {
+	counts := make(map[string]int)
+	data, err := os.ReadFile(filepath.Join(dir, siteManifest))
+	if os.IsNotExist(err) {
+		return counts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", siteManifest, err)
+	}
+
+	var days []siteDay
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", siteManifest, err)
+	}
+	for _, d := range days {
+		counts[d.Date] = d.Matches
+	}
+	return counts, nil
+}
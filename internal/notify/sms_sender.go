@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	twilioURL    = "https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json"
+	smsTitleSize = 60
+	smsTimeout   = 15 * time.Second
+)
+
+// SMSConfig holds Twilio configuration for SMS alerts.
+type SMSConfig struct {
+	AccountSID string
+	AuthToken  string
+	From       string   // Twilio number or messaging service SID
+	To         []string // recipient numbers in E.164 format
+	AllMatches bool     // also text matches that aren't price sensitive
+	Enabled    bool
+}
+
+// SMSRenderer renders a match as a single terse SMS.
+type SMSRenderer struct{}
+
+// NewSMSRenderer creates a renderer for SMS alerts.
+func NewSMSRenderer() *SMSRenderer {
+	return &SMSRenderer{}
+}
+
+// Render produces "⚡ TICKER [keyword] Short title… URL".
+func (r *SMSRenderer) Render(data NotificationData) (*RenderedMessage, error) {
+	m := data.Match
+
+	var sb strings.Builder
+	if m.IsPriceSensitive {
+		sb.WriteString("⚡ ")
+	}
+	sb.WriteString(m.Tickers())
+	if len(m.KeywordsFound) > 0 {
+		fmt.Fprintf(&sb, " [%s]", m.KeywordsFound[0])
+	}
+
+	title := m.Title
+	if runes := []rune(title); len(runes) > smsTitleSize {
+		title = strings.TrimSpace(string(runes[:smsTitleSize])) + "…"
+	}
+	fmt.Fprintf(&sb, " %s %s", title, m.DocumentURL())
+
+	return &RenderedMessage{
+		Subject: "ASX Alert: " + m.Tickers(),
+		Text:    sb.String(),
+		URL:     m.DocumentURL(),
+	}, nil
+}
+
+// SMSSender delivers messages as SMS via the Twilio Messages API.
+type SMSSender struct {
+	cfg    SMSConfig
+	client *http.Client
+}
+
+// NewSMSSender creates a sender with the given Twilio configuration.
+func NewSMSSender(cfg SMSConfig) *SMSSender {
+	return &SMSSender{cfg: cfg, client: &http.Client{Timeout: smsTimeout}}
+}
+
+// Send texts the message to every recipient, returning the first error.
+func (s *SMSSender) Send(msg *RenderedMessage) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	endpoint := fmt.Sprintf(twilioURL, url.PathEscape(s.cfg.AccountSID))
+	var firstErr error
+	for _, to := range s.cfg.To {
+		form := url.Values{"To": {to}, "Body": {msg.Text}}
+		if strings.HasPrefix(s.cfg.From, "MG") {
+			form.Set("MessagingServiceSid", s.cfg.From)
+		} else {
+			form.Set("From", s.cfg.From)
+		}
+
+		if err := s.post(endpoint, form); err != nil {
+			log.Printf("SMS error: failed to send to %s (%s): %v", to, msg.Subject, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		log.Printf("SMS sent to %s: %s", to, msg.Subject)
+	}
+	return firstErr
+}
+
+func (s *SMSSender) post(endpoint string, form url.Values) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.cfg.AccountSID, s.cfg.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("twilio returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// SMSMatches texts price sensitive matches (or every match with AllMatches) to the configured numbers.
+func SMSMatches(matches []types.AnnotatedMatch, cfg SMSConfig) {
+	if !cfg.Enabled {
+		return
+	}
+
+	var selected []types.AnnotatedMatch
+	for _, am := range matches {
+		if cfg.AllMatches || am.Match.IsPriceSensitive {
+			selected = append(selected, am)
+		}
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	log.Printf("Texting %d matches to %d number(s)", len(selected), len(cfg.To))
+
+	renderer := NewSMSRenderer()
+	sender := NewSMSSender(cfg)
+
+	for _, am := range selected {
+		msg, err := renderer.Render(NotificationData{
+			Match:    am.Match,
+			Analysis: am.Analysis,
+		})
+		if err != nil {
+			log.Printf("SMS render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+
+		if err := sender.Send(msg); err != nil {
+			queueFailed(ChannelSMS, []types.AnnotatedMatch{am}, err)
+		}
+	}
+}
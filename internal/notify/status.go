@@ -0,0 +1,9 @@
+package notify
+
+// EmailStatus sends a plain text operational notice, such as a source outage.
+func EmailStatus(subject, text string, cfg EmailConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	return NewEmailSender(cfg).Send(&RenderedMessage{Subject: subject, Text: text})
+}
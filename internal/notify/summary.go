@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// RunSummary is the end of run report of what a scan did.
+type RunSummary struct {
+	Date          string
+	Duration      time.Duration
+	Reported      int              // announcements the feed reported, 0 if not exposed
+	Received      int              // feed rows received across all pages
+	Announcements int              // announcements in the feed after filtering
+	Processed     int              // announcements whose document was considered
+	Downloaded    int              // documents fetched from the network
+	Cached        int              // documents served from the document cache
+	Bandwidth     map[string]int64 // bytes downloaded by category
+	Matches       []types.AnnotatedMatch
+	Failures      map[string]int // skipped or failed announcements by reason
+	Usage         []ai.Usage
+}
+
+// Text renders the summary as plain text.
+func (s RunSummary) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Run summary for %s (%s)\n", s.Date, s.Duration.Round(time.Second))
+	if s.Reported > 0 {
+		fmt.Fprintf(&b, "  Feed:           %d of %d reported received\n", s.Received, s.Reported)
+	}
+	fmt.Fprintf(&b, "  Announcements:  %d scanned, %d processed\n", s.Announcements, s.Processed)
+	fmt.Fprintf(&b, "  Documents:      %d downloaded, %d from cache\n", s.Downloaded, s.Cached)
+
+	var total int64
+	for _, n := range s.Bandwidth {
+		total += n
+	}
+	fmt.Fprintf(&b, "  Transferred:    %s\n", FormatBytes(total))
+
+	byKeyword := make(map[string]int)
+	byTicker := make(map[string]int)
+	for _, am := range s.Matches {
+		for _, keyword := range am.Match.KeywordsFound {
+			byKeyword[keyword]++
+		}
+		byTicker[am.Match.Ticker]++
+	}
+	fmt.Fprintf(&b, "  Matches:        %d\n", len(s.Matches))
+	writeCounts(&b, "By keyword", byKeyword)
+	writeCounts(&b, "By ticker", byTicker)
+
+	failed := 0
+	for _, n := range s.Failures {
+		failed += n
+	}
+	fmt.Fprintf(&b, "  Errors:         %d\n", failed)
+	writeCounts(&b, "By category", s.Failures)
+
+	var in, out int
+	var cost float64
+	for _, u := range s.Usage {
+		in += u.InputTokens
+		out += u.OutputTokens
+		cost += u.CostUSD
+	}
+	if len(s.Usage) > 0 {
+		fmt.Fprintf(&b, "  AI tokens:      %d in, %d out (~$%.4f)\n", in, out, cost)
+	} else {
+		fmt.Fprintf(&b, "  AI tokens:      none\n")
+	}
+	return b.String()
+}
+
+// writeCounts writes counts as an indented line, largest first.
+func writeCounts(b *strings.Builder, label string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	keys := slices.SortedFunc(maps.Keys(counts), func(x, y string) int {
+		return cmp.Or(cmp.Compare(counts[y], counts[x]), strings.Compare(x, y))
+	})
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s %d", k, counts[k])
+	}
+	fmt.Fprintf(b, "    %-14s%s\n", label+":", strings.Join(parts, ", "))
+}
+
+// ReportRunSummary prints the summary to the console.
+func ReportRunSummary(s RunSummary) {
+	printf("%s%s%s", dim, s.Text(), reset)
+}
+
+// EmailRunSummary emails the summary as a plain text status message.
+func EmailRunSummary(s RunSummary, cfg EmailConfig) error {
+	subject := fmt.Sprintf("ASX scan summary for %s: %d match(es)", s.Date, len(s.Matches))
+	return EmailStatus(subject, s.Text(), cfg)
+}
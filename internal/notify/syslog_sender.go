@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// Severity is a syslog severity level, independent of the platform's log/syslog support.
+type Severity int
+
+// Severities used for syslog output, matching RFC 5424 values.
+const (
+	SeverityError   Severity = 3
+	SeverityWarning Severity = 4
+	SeverityNotice  Severity = 5
+	SeverityInfo    Severity = 6
+)
+
+// highScoreSeverity is the AI relevance score at which a match is logged as a warning.
+const highScoreSeverity = 80
+
+// SyslogConfig holds configuration for syslog/journald output.
+type SyslogConfig struct {
+	Address string // "" = local syslog (journald listens on /dev/log), or "udp://host:514", "tcp://host:514"
+	Tag     string
+	Enabled bool
+}
+
+// SyslogRenderer renders a match as a single log line.
+type SyslogRenderer struct{}
+
+// NewSyslogRenderer creates a renderer for syslog lines.
+func NewSyslogRenderer() *SyslogRenderer {
+	return &SyslogRenderer{}
+}
+
+// Render produces a one-line summary in Text; Subject carries the ticker.
+func (r *SyslogRenderer) Render(data NotificationData) (*RenderedMessage, error) {
+	m := data.Match
+
+	parts := []string{fmt.Sprintf("match %s: %s", m.Tickers(), m.Title)}
+	if m.IsPriceSensitive {
+		parts = append(parts, "price-sensitive")
+	}
+	if len(m.KeywordsFound) > 0 {
+		parts = append(parts, "keywords="+strings.Join(m.KeywordsFound, ","))
+	}
+	if data.Analysis != nil {
+		parts = append(parts, fmt.Sprintf("score=%d", data.Analysis.RelevanceScore))
+	}
+	parts = append(parts, "url="+m.PDFURL)
+
+	return &RenderedMessage{
+		Subject: m.Ticker,
+		Text:    strings.Join(parts, " | "),
+	}, nil
+}
+
+// MatchSeverity ranks a match: price sensitive or high-scoring matches are warnings, the rest notices.
+func MatchSeverity(am types.AnnotatedMatch) Severity {
+	if am.Match.IsPriceSensitive || (am.Analysis != nil && am.Analysis.RelevanceScore >= highScoreSeverity) {
+		return SeverityWarning
+	}
+	return SeverityNotice
+}
+
+// SyslogMatches logs each match to syslog with a severity from MatchSeverity.
+func SyslogMatches(matches []types.AnnotatedMatch, cfg SyslogConfig) {
+	if !cfg.Enabled || len(matches) == 0 {
+		return
+	}
+
+	sender, err := NewSyslogSender(cfg)
+	if err != nil {
+		log.Printf("Syslog error: %v", err)
+		return
+	}
+	defer sender.Close()
+
+	renderer := NewSyslogRenderer()
+	for _, am := range matches {
+		msg, err := renderer.Render(NotificationData{
+			Match:    am.Match,
+			Analysis: am.Analysis,
+		})
+		if err != nil {
+			log.Printf("Syslog render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+
+		if err := sender.SendSeverity(MatchSeverity(am), msg); err != nil {
+			log.Printf("Syslog error: %v", err)
+			return
+		}
+	}
+}
+
+// SyslogStatus logs a run status line (start, summary, failure) to syslog.
+func SyslogStatus(cfg SyslogConfig, severity Severity, format string, args ...any) {
+	if !cfg.Enabled {
+		return
+	}
+
+	sender, err := NewSyslogSender(cfg)
+	if err != nil {
+		log.Printf("Syslog error: %v", err)
+		return
+	}
+	defer sender.Close()
+
+	if err := sender.SendSeverity(severity, &RenderedMessage{Text: fmt.Sprintf(format, args...)}); err != nil {
+		log.Printf("Syslog error: %v", err)
+	}
+}
+
+// parseSyslogAddress splits "udp://host:514" into network and address. "" means the local daemon.
+func parseSyslogAddress(address string) (network, addr string, err error) {
+	if address == "" {
+		return "", "", nil
+	}
+	network, addr, ok := strings.Cut(address, "://")
+	if !ok {
+		return "", "", fmt.Errorf("syslog address %q must look like udp://host:514 or tcp://host:514", address)
+	}
+	switch network {
+	case "udp", "tcp", "unix", "unixgram":
+		return network, addr, nil
+	default:
+		return "", "", fmt.Errorf("unsupported syslog network %q", network)
+	}
+}
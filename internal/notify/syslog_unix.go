@@ -0,0 +1,51 @@
+//go:build !windows && !plan9
+
+package notify
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// SyslogSender writes messages to syslog (and so journald) at the user facility.
+type SyslogSender struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSender connects to the local or remote syslog daemon.
+func NewSyslogSender(cfg SyslogConfig) (*SyslogSender, error) {
+	network, addr, err := parseSyslogAddress(cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := syslog.Dial(network, addr, syslog.LOG_USER|syslog.LOG_NOTICE, cfg.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &SyslogSender{writer: writer}, nil
+}
+
+// Send logs the message text at notice severity.
+func (s *SyslogSender) Send(msg *RenderedMessage) error {
+	return s.SendSeverity(SeverityNotice, msg)
+}
+
+// SendSeverity logs the message text at the given severity.
+func (s *SyslogSender) SendSeverity(severity Severity, msg *RenderedMessage) error {
+	switch severity {
+	case SeverityError:
+		return s.writer.Err(msg.Text)
+	case SeverityWarning:
+		return s.writer.Warning(msg.Text)
+	case SeverityInfo:
+		return s.writer.Info(msg.Text)
+	default:
+		return s.writer.Notice(msg.Text)
+	}
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *SyslogSender) Close() error {
+	return s.writer.Close()
+}
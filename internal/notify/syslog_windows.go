@@ -0,0 +1,28 @@
+//go:build windows || plan9
+
+package notify
+
+import (
+	"errors"
+	"runtime"
+)
+
+// SyslogSender is unavailable on this platform.
+type SyslogSender struct{}
+
+// NewSyslogSender always fails: Go's log/syslog does not support this platform.
+func NewSyslogSender(cfg SyslogConfig) (*SyslogSender, error) {
+	if _, _, err := parseSyslogAddress(cfg.Address); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("syslog is not supported on " + runtime.GOOS)
+}
+
+// Send is a no-op.
+func (s *SyslogSender) Send(msg *RenderedMessage) error { return nil }
+
+// SendSeverity is a no-op.
+func (s *SyslogSender) SendSeverity(severity Severity, msg *RenderedMessage) error { return nil }
+
+// Close is a no-op.
+func (s *SyslogSender) Close() error { return nil }
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const teamsTimeout = 15 * time.Second
+
+// TeamsConfig holds configuration for Microsoft Teams incoming webhooks.
+type TeamsConfig struct {
+	WebhookURL string // incoming webhook or Workflows "post to a channel" URL
+	Enabled    bool
+}
+
+// TeamsRenderer renders a match as a Teams message carrying an Adaptive Card.
+type TeamsRenderer struct{}
+
+// NewTeamsRenderer creates a renderer for Teams Adaptive Cards.
+func NewTeamsRenderer() *TeamsRenderer {
+	return &TeamsRenderer{}
+}
+
+type adaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Render produces a message whose Text is the webhook payload.
+func (r *TeamsRenderer) Render(data NotificationData) (*RenderedMessage, error) {
+	m := data.Match
+	subject := fmt.Sprintf("ASX Alert: %s - %s", m.Tickers(), m.Title)
+
+	heading := m.Tickers()
+	if m.CompanyName != "" {
+		heading += " · " + m.CompanyName
+	}
+	if m.IsPriceSensitive {
+		heading += " ⚡"
+	}
+
+	facts := []adaptiveFact{{"Date", displayTime(m.DateTime, "02 Jan 2006 3:04 PM")}}
+	if len(m.KeywordsFound) > 0 {
+		facts = append(facts, adaptiveFact{"Keywords", strings.Join(m.KeywordsFound, ", ")})
+	}
+	if m.Reaction != nil {
+		facts = append(facts, adaptiveFact{"Reaction", m.Reaction.String()})
+	}
+	if data.Analysis != nil {
+		facts = append(facts, adaptiveFact{"AI Score", fmt.Sprintf("%d/100", data.Analysis.RelevanceScore)})
+	}
+
+	body := []map[string]any{
+		{"type": "TextBlock", "text": heading, "weight": "Bolder", "size": "Large", "wrap": true},
+		{"type": "TextBlock", "text": m.Title, "weight": "Bolder", "wrap": true},
+		{"type": "FactSet", "facts": facts},
+	}
+	if data.Analysis != nil && len(data.Analysis.Summary) > 0 {
+		body = append(body, map[string]any{
+			"type": "TextBlock",
+			"text": "- " + strings.Join(data.Analysis.Summary, "\n- "),
+			"wrap": true,
+		})
+	}
+
+	payload := map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"msteams": map[string]string{"width": "Full"},
+				"body":    body,
+				"actions": []map[string]string{{
+					"type":  "Action.OpenUrl",
+					"title": "Open announcement",
+					"url":   m.DocumentURL(),
+				}},
+			},
+		}},
+	}
+
+	text, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Teams card: %w", err)
+	}
+
+	return &RenderedMessage{
+		Subject: subject,
+		Text:    string(text),
+		URL:     m.DocumentURL(),
+	}, nil
+}
+
+// TeamsSender posts rendered cards to a Teams webhook.
+type TeamsSender struct {
+	cfg    TeamsConfig
+	client *http.Client
+}
+
+// NewTeamsSender creates a sender for the configured webhook.
+func NewTeamsSender(cfg TeamsConfig) *TeamsSender {
+	return &TeamsSender{cfg: cfg, client: &http.Client{Timeout: teamsTimeout}}
+}
+
+// Send posts the message text, which must be a Teams webhook payload.
+func (s *TeamsSender) Send(msg *RenderedMessage) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+
+	resp, err := s.client.Post(s.cfg.WebhookURL, "application/json", bytes.NewReader([]byte(msg.Text)))
+	if err != nil {
+		return fmt.Errorf("teams webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("teams webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	log.Printf("Teams message sent: %s", msg.Subject)
+	return nil
+}
+
+// TeamsMatches posts each match to Teams as an Adaptive Card.
+func TeamsMatches(matches []types.AnnotatedMatch, cfg TeamsConfig) {
+	if !cfg.Enabled || len(matches) == 0 {
+		return
+	}
+
+	log.Printf("Posting %d matches to Teams", len(matches))
+
+	renderer := NewTeamsRenderer()
+	sender := NewTeamsSender(cfg)
+
+	for i, am := range matches {
+		msg, err := renderer.Render(NotificationData{
+			Match:    am.Match,
+			Analysis: am.Analysis,
+		})
+		if err != nil {
+			log.Printf("Teams render error for %s: %v", am.Match.Ticker, err)
+			continue
+		}
+
+		if err := sender.Send(msg); err != nil {
+			log.Printf("Teams error: %v", err)
+			queueFailed(ChannelTeams, matches[i:], err)
+			return // the webhook is unreachable, don't repeat the same error for every match
+		}
+	}
+}
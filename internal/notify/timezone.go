@@ -0,0 +1,21 @@
+package notify
+
+import "time"
+
+// displayLocation is the time zone announcement times are shown in, nil = as released
+// (Sydney time).
+var displayLocation *time.Location
+
+// SetDisplayLocation shows announcement times in reports, emails and messages in loc,
+// followed by the zone's abbreviation.
+func SetDisplayLocation(loc *time.Location) {
+	displayLocation = loc
+}
+
+// displayTime formats t with layout in the display time zone.
+func displayTime(t time.Time, layout string) string {
+	if displayLocation == nil {
+		return t.Format(layout)
+	}
+	return t.In(displayLocation).Format(layout + " MST")
+}
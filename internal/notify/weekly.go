@@ -0,0 +1,224 @@
+package notify
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"html/template"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+const (
+	weeklyTopCatalysts = 10
+	weeklyDateHorizon  = 21 * 24 * time.Hour
+)
+
+// WeeklyWrap is the input for the weekly summary report.
+type WeeklyWrap struct {
+	From    time.Time
+	To      time.Time
+	Matches []types.AnnotatedMatch
+	Scanned int // announcements archived in the period, -1 = unknown (archive disabled)
+}
+
+// TickerSummary aggregates a ticker's matches for the week.
+type TickerSummary struct {
+	Ticker         string
+	Matches        int
+	PriceSensitive int
+	TopScore       int // -1 = no AI analysis
+	Titles         []string
+}
+
+// CategoryCount is the number of catalysts the AI filed under a category.
+type CategoryCount struct {
+	Category string
+	Count    int
+}
+
+// WeeklyCatalyst is an AI-identified catalyst with the match it came from.
+type WeeklyCatalyst struct {
+	Ticker   string
+	Title    string
+	URL      string
+	Score    int
+	Category string
+	Details  string
+}
+
+// WeeklyDate is a key date falling shortly after the week.
+type WeeklyDate struct {
+	Ticker string
+	Date   string
+	Type   string
+	Event  string
+}
+
+// weeklyView is the data passed to the weekly template.
+type weeklyView struct {
+	From, To      string
+	Scanned       int
+	TotalMatches  int
+	Tickers       []TickerSummary
+	Categories    []CategoryCount
+	TopCatalysts  []WeeklyCatalyst
+	UpcomingDates []WeeklyDate
+}
+
+func buildWeeklyView(wrap WeeklyWrap) weeklyView {
+	view := weeklyView{
+		From:         wrap.From.Format("Mon 02 Jan"),
+		To:           wrap.To.Format("Mon 02 Jan 2006"),
+		Scanned:      wrap.Scanned,
+		TotalMatches: len(wrap.Matches),
+	}
+
+	tickers := make(map[string]*TickerSummary)
+	categories := make(map[string]int)
+	horizon := wrap.To.Add(weeklyDateHorizon).Format("2006-01-02")
+	today := wrap.To.Format("2006-01-02")
+
+	for _, am := range wrap.Matches {
+		m := am.Match
+		t := tickers[m.Ticker]
+		if t == nil {
+			t = &TickerSummary{Ticker: m.Ticker, TopScore: -1}
+			tickers[m.Ticker] = t
+		}
+		t.Matches++
+		if m.IsPriceSensitive {
+			t.PriceSensitive++
+		}
+		t.Titles = append(t.Titles, m.Title)
+
+		if am.Analysis == nil {
+			continue
+		}
+		t.TopScore = max(t.TopScore, am.Analysis.RelevanceScore)
+
+		for _, c := range am.Analysis.PotentialCatalysts {
+			categories[c.Category]++
+			view.TopCatalysts = append(view.TopCatalysts, WeeklyCatalyst{
+				Ticker:   m.Ticker,
+				Title:    m.Title,
+				URL:      m.PDFURL,
+				Score:    am.Analysis.RelevanceScore,
+				Category: c.Category,
+				Details:  c.Details,
+			})
+		}
+		for _, d := range am.Analysis.KeyDates {
+			if d.Date >= today && d.Date <= horizon {
+				view.UpcomingDates = append(view.UpcomingDates, WeeklyDate{Ticker: m.Ticker, Date: d.Date, Type: d.Type, Event: d.Event})
+			}
+		}
+	}
+
+	for _, t := range tickers {
+		view.Tickers = append(view.Tickers, *t)
+	}
+	slices.SortFunc(view.Tickers, func(a, b TickerSummary) int {
+		return cmp.Or(cmp.Compare(b.Matches, a.Matches), cmp.Compare(b.TopScore, a.TopScore), cmp.Compare(a.Ticker, b.Ticker))
+	})
+
+	for category, count := range categories {
+		view.Categories = append(view.Categories, CategoryCount{Category: category, Count: count})
+	}
+	slices.SortFunc(view.Categories, func(a, b CategoryCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Category, b.Category))
+	})
+
+	slices.SortStableFunc(view.TopCatalysts, func(a, b WeeklyCatalyst) int { return cmp.Compare(b.Score, a.Score) })
+	if len(view.TopCatalysts) > weeklyTopCatalysts {
+		view.TopCatalysts = view.TopCatalysts[:weeklyTopCatalysts]
+	}
+
+	slices.SortFunc(view.UpcomingDates, func(a, b WeeklyDate) int { return cmp.Compare(a.Date, b.Date) })
+
+	return view
+}
+
+// RenderWeeklyWrap renders the weekly summary as an HTML email with a plain text alternative.
+func RenderWeeklyWrap(wrap WeeklyWrap) (*RenderedMessage, error) {
+	view := buildWeeklyView(wrap)
+
+	var htmlBuf bytes.Buffer
+	tmpl := template.Must(template.New("weekly").Parse(weeklyHTMLTemplate))
+	if err := tmpl.Execute(&htmlBuf, view); err != nil {
+		return nil, fmt.Errorf("failed to render weekly template: %w", err)
+	}
+
+	return &RenderedMessage{
+		Subject: fmt.Sprintf("ASX Weekly Wrap: %s – %s (%d matches)", view.From, view.To, view.TotalMatches),
+		Text:    renderWeeklyText(view),
+		HTML:    htmlBuf.String(),
+	}, nil
+}
+
+func renderWeeklyText(view weeklyView) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "ASX WEEKLY WRAP: %s – %s\n", view.From, view.To)
+	sb.WriteString(strings.Repeat("=", 50) + "\n\n")
+	fmt.Fprintf(&sb, "%d matches across %d tickers", view.TotalMatches, len(view.Tickers))
+	if view.Scanned >= 0 {
+		fmt.Fprintf(&sb, " from %d announcements scanned", view.Scanned)
+	}
+	sb.WriteString("\n\n")
+
+	if len(view.Tickers) > 0 {
+		sb.WriteString("BY TICKER\n" + strings.Repeat("-", 20) + "\n")
+		for _, t := range view.Tickers {
+			score := ""
+			if t.TopScore >= 0 {
+				score = fmt.Sprintf(", top score %d", t.TopScore)
+			}
+			fmt.Fprintf(&sb, "%-6s %d match(es), %d price sensitive%s\n", t.Ticker, t.Matches, t.PriceSensitive, score)
+			for _, title := range t.Titles {
+				fmt.Fprintf(&sb, "       - %s\n", title)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(view.Categories) > 0 {
+		sb.WriteString("CATALYST CATEGORIES\n" + strings.Repeat("-", 20) + "\n")
+		for _, c := range view.Categories {
+			fmt.Fprintf(&sb, "%3d  %s\n", c.Count, c.Category)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(view.TopCatalysts) > 0 {
+		sb.WriteString("TOP CATALYSTS\n" + strings.Repeat("-", 20) + "\n")
+		for _, c := range view.TopCatalysts {
+			fmt.Fprintf(&sb, "%s (score %d) [%s] %s\n", c.Ticker, c.Score, c.Category, c.Details)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(view.UpcomingDates) > 0 {
+		sb.WriteString("UPCOMING DATES\n" + strings.Repeat("-", 20) + "\n")
+		for _, d := range view.UpcomingDates {
+			fmt.Fprintf(&sb, "%s  %-6s %s (%s)\n", d.Date, d.Ticker, d.Event, d.Type)
+		}
+	}
+
+	return sb.String()
+}
+
+// EmailWeeklyWrap sends the weekly summary. It returns an error so schedulers can retry.
+func EmailWeeklyWrap(wrap WeeklyWrap, cfg EmailConfig) error {
+	msg, err := RenderWeeklyWrap(wrap)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Emailing weekly wrap with %d matches (%s)", len(wrap.Matches), cfg.Transport())
+	return NewEmailSender(cfg).Send(msg)
+}
@@ -0,0 +1,136 @@
+/*
+Package paths locates annscraper's persistent data and caches.
+
+Data (report history, notifications, the archive) lives in the XDG data directory and
+downloads that can be fetched again live in the XDG cache directory, so neither is lost
+when the system temp directory is cleaned on reboot.
+*/
+package paths
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+const appName = "annscraper"
+
+var (
+	mutex   sync.Mutex
+	dataDir string // set by SetDataDir, "" = platform default
+)
+
+// SetDataDir overrides the data directory. Caches then go in its cache subdirectory.
+// An empty dir restores the platform defaults.
+func SetDataDir(dir string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	dataDir = dir
+}
+
+// DataDir returns the directory for persistent data: $XDG_DATA_HOME/annscraper, falling back
+// to ~/.local/share/annscraper, ~/Library/Application Support/annscraper on macOS and
+// %LocalAppData%\annscraper on Windows.
+func DataDir() string {
+	mutex.Lock()
+	override := dataDir
+	mutex.Unlock()
+	if override != "" {
+		return override
+	}
+
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName)
+	}
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, appName)
+		}
+	case "darwin", "ios":
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, "Library", "Application Support", appName)
+		}
+	default:
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".local", "share", appName)
+		}
+	}
+	return LegacyDir()
+}
+
+// CacheDir returns the directory for data that can be downloaded again: the cache
+// subdirectory of an overridden data directory, otherwise the platform's user cache
+// directory ($XDG_CACHE_HOME, ~/.cache, ...).
+func CacheDir() string {
+	mutex.Lock()
+	override := dataDir
+	mutex.Unlock()
+	if override != "" {
+		return filepath.Join(override, "cache")
+	}
+
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, appName)
+	}
+	return LegacyDir()
+}
+
+// LegacyDir returns the temp directory that earlier versions kept everything in.
+func LegacyDir() string {
+	return filepath.Join(os.TempDir(), appName)
+}
+
+// MigrateLegacy moves name from LegacyDir to dir if it exists there and not in dir, so
+// upgrading doesn't lose the report history and resend alerts. It reports whether a file
+// was moved.
+func MigrateLegacy(dir, name string) (bool, error) {
+	from, to := filepath.Join(LegacyDir(), name), filepath.Join(dir, name)
+	if from == to {
+		return false, nil
+	}
+	if _, err := os.Stat(to); !os.IsNotExist(err) {
+		return false, nil
+	}
+	if _, err := os.Stat(from); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false, err
+	}
+	if err := os.Rename(from, to); err == nil {
+		return true, nil
+	}
+
+	// The temp directory is often a different filesystem, so fall back to copying.
+	if err := copyFile(from, to); err != nil {
+		os.Remove(to)
+		return false, err
+	}
+	return true, os.Remove(from)
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
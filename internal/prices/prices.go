@@ -0,0 +1,184 @@
+/*
+Package prices fetches intraday prices from Yahoo Finance to show how the market has reacted
+to an announcement since it was released.
+*/
+package prices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	chartURL       = "https://query1.finance.yahoo.com/v8/finance/chart/"
+	requestTimeout = 20 * time.Second
+	userAgent      = "Mozilla/5.0 (compatible; annscraper)"
+)
+
+var client = &http.Client{Timeout: requestTimeout}
+
+// Reaction is the price and volume move in a ticker since an announcement.
+type Reaction struct {
+	Before      float64   `json:"before"`       // last traded price before the announcement
+	Last        float64   `json:"last"`         // latest traded price
+	ChangePct   float64   `json:"change_pct"`   // % change from Before to Last
+	Volume      int64     `json:"volume"`       // shares traded since the announcement
+	AvgVolume   int64     `json:"avg_volume"`   // average daily volume over the prior month
+	VolumeRatio float64   `json:"volume_ratio"` // Volume / AvgVolume, 0 if unknown
+	AsOf        time.Time `json:"as_of"`
+}
+
+// String describes the reaction, e.g. "price +14.0% on 3.1x average volume since announcement".
+func (r *Reaction) String() string {
+	s := fmt.Sprintf("price %+.1f%%", r.ChangePct)
+	if r.VolumeRatio > 0 {
+		s += fmt.Sprintf(" on %.1fx average volume", r.VolumeRatio)
+	}
+	return s + " since announcement"
+}
+
+// chart is the subset of the Yahoo Finance chart response used here.
+type chart struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				ChartPreviousClose float64 `json:"chartPreviousClose"`
+			} `json:"meta"`
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Close  []*float64 `json:"close"`
+					Volume []*int64   `json:"volume"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// bar is one interval of a chart.
+type bar struct {
+	at     time.Time
+	close  float64
+	volume int64
+}
+
+// Fetch returns the reaction in an ASX ticker since the announcement released at since.
+func Fetch(ctx context.Context, ticker string, since time.Time) (*Reaction, error) {
+	symbol := strings.ToUpper(ticker) + ".AX"
+
+	intraday, previousClose, err := fetchChart(ctx, symbol, "5d", "5m")
+	if err != nil {
+		return nil, err
+	}
+	if len(intraday) == 0 {
+		return nil, fmt.Errorf("no intraday prices for %s", symbol)
+	}
+
+	r := &Reaction{Before: previousClose}
+	for _, b := range intraday {
+		if b.at.Before(since) {
+			r.Before = b.close
+			continue
+		}
+		r.Volume += b.volume
+	}
+	last := intraday[len(intraday)-1]
+	r.Last, r.AsOf = last.close, last.at
+
+	if r.Before <= 0 {
+		return nil, fmt.Errorf("no price before the announcement for %s", symbol)
+	}
+	r.ChangePct = (r.Last - r.Before) / r.Before * 100
+
+	daily, _, err := fetchChart(ctx, symbol, "1mo", "1d")
+	if err == nil {
+		r.AvgVolume = averageVolume(daily, since)
+		if r.AvgVolume > 0 {
+			r.VolumeRatio = float64(r.Volume) / float64(r.AvgVolume)
+		}
+	}
+
+	return r, nil
+}
+
+// averageVolume averages the daily volume of the trading days before the announcement's.
+func averageVolume(daily []bar, since time.Time) int64 {
+	day := asxDate(since)
+
+	var total, days int64
+	for _, b := range daily {
+		if asxDate(b.at) >= day {
+			continue
+		}
+		total += b.volume
+		days++
+	}
+	if days == 0 {
+		return 0
+	}
+	return total / days
+}
+
+// asxDate returns the Sydney calendar date of t, which daily bars are stamped by.
+func asxDate(t time.Time) string {
+	if loc, err := time.LoadLocation("Australia/Sydney"); err == nil {
+		t = t.In(loc)
+	}
+	return t.Format("2006-01-02")
+}
+
+func fetchChart(ctx context.Context, symbol, rangeParam, interval string) ([]bar, float64, error) {
+	u := chartURL + url.PathEscape(symbol) + "?" + url.Values{
+		"range":    {rangeParam},
+		"interval": {interval},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch prices for %s: %w", symbol, err)
+	}
+	defer resp.Body.Close()
+
+	var c chart
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode prices for %s (status %d): %w", symbol, resp.StatusCode, err)
+	}
+	if c.Chart.Error != nil {
+		return nil, 0, fmt.Errorf("prices for %s: %s", symbol, c.Chart.Error.Description)
+	}
+	if len(c.Chart.Result) == 0 || len(c.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, 0, errors.New("no price data for " + symbol)
+	}
+
+	result := c.Chart.Result[0]
+	quote := result.Indicators.Quote[0]
+
+	var bars []bar
+	for i, ts := range result.Timestamp {
+		if i >= len(quote.Close) || quote.Close[i] == nil {
+			continue
+		}
+		b := bar{at: time.Unix(ts, 0), close: *quote.Close[i]}
+		if i < len(quote.Volume) && quote.Volume[i] != nil {
+			b.volume = *quote.Volume[i]
+		}
+		bars = append(bars, b)
+	}
+	return bars, result.Meta.ChartPreviousClose, nil
+}
@@ -0,0 +1,274 @@
+// Package routing ranks matches by severity and decides which notification channels each
+// severity is delivered to, from a rules file such as:
+//
+//	# Severity: matches start as normal and are raised by any rule they meet.
+//	halt = critical
+//	score 90 = critical
+//	price-sensitive = high
+//	keyword takeover, scheme of arrangement = critical
+//
+//	# Routes: severities without a route go to every enabled channel.
+//	critical -> sms, email
+//	high -> email
+//	normal -> digest
+package routing
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shanehull/annscraper/internal/notify"
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// Severity ranks how urgently a match should reach the user.
+type Severity int
+
+const (
+	SeverityNormal Severity = iota
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = []string{"normal", "high", "critical"}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// ParseSeverity parses "normal", "high" or "critical".
+func ParseSeverity(name string) (Severity, error) {
+	name = strings.TrimSpace(name)
+	i := slices.Index(severityNames, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown severity %q (expected normal, high or critical)", name)
+	}
+	return Severity(i), nil
+}
+
+// ChannelDigest queues email for the next -digest-times digest rather than sending it.
+const ChannelDigest = "digest"
+
+// Channels that can be routed to, besides "none", which drops the matches.
+var Channels = []string{
+	notify.ChannelConsole, notify.ChannelEmail, ChannelDigest, notify.ChannelExec, notify.ChannelDesktop, notify.ChannelSyslog, notify.ChannelNtfy,
+	notify.ChannelSMS, notify.ChannelTeams, notify.ChannelMatrix, notify.ChannelNATS, notify.ChannelKafka, notify.ChannelMQTT,
+}
+
+// DefaultRules are used when the rules file has no severity rules of its own.
+var DefaultRules = []Rule{
+	{Severity: SeverityCritical, Halt: true},
+	{Severity: SeverityCritical, MinScore: 90},
+	{Severity: SeverityHigh, PriceSensitive: true},
+	{Severity: SeverityHigh, MinScore: 75},
+}
+
+// Rule raises a match to Severity when it meets the rule's one condition.
+type Rule struct {
+	Severity       Severity
+	Halt           bool     // the match follows a trading halt
+	PriceSensitive bool     // the announcement is price sensitive
+	Ticker         bool     // the match is for one of the configured tickers
+	MinScore       int      // the AI relevance score is at least this, 0 = unused
+	Keywords       []string // any of these keywords was found (lowercase)
+}
+
+func (r Rule) matches(am types.AnnotatedMatch) bool {
+	m := am.Match
+	switch {
+	case r.Halt:
+		return m.FollowsHalt != nil
+	case r.PriceSensitive:
+		return m.IsPriceSensitive
+	case r.Ticker:
+		return m.TickerMatched
+	case r.MinScore > 0:
+		return am.Analysis != nil && am.Analysis.RelevanceScore >= r.MinScore
+	}
+	return slices.ContainsFunc(m.KeywordsFound, func(k string) bool {
+		return slices.Contains(r.Keywords, strings.ToLower(k))
+	})
+}
+
+// Rules are the severity rules and routes read from a rules file.
+type Rules struct {
+	Rules  []Rule
+	Routes map[Severity][]string // severity -> channels, missing = every channel
+}
+
+// Load reads a rules file.
+func Load(path string) (*Rules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f, path)
+}
+
+func parse(r io.Reader, name string) (*Rules, error) {
+	rules := &Rules{Routes: make(map[Severity][]string)}
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var err error
+		if from, to, ok := strings.Cut(line, "->"); ok {
+			err = rules.addRoute(from, to)
+		} else if cond, sev, ok := strings.Cut(line, "="); ok {
+			err = rules.addRule(cond, sev)
+		} else {
+			err = fmt.Errorf("expected 'condition = severity' or 'severity -> channels', got %q", line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	if len(rules.Rules) == 0 {
+		rules.Rules = DefaultRules
+	}
+	return rules, nil
+}
+
+func (r *Rules) addRule(condition, severity string) error {
+	sev, err := ParseSeverity(severity)
+	if err != nil {
+		return err
+	}
+
+	rule := Rule{Severity: sev}
+	kind, arg, _ := strings.Cut(strings.TrimSpace(condition), " ")
+	arg = strings.TrimSpace(arg)
+	switch kind {
+	case "halt":
+		rule.Halt = true
+	case "price-sensitive":
+		rule.PriceSensitive = true
+	case "ticker":
+		rule.Ticker = true
+	case "score":
+		if rule.MinScore, err = strconv.Atoi(arg); err != nil || rule.MinScore < 1 || rule.MinScore > 100 {
+			return fmt.Errorf("invalid score %q (expected 1-100)", arg)
+		}
+	case "keyword":
+		for k := range strings.SplitSeq(arg, ",") {
+			if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+				rule.Keywords = append(rule.Keywords, k)
+			}
+		}
+		if len(rule.Keywords) == 0 {
+			return fmt.Errorf("keyword rule lists no keywords")
+		}
+	default:
+		return fmt.Errorf("unknown condition %q (expected halt, price-sensitive, ticker, score N or keyword a, b)", kind)
+	}
+	if kind != "score" && kind != "keyword" && arg != "" {
+		return fmt.Errorf("condition %q takes no argument", kind)
+	}
+
+	r.Rules = append(r.Rules, rule)
+	return nil
+}
+
+func (r *Rules) addRoute(severity, channels string) error {
+	sev, err := ParseSeverity(severity)
+	if err != nil {
+		return err
+	}
+	if _, ok := r.Routes[sev]; ok {
+		return fmt.Errorf("%s is routed twice", sev)
+	}
+
+	route := []string{}
+	for ch := range strings.SplitSeq(channels, ",") {
+		ch = strings.ToLower(strings.TrimSpace(ch))
+		switch {
+		case ch == "none":
+		case slices.Contains(Channels, ch):
+			route = append(route, ch)
+		default:
+			return fmt.Errorf("unknown channel %q (expected none or one of %s)", ch, strings.Join(Channels, ", "))
+		}
+	}
+	r.Routes[sev] = route
+	return nil
+}
+
+// Severity returns the highest severity of the rules am meets, normal if it meets none.
+func (r *Rules) Severity(am types.AnnotatedMatch) Severity {
+	sev := SeverityNormal
+	for _, rule := range r.Rules {
+		if rule.Severity > sev && rule.matches(am) {
+			sev = rule.Severity
+		}
+	}
+	return sev
+}
+
+// Channels returns the channels am is routed to, and false when its severity has no route
+// (or r is nil), in which case it goes to every enabled channel.
+func (r *Rules) Channels(am types.AnnotatedMatch) ([]string, bool) {
+	if r == nil {
+		return nil, false
+	}
+	channels, ok := r.Routes[r.Severity(am)]
+	return channels, ok
+}
+
+// Filter returns the matches routed to channel.
+func (r *Rules) Filter(matches []types.AnnotatedMatch, channel string) []types.AnnotatedMatch {
+	if r == nil || len(r.Routes) == 0 {
+		return matches
+	}
+
+	var routed []types.AnnotatedMatch
+	for _, am := range matches {
+		if channels, ok := r.Channels(am); !ok || slices.Contains(channels, channel) {
+			routed = append(routed, am)
+		}
+	}
+	return routed
+}
+
+// RoutesTo reports whether any severity is routed to channel.
+func (r *Rules) RoutesTo(channel string) bool {
+	if r == nil {
+		return false
+	}
+	for _, channels := range r.Routes {
+		if slices.Contains(channels, channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes how many matches there are of each severity, e.g. "1 critical, 4 normal".
+func (r *Rules) Summary(matches []types.AnnotatedMatch) string {
+	counts := make(map[Severity]int)
+	for _, am := range matches {
+		counts[r.Severity(am)]++
+	}
+
+	var parts []string
+	for sev := SeverityCritical; sev >= SeverityNormal; sev-- {
+		if counts[sev] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[sev], sev))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
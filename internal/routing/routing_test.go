@@ -0,0 +1,35 @@
+package routing
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shanehull/annscraper/internal/notify"
+)
+
+func TestParseRoutes(t *testing.T) {
+	rules, err := parse(strings.NewReader("critical -> SMS, console\nhigh -> desktop, syslog, digest\nnormal -> none\n"), "routes")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	want := map[Severity][]string{
+		SeverityCritical: {notify.ChannelSMS, notify.ChannelConsole},
+		SeverityHigh:     {notify.ChannelDesktop, notify.ChannelSyslog, ChannelDigest},
+		SeverityNormal:   {},
+	}
+	for sev, channels := range want {
+		if got := rules.Routes[sev]; !slices.Equal(got, channels) {
+			t.Errorf("%s routes = %q, want %q", sev, got, channels)
+		}
+	}
+}
+
+func TestParseRoutesUnknownChannel(t *testing.T) {
+	for _, route := range []string{"critical -> pager", "high -> email, slack", "normal -> "} {
+		if _, err := parse(strings.NewReader(route), "routes"); err == nil || !strings.Contains(err.Error(), "unknown channel") {
+			t.Errorf("parse(%q) = %v, want an unknown channel error", route, err)
+		}
+	}
+}
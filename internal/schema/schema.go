@@ -0,0 +1,213 @@
+// Package schema publishes the JSON Schema of the match export format: the JSON encoding of
+// types.AnnotatedMatch written by the stream, MQTT, NATS, Kafka, upload and JSON renderer
+// outputs.
+//
+// match.schema.json must be updated with types.Match and ai.AIAnalysis. Adding an optional
+// field is compatible; removing or renaming one, or changing its type, needs a new Version.
+package schema
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/types"
+)
+
+// Version is the version of the match schema, also the last element of its $id.
+const Version = "v1"
+
+//go:embed match.schema.json
+var matchSchema []byte
+
+// Match returns the JSON Schema document for a match.
+func Match() []byte {
+	return slices.Clone(matchSchema)
+}
+
+// MarshalMatch encodes am as JSON and validates the result against the schema, so a change
+// to the types that breaks the published contract fails the write rather than consumers.
+func MarshalMatch(am types.AnnotatedMatch) ([]byte, error) {
+	data, err := json.Marshal(am)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// node is the subset of JSON Schema the match schema uses.
+type node struct {
+	Ref                  string           `json:"$ref"`
+	Type                 typeList         `json:"type"`
+	Format               string           `json:"format"`
+	AnyOf                []*node          `json:"anyOf"`
+	Properties           map[string]*node `json:"properties"`
+	Required             []string         `json:"required"`
+	AdditionalProperties json.RawMessage  `json:"additionalProperties"`
+	Items                *node            `json:"items"`
+	Defs                 map[string]*node `json:"$defs"`
+}
+
+// typeList is a "type" keyword, either a single type or a list of them.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+var root = sync.OnceValues(func() (*node, error) {
+	var n node
+	if err := json.Unmarshal(matchSchema, &n); err != nil {
+		return nil, fmt.Errorf("invalid embedded match schema: %w", err)
+	}
+	return &n, nil
+})
+
+// Validate checks that data is a single match document conforming to the schema.
+func Validate(data []byte) error {
+	schema, err := root()
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("invalid match JSON: %w", err)
+	}
+	v := validator{defs: schema.Defs}
+	if err := v.check(schema, doc, "$"); err != nil {
+		return fmt.Errorf("match does not conform to schema %s: %w", Version, err)
+	}
+	return nil
+}
+
+type validator struct {
+	defs map[string]*node
+}
+
+func (v validator) check(n *node, value any, path string) error {
+	if n.Ref != "" {
+		def, ok := v.defs[strings.TrimPrefix(n.Ref, "#/$defs/")]
+		if !ok {
+			return fmt.Errorf("%s: unresolved reference %s", path, n.Ref)
+		}
+		return v.check(def, value, path)
+	}
+
+	if len(n.AnyOf) > 0 {
+		var firstErr error
+		for _, alt := range n.AnyOf {
+			err := v.check(alt, value, path)
+			if err == nil {
+				return nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	if len(n.Type) > 0 && !slices.Contains(n.Type, jsonType(value)) &&
+		!(jsonType(value) == "number" && slices.Contains(n.Type, "integer") && isInteger(value)) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(n.Type, " or "), jsonType(value))
+	}
+
+	switch value := value.(type) {
+	case string:
+		if n.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				return fmt.Errorf("%s: invalid date-time %q", path, value)
+			}
+		}
+	case []any:
+		if n.Items != nil {
+			for i, item := range value {
+				if err := v.check(n.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		for _, name := range n.Required {
+			if _, ok := value[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for name, field := range value {
+			fieldPath := path + "." + name
+			if prop, ok := n.Properties[name]; ok {
+				if err := v.check(prop, field, fieldPath); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := v.checkAdditional(n.AdditionalProperties, field, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkAdditional checks a property not listed in properties against additionalProperties,
+// which is either a boolean or a schema.
+func (v validator) checkAdditional(raw json.RawMessage, value any, path string) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var allowed bool
+	if err := json.Unmarshal(raw, &allowed); err == nil {
+		if !allowed {
+			return fmt.Errorf("%s: unexpected property", path)
+		}
+		return nil
+	}
+	var n node
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return fmt.Errorf("%s: invalid additionalProperties schema: %w", path, err)
+	}
+	return v.check(&n, value, path)
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func isInteger(value any) bool {
+	n, ok := value.(json.Number)
+	if !ok {
+		return false
+	}
+	_, err := n.Int64()
+	return err == nil
+}
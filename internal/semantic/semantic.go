@@ -0,0 +1,218 @@
+/*
+Package semantic matches announcements against plain-language concept queries, such as
+"imminent capital raise", by the cosine similarity of their embeddings, catching documents
+that describe a concept without using any configured keyword.
+*/
+package semantic
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shanehull/annscraper/internal/ai"
+)
+
+const (
+	DefaultModel       = "gemini-embedding-001"
+	DefaultOllamaModel = "nomic-embed-text"
+	DefaultThreshold   = 0.75
+
+	chunkChars     = 1500 // roughly what fits comfortably in an embedding model's input
+	maxChunks      = 16   // long documents are only embedded up to here
+	batchSize      = 100  // texts per embedding request
+	requestTimeout = 2 * time.Minute
+)
+
+// Embedder turns texts into embedding vectors.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Gemini embeds with a Gemini embedding model through the configured AI backend.
+type Gemini struct {
+	APIKey string
+	Model  string
+}
+
+func (g Gemini) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return ai.Embed(ctx, g.APIKey, g.Model, texts)
+}
+
+// Ollama embeds with a local model served by Ollama, or anything implementing its
+// /api/embed endpoint.
+type Ollama struct {
+	URL   string // e.g. http://localhost:11434
+	Model string // e.g. nomic-embed-text
+}
+
+var client = &http.Client{Timeout: requestTimeout}
+
+func (o Ollama) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": o.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(o.URL, "/")+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding request failed: %s", resp.Status)
+	}
+
+	var result struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding response: %w", err)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(result.Embeddings), len(texts))
+	}
+	return result.Embeddings, nil
+}
+
+// Result is a query a document matched and how closely.
+type Result struct {
+	Query      string
+	Similarity float64
+	Passage    string // the passage most similar to the query
+}
+
+// Matcher compares documents against a fixed set of queries. Query embeddings are computed
+// on first use and reused for every document.
+type Matcher struct {
+	queries   []string
+	threshold float64
+	embedder  Embedder
+
+	mutex     sync.Mutex
+	queryVecs [][]float32
+}
+
+// NewMatcher returns a Matcher reporting queries whose similarity to a document reaches
+// threshold (0 = DefaultThreshold).
+func NewMatcher(queries []string, threshold float64, embedder Embedder) *Matcher {
+	if threshold == 0 {
+		threshold = DefaultThreshold
+	}
+	return &Matcher{queries: queries, threshold: threshold, embedder: embedder}
+}
+
+// Match returns the queries the title and text match, most similar first. Each query is
+// scored by its best matching passage, so a concept mentioned once in a long document
+// still counts.
+func (m *Matcher) Match(ctx context.Context, title, text string) ([]Result, error) {
+	queryVecs, err := m.queryVectors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed queries: %w", err)
+	}
+
+	passages := chunks(title, text)
+	vecs, err := embedAll(ctx, m.embedder, passages)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for i, query := range m.queries {
+		best := Result{Query: query}
+		for j, v := range vecs {
+			if sim := cosine(queryVecs[i], v); sim > best.Similarity {
+				best.Similarity, best.Passage = sim, passages[j]
+			}
+		}
+		if best.Similarity >= m.threshold {
+			results = append(results, best)
+		}
+	}
+	slices.SortFunc(results, func(a, b Result) int {
+		return cmp.Compare(b.Similarity, a.Similarity)
+	})
+	return results, nil
+}
+
+// queryVectors embeds the queries once, retrying on later calls if embedding failed.
+func (m *Matcher) queryVectors(ctx context.Context) ([][]float32, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.queryVecs == nil {
+		vecs, err := embedAll(ctx, m.embedder, m.queries)
+		if err != nil {
+			return nil, err
+		}
+		m.queryVecs = vecs
+	}
+	return m.queryVecs, nil
+}
+
+// embedAll embeds texts in batches of batchSize.
+func embedAll(ctx context.Context, e Embedder, texts []string) ([][]float32, error) {
+	vecs := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		batch, err := e.Embed(ctx, texts[start:min(start+batchSize, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		vecs = append(vecs, batch...)
+	}
+	return vecs, nil
+}
+
+// chunks splits a document into passages of about chunkChars at word boundaries, the first
+// led by the title, up to maxChunks.
+func chunks(title, text string) []string {
+	words := strings.Fields(title + "\n" + text)
+	var chunks []string
+	var b strings.Builder
+	for _, w := range words {
+		if b.Len() > 0 && b.Len()+1+len(w) > chunkChars {
+			chunks = append(chunks, b.String())
+			if len(chunks) == maxChunks {
+				return chunks
+			}
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(w)
+	}
+	if b.Len() > 0 {
+		chunks = append(chunks, b.String())
+	}
+	return chunks
+}
+
+// cosine returns the cosine similarity of a and b, 0 if either is empty or their lengths differ.
+func cosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,143 @@
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeEmbedder returns a fixed vector for each text containing one of its keys, [0, 1]
+// otherwise, and records the size of each request.
+type fakeEmbedder struct {
+	vectors map[string][]float32
+	batches []int
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.batches = append(f.batches, len(texts))
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vecs[i] = []float32{0, 1}
+		for key, v := range f.vectors {
+			if strings.Contains(text, key) {
+				vecs[i] = v
+			}
+		}
+	}
+	return vecs, nil
+}
+
+func TestChunks(t *testing.T) {
+	if got := chunks("Capital Raising", "Placement to raise $20m."); len(got) != 1 || got[0] != "Capital Raising Placement to raise $20m." {
+		t.Errorf("short document: got %q", got)
+	}
+	if got := chunks("", ""); len(got) != 0 {
+		t.Errorf("empty document: got %q", got)
+	}
+
+	word := strings.Repeat("x", 99)
+	text := strings.TrimSpace(strings.Repeat(word+" ", 40)) // 4000 chars
+	got := chunks("Title", text)
+	if len(got) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(got))
+	}
+	if !strings.HasPrefix(got[0], "Title "+word) {
+		t.Errorf("first chunk does not lead with the title: %.20q", got[0])
+	}
+	for i, c := range got {
+		if len(c) > chunkChars {
+			t.Errorf("chunk %d is %d chars, over %d", i, len(c), chunkChars)
+		}
+		for _, w := range strings.Fields(c) {
+			if w != word && w != "Title" {
+				t.Errorf("chunk %d split a word: %.20q", i, w)
+				break
+			}
+		}
+	}
+
+	long := strings.Repeat(word+" ", 40*maxChunks)
+	if got := chunks("", long); len(got) != maxChunks {
+		t.Errorf("long document: got %d chunks, want %d", len(got), maxChunks)
+	}
+}
+
+func TestEmbedAllBatches(t *testing.T) {
+	texts := make([]string, 2*batchSize+50)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+
+	e := &fakeEmbedder{}
+	vecs, err := embedAll(context.Background(), e, texts)
+	if err != nil {
+		t.Fatalf("embedAll: %v", err)
+	}
+	if len(vecs) != len(texts) {
+		t.Errorf("got %d vectors for %d texts", len(vecs), len(texts))
+	}
+	if want := []int{batchSize, batchSize, 50}; fmt.Sprint(e.batches) != fmt.Sprint(want) {
+		t.Errorf("batches = %v, want %v", e.batches, want)
+	}
+}
+
+func TestMatcherThreshold(t *testing.T) {
+	e := &fakeEmbedder{vectors: map[string][]float32{
+		"capital raise": {1, 0},
+		"placement":     {4, 3}, // similarity 0.8 to "capital raise"
+		"entitlement":   {3, 4}, // similarity 0.6
+	}}
+	ctx := context.Background()
+
+	m := NewMatcher([]string{"capital raise"}, 0, e)
+	if m.threshold != DefaultThreshold {
+		t.Errorf("threshold = %v, want the default %v", m.threshold, DefaultThreshold)
+	}
+
+	got, err := m.Match(ctx, "Placement", "The company completed a placement to institutional investors.")
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+	if len(got) != 1 || got[0].Query != "capital raise" || got[0].Similarity < 0.79 {
+		t.Errorf("0.8 similarity at the default threshold: got %+v, want a match", got)
+	}
+
+	got, err = m.Match(ctx, "Offer", "A renounceable entitlement offer opens today.")
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("0.6 similarity at the default threshold: got %+v, want no match", got)
+	}
+
+	// The threshold is inclusive.
+	exact := NewMatcher([]string{"capital raise"}, 0.8, e)
+	if got, _ := exact.Match(ctx, "Placement", "placement"); len(got) != 1 {
+		t.Errorf("similarity equal to the threshold: got %+v, want a match", got)
+	}
+}
+
+func TestMatcherBestPassage(t *testing.T) {
+	e := &fakeEmbedder{vectors: map[string][]float32{
+		"capital raise": {1, 0},
+		"drilling":      {0, 1},
+		"placement":     {4, 3},
+	}}
+	m := NewMatcher([]string{"drilling", "capital raise"}, 0, e)
+
+	filler := strings.TrimSpace(strings.Repeat("quarterly update ", 200))
+	got, err := m.Match(context.Background(), "Quarterly", filler+" The company also completed a placement.")
+	if err != nil {
+		t.Fatalf("Match: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(got), got)
+	}
+	if got[0].Query != "drilling" || got[1].Query != "capital raise" {
+		t.Errorf("results not ordered by similarity: %+v", got)
+	}
+	if !strings.Contains(got[1].Passage, "placement") {
+		t.Errorf("capital raise passage = %.40q, want the one mentioning the placement", got[1].Passage)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Package shorts reads ASIC's daily aggregated short position reports, which give each ASX
+product's reported short positions as a percentage of its issued capital.
+
+ASIC publishes each report about four business days after the positions date, so the latest
+report is found by walking back from today.
+*/
+package shorts
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	reportURLFormat = "https://download.asic.gov.au/short-selling/RR%s-001-SSDailyAggShortPos.csv"
+	lookbackDays    = 14
+	requestTimeout  = 30 * time.Second
+)
+
+var client = &http.Client{Timeout: requestTimeout}
+
+// Position is a product's aggregated short position on the report date.
+type Position struct {
+	Code    string    `json:"code"`
+	Short   int64     `json:"short"`   // reported short positions
+	Issued  int64     `json:"issued"`  // total product in issue
+	Percent float64   `json:"percent"` // % of issued capital reported short
+	AsOf    time.Time `json:"as_of"`   // positions date of the report
+}
+
+// String describes the position, e.g. "8.42% of issued capital short (ASIC, 10 Oct)".
+func (p *Position) String() string {
+	return fmt.Sprintf("%.2f%% of issued capital short (ASIC, %s)", p.Percent, p.AsOf.Format("02 Jan"))
+}
+
+// Report is one day's short positions, keyed by ASX code.
+type Report struct {
+	Date      time.Time
+	positions map[string]*Position
+}
+
+// Lookup returns the short position for an ASX code.
+func (r *Report) Lookup(code string) (*Position, bool) {
+	if r == nil {
+		return nil, false
+	}
+	p, ok := r.positions[strings.ToUpper(code)]
+	return p, ok
+}
+
+// Latest returns the most recent report published on or before now. Reports are cached in
+// cacheDir since they never change once published.
+func Latest(ctx context.Context, cacheDir string, now time.Time) (*Report, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var lastErr error
+	for i := 0; i < lookbackDays; i++ {
+		date := day.AddDate(0, 0, -i)
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+
+		data, err := loadReport(ctx, cacheDir, date)
+		if errors.Is(err, errNotPublished) {
+			continue
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return parseReport(data, date)
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("no ASIC short position report in the last %d days", lookbackDays)
+}
+
+var errNotPublished = errors.New("report not published")
+
+func loadReport(ctx context.Context, cacheDir string, date time.Time) ([]byte, error) {
+	stamp := date.Format("20060102")
+	path := filepath.Join(cacheDir, "asic_shorts_"+stamp+".csv")
+	if data, err := os.ReadFile(path); err == nil {
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(reportURLFormat, stamp), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download ASIC short report for %s: %w", stamp, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return nil, errNotPublished
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download ASIC short report for %s: received status code %d", stamp, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ASIC short report for %s: %w", stamp, err)
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err == nil {
+		_ = os.WriteFile(path, data, 0o644)
+	}
+	return data, nil
+}
+
+// parseReport reads a report. Older reports are UTF-16 and tab separated, newer ones UTF-8
+// CSV, so both are accepted and columns are located by header name.
+func parseReport(data []byte, date time.Time) (*Report, error) {
+	data = decodeUTF16(data)
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Contains(firstLine, []byte("\t")) {
+		r.Comma = '\t'
+	}
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ASIC short report header: %w", err)
+	}
+
+	codeCol, shortCol, issuedCol, pctCol := -1, -1, -1, -1
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		switch {
+		case h == "product code":
+			codeCol = i
+		case strings.HasPrefix(h, "reported short positions"):
+			shortCol = i
+		case strings.HasPrefix(h, "total product in issue"):
+			issuedCol = i
+		case strings.HasPrefix(h, "%"):
+			pctCol = i
+		}
+	}
+	if codeCol < 0 || pctCol < 0 {
+		return nil, errors.New("ASIC short report has no 'Product Code' and '%' columns")
+	}
+
+	report := &Report{Date: date, positions: make(map[string]*Position)}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ASIC short report: %w", err)
+		}
+		if codeCol >= len(record) || pctCol >= len(record) {
+			continue
+		}
+
+		p := &Position{Code: strings.ToUpper(strings.TrimSpace(record[codeCol])), AsOf: date}
+		p.Percent, _ = strconv.ParseFloat(strings.TrimSpace(record[pctCol]), 64)
+		if shortCol >= 0 && shortCol < len(record) {
+			p.Short = parseInt(record[shortCol])
+		}
+		if issuedCol >= 0 && issuedCol < len(record) {
+			p.Issued = parseInt(record[issuedCol])
+		}
+		if p.Code != "" {
+			report.positions[p.Code] = p
+		}
+	}
+	return report, nil
+}
+
+func parseInt(s string) int64 {
+	v, _ := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 10, 64)
+	return v
+}
+
+// decodeUTF16 converts UTF-16LE data with a byte order mark to UTF-8.
+func decodeUTF16(data []byte) []byte {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xFE {
+		return data
+	}
+	data = data[2:]
+	u := make([]uint16, len(data)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	return []byte(string(utf16.Decode(u)))
+}
@@ -0,0 +1,184 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// durationBounds are the histogram bucket boundaries in seconds, from feed requests to slow
+// AI calls.
+var durationBounds = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// stageMetrics accumulates the cumulative duration histogram and error count of a stage.
+type stageMetrics struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+	errors  uint64
+}
+
+func newStageMetrics() *stageMetrics {
+	return &stageMetrics{buckets: make([]uint64, len(durationBounds)+1)}
+}
+
+func (m *stageMetrics) observe(d time.Duration, failed bool) {
+	seconds := d.Seconds()
+	m.count++
+	m.sum += seconds
+	i, _ := slices.BinarySearch(durationBounds, seconds)
+	m.buckets[i]++
+	if failed {
+		m.errors++
+	}
+}
+
+// OTLP JSON encoding. 64-bit integers are strings and IDs are hex, as the spec requires.
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+func otlpAttributes(attrs map[string]string) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, k := range slices.Sorted(maps.Keys(attrs)) {
+		kv := otlpKeyValue{Key: k}
+		kv.Value.StringValue = attrs[k]
+		kvs = append(kvs, kv)
+	}
+	return kvs
+}
+
+func (e *exporter) resource() map[string]any {
+	return map[string]any{"attributes": otlpAttributes(map[string]string{"service.name": e.cfg.ServiceName})}
+}
+
+var scope = map[string]string{"name": "github.com/shanehull/annscraper"}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func (e *exporter) tracesPayload(spans []*Span) any {
+	otlpSpans := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		span := map[string]any{
+			"traceId":           s.traceID,
+			"spanId":            s.spanID,
+			"name":              s.name,
+			"kind":              1, // internal
+			"startTimeUnixNano": unixNano(s.start),
+			"endTimeUnixNano":   unixNano(s.end),
+			"attributes":        otlpAttributes(s.attrs),
+		}
+		if s.parentID != "" {
+			span["parentSpanId"] = s.parentID
+		}
+		if s.err != nil {
+			span["status"] = map[string]any{"code": 2, "message": s.err.Error()}
+		}
+		otlpSpans = append(otlpSpans, span)
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource":   e.resource(),
+			"scopeSpans": []any{map[string]any{"scope": scope, "spans": otlpSpans}},
+		}},
+	}
+}
+
+// metricsPayload returns the cumulative stage metrics, or nil before any span has ended.
+// The caller holds e.mutex.
+func (e *exporter) metricsPayload(now time.Time) any {
+	if len(e.stages) == 0 {
+		return nil
+	}
+
+	var durations, errors []map[string]any
+	for _, stage := range slices.Sorted(maps.Keys(e.stages)) {
+		m := e.stages[stage]
+		attrs := otlpAttributes(map[string]string{"stage": stage})
+
+		buckets := make([]string, len(m.buckets))
+		for i, b := range m.buckets {
+			buckets[i] = strconv.FormatUint(b, 10)
+		}
+		durations = append(durations, map[string]any{
+			"attributes":        attrs,
+			"startTimeUnixNano": unixNano(e.startedAt),
+			"timeUnixNano":      unixNano(now),
+			"count":             strconv.FormatUint(m.count, 10),
+			"sum":               m.sum,
+			"bucketCounts":      buckets,
+			"explicitBounds":    durationBounds,
+		})
+		errors = append(errors, map[string]any{
+			"attributes":        attrs,
+			"startTimeUnixNano": unixNano(e.startedAt),
+			"timeUnixNano":      unixNano(now),
+			"asInt":             strconv.FormatUint(m.errors, 10),
+		})
+	}
+
+	const cumulative = 2
+	metrics := []any{
+		map[string]any{
+			"name":        "annscraper.stage.duration",
+			"description": "Duration of each pipeline stage",
+			"unit":        "s",
+			"histogram":   map[string]any{"aggregationTemporality": cumulative, "dataPoints": durations},
+		},
+		map[string]any{
+			"name":        "annscraper.stage.errors",
+			"description": "Failed pipeline stages",
+			"unit":        "{error}",
+			"sum":         map[string]any{"aggregationTemporality": cumulative, "isMonotonic": true, "dataPoints": errors},
+		},
+	}
+
+	return map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource":     e.resource(),
+			"scopeMetrics": []any{map[string]any{"scope": scope, "metrics": metrics}},
+		}},
+	}
+}
+
+func (e *exporter) post(path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.cfg.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+/*
+Package telemetry records spans for each stage of the pipeline and exports them, with
+per-stage duration and error metrics, to an OpenTelemetry collector over OTLP/HTTP.
+
+The OTLP JSON encoding is written directly to keep the binary free of the OpenTelemetry
+SDK. Until Init is called every function is a cheap no-op.
+*/
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	exportInterval = 10 * time.Second
+	maxQueuedSpans = 2048 // spans beyond this between exports are dropped
+)
+
+// Config selects the collector to export to.
+type Config struct {
+	Endpoint    string            // base OTLP/HTTP URL, e.g. http://localhost:4318
+	Headers     map[string]string // sent with every export, e.g. an API key
+	ServiceName string
+}
+
+// ConfigFromEnv reads the standard OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS
+// and OTEL_SERVICE_NAME variables.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		Headers:     make(map[string]string),
+	}
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			cfg.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return cfg
+}
+
+// Span is a timed pipeline stage. A nil Span records nothing.
+type Span struct {
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    map[string]string
+	err      error
+}
+
+type spanKey struct{}
+
+// Start begins a span named name as a child of the span in ctx, if any.
+// Attributes are given as alternating keys and values.
+func Start(ctx context.Context, name string, attrs ...string) (context.Context, *Span) {
+	if current() == nil {
+		return ctx, nil
+	}
+
+	s := &Span{name: name, start: time.Now(), spanID: randomHex(8), attrs: make(map[string]string)}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		s.traceID = randomHex(16)
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.attrs[attrs[i]] = attrs[i+1]
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// SetAttr adds an attribute to the span.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.attrs[key] = fmt.Sprint(value)
+}
+
+// End finishes the span, marking it failed when err is non-nil.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+	if e := current(); e != nil {
+		e.record(s)
+	}
+}
+
+// exporter queues finished spans, aggregates their metrics and posts both periodically.
+type exporter struct {
+	cfg       Config
+	startedAt time.Time
+
+	mutex   sync.Mutex
+	spans   []*Span
+	dropped int
+	stages  map[string]*stageMetrics
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var (
+	exporterMutex sync.RWMutex
+	active        *exporter
+)
+
+func current() *exporter {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	return active
+}
+
+// Init starts exporting to cfg.Endpoint. An empty endpoint leaves telemetry disabled.
+func Init(cfg Config) {
+	if cfg.Endpoint == "" {
+		return
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "annscraper"
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+
+	e := &exporter{
+		cfg:       cfg,
+		startedAt: time.Now(),
+		stages:    make(map[string]*stageMetrics),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	exporterMutex.Lock()
+	active = e
+	exporterMutex.Unlock()
+
+	go e.run()
+	log.Printf("Exporting traces and metrics to %s.", cfg.Endpoint)
+}
+
+// Shutdown exports anything pending and stops the exporter.
+func Shutdown() {
+	exporterMutex.Lock()
+	e := active
+	active = nil
+	exporterMutex.Unlock()
+	if e == nil {
+		return
+	}
+	close(e.stop)
+	<-e.done
+}
+
+func (e *exporter) record(s *Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if len(e.spans) < maxQueuedSpans {
+		e.spans = append(e.spans, s)
+	} else {
+		e.dropped++
+	}
+
+	m := e.stages[s.name]
+	if m == nil {
+		m = newStageMetrics()
+		e.stages[s.name] = m
+	}
+	m.observe(s.end.Sub(s.start), s.err != nil)
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.export()
+		case <-e.stop:
+			e.export()
+			return
+		}
+	}
+}
+
+// export posts the queued spans and the current metric totals.
+func (e *exporter) export() {
+	e.mutex.Lock()
+	spans := e.spans
+	e.spans = nil
+	if e.dropped > 0 {
+		log.Printf("Warning: dropped %d telemetry spans, the export queue was full", e.dropped)
+		e.dropped = 0
+	}
+	metrics := e.metricsPayload(time.Now())
+	e.mutex.Unlock()
+
+	if len(spans) > 0 {
+		if err := e.post("/v1/traces", e.tracesPayload(spans)); err != nil {
+			log.Printf("Warning: failed to export traces: %v", err)
+		}
+	}
+	if metrics != nil {
+		if err := e.post("/v1/metrics", metrics); err != nil {
+			log.Printf("Warning: failed to export metrics: %v", err)
+		}
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
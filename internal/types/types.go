@@ -4,15 +4,25 @@ Package types defines the core data structures used in the annscraper applicatio
 package types
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/shanehull/annscraper/internal/ai"
+	"github.com/shanehull/annscraper/internal/forms"
+	"github.com/shanehull/annscraper/internal/geo"
+	"github.com/shanehull/annscraper/internal/prices"
+	"github.com/shanehull/annscraper/internal/shorts"
+	"github.com/shanehull/annscraper/internal/xbrl"
 )
 
 const TickerMatchPlaceholder = "__TICKER_MATCHED__"
 
 type Announcement struct {
 	Ticker           string
+	CompanyName      string  // from the ASX company directory, "" if unknown
+	Sector           string  // GICS sector from the company directory
+	MarketCap        float64 // AUD from the company directory, 0 = unknown
 	DateTime         time.Time
 	Title            string
 	PDFURL           string
@@ -24,9 +34,66 @@ type Match struct {
 	KeywordsFound []string
 	TickerMatched bool
 	Context       string
+	Financials    *xbrl.Financials        // set when the lodgement carried structured (XBRL) results
+	Geo           geo.Tags                // states, regions and commodities mentioned
+	Insider       *forms.DirectorInterest // set for parsed Appendix 3Y director interest notices
+	CashFlow      *forms.CashFlow         // set for parsed Appendix 4C/5B quarterly cash flow reports
+	FollowsHalt   *Announcement           // the trading halt this is the first announcement after
+	Reaction      *prices.Reaction        // price and volume move since release, when requested
+	Short         *shorts.Position        // latest ASIC short position, when requested
+	KeywordPages  map[string]int          // 1-based page each keyword was first found on, for paged documents
+	DocumentHash  string                  // SHA-256 of the extracted text, identifying the same document across tickers
+	Changes       *Changes                // what changed since the previous report of the same kind, when requested
+	JointTickers  []string                // other tickers the same document was released under
+}
+
+// Tickers returns the ticker, followed by any joint tickers, e.g. "BHP/RIO".
+func (m Match) Tickers() string {
+	return strings.Join(append([]string{m.Ticker}, m.JointTickers...), "/")
+}
+
+// Page returns the page the first keyword was found on, 0 if unknown.
+func (m Match) Page() int {
+	if len(m.KeywordsFound) == 0 {
+		return 0
+	}
+	return m.KeywordPages[m.KeywordsFound[0]]
+}
+
+// DocumentURL links to the attachment, opening at the matching page where known.
+func (m Match) DocumentURL() string {
+	if page := m.Page(); page > 0 {
+		return fmt.Sprintf("%s#page=%d", m.PDFURL, page)
+	}
+	return m.PDFURL
+}
+
+// Changes is what changed in a recurring report, such as a quarterly cash flow report or an
+// investor presentation, since the company's previous one.
+type Changes struct {
+	Kind          string // e.g. "Appendix 4C"
+	PreviousTitle string
+	PreviousURL   string
+	PreviousDate  time.Time
+	Added         []string // lines new in this report
+	Removed       []string // lines from the previous report no longer present
+}
+
+// Summary lists the added and removed lines, "+ " and "- " prefixed.
+func (c *Changes) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Compared with %s (%s):\n", c.PreviousTitle, c.PreviousDate.Format("02 Jan 2006"))
+	for _, line := range c.Added {
+		b.WriteString("+ " + line + "\n")
+	}
+	for _, line := range c.Removed {
+		b.WriteString("- " + line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
 }
 
 type AnnotatedMatch struct {
 	Match    Match
 	Analysis *ai.AIAnalysis
+	Model    string // the AI model that produced Analysis
 }
@@ -0,0 +1,269 @@
+/*
+Package xbrl extracts headline results (revenue, NPAT and EPS) from XBRL instance documents
+and inline XBRL (iXBRL) lodgements so that period-on-period changes can be alerted on precisely.
+*/
+package xbrl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// conceptAliases maps each headline metric to the IFRS concepts that may report it, in priority order.
+var conceptAliases = map[string][]string{
+	"revenue": {"Revenue", "RevenueFromContractsWithCustomers", "RevenueFromSaleOfGoods"},
+	"npat":    {"ProfitLossAttributableToOwnersOfParent", "ProfitLoss"},
+	"eps":     {"BasicEarningsLossPerShare", "BasicAndDilutedEarningsLossPerShare"},
+}
+
+type Metric struct {
+	Name    string  `json:"name"`
+	Current float64 `json:"current"`
+	Prior   float64 `json:"prior"`
+	Found   bool    `json:"found"` // both current and prior periods were reported
+}
+
+// Change returns the percentage change from the prior to the current period.
+func (m Metric) Change() float64 {
+	if !m.Found || m.Prior == 0 {
+		return 0
+	}
+	return (m.Current - m.Prior) / math.Abs(m.Prior) * 100
+}
+
+func (m Metric) String() string {
+	if !m.Found {
+		return m.Name + " n/a"
+	}
+	return fmt.Sprintf("%s %s (%+.1f%%)", m.Name, formatValue(m.Current), m.Change())
+}
+
+type Financials struct {
+	PeriodEnd string `json:"period_end"`
+	Revenue   Metric `json:"revenue"`
+	NPAT      Metric `json:"npat"`
+	EPS       Metric `json:"eps"`
+}
+
+// Metrics returns the headline metrics in display order.
+func (f *Financials) Metrics() []Metric {
+	return []Metric{f.Revenue, f.NPAT, f.EPS}
+}
+
+// Summary renders the financials as a single line of text suitable for matching and AI prompts.
+func (f *Financials) Summary() string {
+	var parts []string
+	for _, m := range f.Metrics() {
+		parts = append(parts, m.String())
+	}
+	return fmt.Sprintf("Structured results for period ending %s: %s", f.PeriodEnd, strings.Join(parts, ", "))
+}
+
+// Exceeding returns a description of each metric whose absolute change is at least thresholdPct.
+func (f *Financials) Exceeding(thresholdPct float64) []string {
+	if thresholdPct <= 0 {
+		return nil
+	}
+
+	var hits []string
+	for _, m := range f.Metrics() {
+		if m.Found && math.Abs(m.Change()) >= thresholdPct {
+			hits = append(hits, fmt.Sprintf("%s %+.1f%%", m.Name, m.Change()))
+		}
+	}
+	return hits
+}
+
+// IsXBRL reports whether the document looks like an XBRL instance or inline XBRL file.
+func IsXBRL(data []byte) bool {
+	head := data[:min(len(data), 4096)]
+	return bytes.Contains(head, []byte("xbrli:xbrl")) ||
+		bytes.Contains(head, []byte("<xbrl")) ||
+		bytes.Contains(head, []byte("http://www.xbrl.org/2013/inlineXBRL"))
+}
+
+type fact struct {
+	context string
+	value   float64
+}
+
+// Parse reads the headline metrics from an XBRL or iXBRL document. The two most recent
+// duration periods are treated as current and prior.
+func Parse(data []byte) (*Financials, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	periods := make(map[string]string) // context id -> period end date
+	facts := make(map[string][]fact)   // concept local name -> facts
+
+	var contextID string
+	var inEndDate, inInstant bool
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XBRL: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "context":
+				contextID = attr(t, "id")
+			case "endDate":
+				inEndDate = true
+			case "instant":
+				inInstant = true
+			case "nonFraction":
+				// Inline XBRL: <ix:nonFraction name="ifrs-full:Revenue" contextRef="..." scale="6" sign="-">
+				name := localName(attr(t, "name"))
+				var text string
+				if err := dec.DecodeElement(&text, &t); err != nil {
+					return nil, fmt.Errorf("failed to read inline fact %s: %w", name, err)
+				}
+				if v, ok := parseInlineValue(text, attr(t, "scale"), attr(t, "sign")); ok {
+					facts[name] = append(facts[name], fact{context: attr(t, "contextRef"), value: v})
+				}
+			default:
+				// XBRL instance: <ifrs-full:Revenue contextRef="..." decimals="-3">123000</ifrs-full:Revenue>
+				ref := attr(t, "contextRef")
+				if ref == "" {
+					continue
+				}
+				var text string
+				if err := dec.DecodeElement(&text, &t); err != nil {
+					return nil, fmt.Errorf("failed to read fact %s: %w", t.Name.Local, err)
+				}
+				if v, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+					facts[t.Name.Local] = append(facts[t.Name.Local], fact{context: ref, value: v})
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "endDate":
+				inEndDate = false
+			case "instant":
+				inInstant = false
+			case "context":
+				contextID = ""
+			}
+		case xml.CharData:
+			if contextID != "" && (inEndDate || inInstant) {
+				periods[contextID] = strings.TrimSpace(string(t))
+			}
+		}
+	}
+
+	fin := &Financials{
+		Revenue: metricFor("revenue", "Revenue", facts, periods),
+		NPAT:    metricFor("npat", "NPAT", facts, periods),
+		EPS:     metricFor("eps", "EPS", facts, periods),
+	}
+
+	if ids := sortedPeriods(periods); len(ids) > 0 {
+		fin.PeriodEnd = periods[ids[0]]
+	}
+
+	if !fin.Revenue.Found && !fin.NPAT.Found && !fin.EPS.Found {
+		return nil, fmt.Errorf("no comparable revenue, NPAT or EPS facts found")
+	}
+	return fin, nil
+}
+
+func metricFor(key, display string, facts map[string][]fact, periods map[string]string) Metric {
+	m := Metric{Name: display}
+
+	for _, concept := range conceptAliases[key] {
+		byEnd := make(map[string]float64)
+		for _, f := range facts[concept] {
+			if end, ok := periods[f.context]; ok {
+				if _, seen := byEnd[end]; !seen {
+					byEnd[end] = f.value
+				}
+			}
+		}
+		if len(byEnd) < 2 {
+			continue
+		}
+
+		ends := make([]string, 0, len(byEnd))
+		for end := range byEnd {
+			ends = append(ends, end)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(ends)))
+
+		m.Current = byEnd[ends[0]]
+		m.Prior = byEnd[ends[1]]
+		m.Found = true
+		return m
+	}
+	return m
+}
+
+// sortedPeriods returns context ids ordered by period end date, most recent first.
+func sortedPeriods(periods map[string]string) []string {
+	ids := make([]string, 0, len(periods))
+	for id := range periods {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return periods[ids[i]] > periods[ids[j]] })
+	return ids
+}
+
+func parseInlineValue(text, scale, sign string) (float64, bool) {
+	cleaned := strings.NewReplacer(",", "", " ", "", "(", "", ")", "").Replace(strings.TrimSpace(text))
+	v, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, false
+	}
+	if scale != "" {
+		if exp, err := strconv.Atoi(scale); err == nil {
+			v *= math.Pow10(exp)
+		}
+	}
+	if sign == "-" {
+		v = -v
+	}
+	return v, true
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func localName(qname string) string {
+	if i := strings.LastIndex(qname, ":"); i >= 0 {
+		return qname[i+1:]
+	}
+	return qname
+}
+
+func formatValue(v float64) string {
+	switch abs := math.Abs(v); {
+	case abs >= 1e9:
+		return fmt.Sprintf("%.2fb", v/1e9)
+	case abs >= 1e6:
+		return fmt.Sprintf("%.2fm", v/1e6)
+	case abs >= 1e3:
+		return fmt.Sprintf("%.1fk", v/1e3)
+	default:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+}